@@ -0,0 +1,160 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 日志存储类型
+const (
+	TypeJSONL  = "jsonl"
+	TypeSQLite = "sqlite"
+)
+
+// Entry 一条审计日志：谁、何时、对哪个 Zone 做了什么，以及变更前后的值
+type Entry struct {
+	Time    time.Time       `json:"time"`
+	Actor   string          `json:"actor"`             // 操作者（Cloudflare 账户邮箱或账户名称）
+	Account string          `json:"account,omitempty"` // 执行操作的凭证标识（service.CredentialID），不含密钥明文
+	IP      string          `json:"ip"`
+	ZoneID  string          `json:"zone_id,omitempty"`
+	Zone    string          `json:"zone,omitempty"` // 域名
+	Action  string          `json:"action"`         // 处理函数名，如 AddRecord
+	Target  string          `json:"target,omitempty"`
+	Before  json.RawMessage `json:"before,omitempty"`
+	After   json.RawMessage `json:"after,omitempty"`
+	Success bool            `json:"success"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// BeforeText 格式化的变更前快照（用于页面展示）
+func (e Entry) BeforeText() string {
+	return indent(e.Before)
+}
+
+// AfterText 格式化的变更后快照（用于页面展示）
+func (e Entry) AfterText() string {
+	return indent(e.After)
+}
+
+// Filter 查询条件，空值表示不限制
+type Filter struct {
+	Zone string // 匹配 Zone ID 或域名
+	// Account 与 ZoneIDs 用于把结果限制在当前账户范围内：条目由该凭证写入，或属于其中一个 Zone。
+	// 按凭证匹配的条目包括已删除的 Zone 和没有 Zone 的操作；两者都为空时不限制
+	Account string
+	ZoneIDs map[string]bool
+	Action  string
+	Actor   string // 子串匹配
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+// Scoped 是否按账户限制结果
+func (f Filter) Scoped() bool {
+	return f.Account != "" || f.ZoneIDs != nil
+}
+
+// Match 判断条目是否满足查询条件
+func (f Filter) Match(e Entry) bool {
+	if f.Zone != "" && f.Zone != e.ZoneID && !strings.EqualFold(f.Zone, e.Zone) {
+		return false
+	}
+	if f.Scoped() && !(f.Account != "" && f.Account == e.Account) && !f.ZoneIDs[e.ZoneID] {
+		return false
+	}
+	if f.Action != "" && f.Action != e.Action {
+		return false
+	}
+	if f.Actor != "" && !strings.Contains(strings.ToLower(e.Actor), strings.ToLower(f.Actor)) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Logger 只追加的审计日志
+type Logger interface {
+	// Append 追加一条日志
+	Append(entry Entry) error
+	// Query 按时间倒序返回满足条件的日志
+	Query(filter Filter) ([]Entry, error)
+	Close() error
+}
+
+// New 根据类型创建审计日志
+func New(kind, path string) (Logger, error) {
+	if path == "" {
+		return nil, fmt.Errorf("audit log path is required")
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+		}
+	}
+
+	switch kind {
+	case TypeJSONL, "":
+		return NewJSONL(path)
+	case TypeSQLite:
+		return NewSQLite(path)
+	default:
+		return nil, fmt.Errorf("unknown audit log type: %s", kind)
+	}
+}
+
+// Snapshot 将任意值序列化为快照，nil 返回空
+func Snapshot(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// Actions 支持按操作过滤的全部操作名
+var Actions = []string{
+	"AddZone",
+	"DeleteZone",
+	"AddRecord",
+	"EditRecord",
+	"DeleteRecord",
+	"ToggleProxy",
+	"ImportZoneFile",
+	"RestoreSnapshot",
+	"UpdateSetting",
+	"ApplyPreset",
+	"UpdateDNSSEC",
+	"PurgeCache",
+	"CreateOriginCertificate",
+	"RevokeOriginCertificate",
+}
+
+// indent 格式化 JSON
+func indent(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return string(raw)
+	}
+	return string(out)
+}
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testLogger(t *testing.T, logger Logger) {
+	t.Helper()
+	defer logger.Close()
+
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []Entry{
+		{Time: base, Actor: "alice@example.com", ZoneID: "zone-a", Zone: "a.example", Action: "AddRecord", Success: true},
+		{Time: base.Add(time.Minute), Actor: "bob@example.com", ZoneID: "zone-b", Zone: "b.example", Action: "DeleteRecord", Success: true},
+		{Time: base.Add(2 * time.Minute), Actor: "alice@example.com", ZoneID: "zone-a", Zone: "a.example", Action: "EditRecord", Before: []byte(`{"content":"192.0.2.1"}`)},
+		// 以下条目由凭证写入：已删除的 Zone、没有 Zone 的操作，以及另一个凭证的操作
+		{Time: base.Add(3 * time.Minute), Actor: "alice@example.com", Account: "key:alice@example.com", ZoneID: "zone-c", Zone: "c.example", Action: "DeleteZone", Success: true},
+		{Time: base.Add(4 * time.Minute), Actor: "alice@example.com", Account: "key:alice@example.com", Zone: "d.example", Action: "AddZone", Error: "zone already exists"},
+		{Time: base.Add(5 * time.Minute), Actor: "carol@example.com", Account: "key:carol@example.com", Action: "CreateOriginCertificate", Success: true},
+	}
+	for _, entry := range entries {
+		if err := logger.Append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		actions []string
+	}{
+		{"all, newest first", Filter{}, []string{"CreateOriginCertificate", "AddZone", "DeleteZone", "EditRecord", "DeleteRecord", "AddRecord"}},
+		{"limit", Filter{Limit: 1}, []string{"CreateOriginCertificate"}},
+		{"zone name", Filter{Zone: "B.EXAMPLE"}, []string{"DeleteRecord"}},
+		{"actor substring", Filter{Actor: "ALICE"}, []string{"AddZone", "DeleteZone", "EditRecord", "AddRecord"}},
+		{"since", Filter{Since: base.Add(30 * time.Second), Until: base.Add(3 * time.Minute)}, []string{"EditRecord", "DeleteRecord"}},
+		{"accessible zones", Filter{ZoneIDs: map[string]bool{"zone-a": true}}, []string{"EditRecord", "AddRecord"}},
+		{"accessible zones and zone filter", Filter{Zone: "zone-b", ZoneIDs: map[string]bool{"zone-a": true}}, nil},
+		{"no accessible zones", Filter{ZoneIDs: map[string]bool{}}, nil},
+		{"own account keeps deleted zone and zoneless entries", Filter{Account: "key:alice@example.com", ZoneIDs: map[string]bool{"zone-a": true}}, []string{"AddZone", "DeleteZone", "EditRecord", "AddRecord"}},
+		{"own account without accessible zones", Filter{Account: "key:alice@example.com", ZoneIDs: map[string]bool{}}, []string{"AddZone", "DeleteZone"}},
+		{"own account and action filter", Filter{Account: "key:alice@example.com", ZoneIDs: map[string]bool{}, Action: "DeleteZone"}, []string{"DeleteZone"}},
+		{"other account", Filter{Account: "key:carol@example.com", ZoneIDs: map[string]bool{"zone-b": true}}, []string{"CreateOriginCertificate", "DeleteRecord"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := logger.Query(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.actions) {
+				t.Fatalf("got %d entries, want %v", len(got), tt.actions)
+			}
+			for i, entry := range got {
+				if entry.Action != tt.actions[i] {
+					t.Errorf("entry %d = %s, want %s", i, entry.Action, tt.actions[i])
+				}
+			}
+		})
+	}
+
+	got, _ := logger.Query(Filter{Action: "EditRecord"})
+	if len(got) != 1 || got[0].BeforeText() == "" || !got[0].Time.Equal(entries[2].Time) {
+		t.Errorf("EditRecord entry = %+v", got)
+	}
+	got, _ = logger.Query(Filter{Action: "AddZone"})
+	if len(got) != 1 || got[0].Account != "key:alice@example.com" || got[0].ZoneID != "" || got[0].Error == "" {
+		t.Errorf("AddZone entry = %+v", got)
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	logger, err := NewJSONL(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testLogger(t, logger)
+}
+
+func TestSQLite(t *testing.T) {
+	logger, err := NewSQLite(filepath.Join(t.TempDir(), "audit.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testLogger(t, logger)
+}
+
+// TestSQLiteMigratesAccount 没有 account 列的旧数据库在打开时补充该列，旧条目仍按 Zone 显示
+func TestSQLiteMigratesAccount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time INTEGER NOT NULL, actor TEXT NOT NULL, ip TEXT NOT NULL,
+		zone_id TEXT NOT NULL, zone TEXT NOT NULL, action TEXT NOT NULL, target TEXT NOT NULL,
+		before TEXT, after TEXT, success INTEGER NOT NULL, error TEXT NOT NULL
+	);
+	INSERT INTO audit_log (time, actor, ip, zone_id, zone, action, target, success, error)
+		VALUES (1, 'alice@example.com', '', 'zone-a', 'a.example', 'AddRecord', '', 1, '')`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger, err := NewSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	if err := logger.Append(Entry{Time: time.Now(), Account: "key:alice@example.com", Action: "AddZone"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := logger.Query(Filter{Account: "key:alice@example.com", ZoneIDs: map[string]bool{"zone-a": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Action != "AddZone" || got[1].Action != "AddRecord" || got[1].Account != "" {
+		t.Errorf("entries after migration = %+v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLite 将日志写入 SQLite 数据库（只插入，不更新、不删除）
+type SQLite struct {
+	db *sql.DB
+}
+
+// NewSQLite 打开（或创建）日志数据库
+func NewSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time INTEGER NOT NULL,
+		actor TEXT NOT NULL,
+		account TEXT NOT NULL DEFAULT '',
+		ip TEXT NOT NULL,
+		zone_id TEXT NOT NULL,
+		zone TEXT NOT NULL,
+		action TEXT NOT NULL,
+		target TEXT NOT NULL,
+		before TEXT,
+		after TEXT,
+		success INTEGER NOT NULL,
+		error TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS audit_log_time ON audit_log (time)`)
+	if err == nil {
+		err = addAccountColumn(db)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLite{db: db}, nil
+}
+
+// Append 追加一条日志
+func (l *SQLite) Append(entry Entry) error {
+	_, err := l.db.Exec(`INSERT INTO audit_log
+		(time, actor, account, ip, zone_id, zone, action, target, before, after, success, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Time.UnixNano(), entry.Actor, entry.Account, entry.IP, entry.ZoneID, entry.Zone, entry.Action, entry.Target,
+		nullableJSON(entry.Before), nullableJSON(entry.After), entry.Success, entry.Error)
+	return err
+}
+
+// Query 按时间倒序返回满足条件的日志
+func (l *SQLite) Query(filter Filter) ([]Entry, error) {
+	if filter.Account == "" && filter.ZoneIDs != nil && len(filter.ZoneIDs) == 0 {
+		return nil, nil
+	}
+
+	var where []string
+	var args []interface{}
+
+	if filter.Zone != "" {
+		where = append(where, "(zone_id = ? OR zone = ? COLLATE NOCASE)")
+		args = append(args, filter.Zone, filter.Zone)
+	}
+	if filter.Scoped() {
+		var scope []string
+		if filter.Account != "" {
+			scope = append(scope, "account = ?")
+			args = append(args, filter.Account)
+		}
+		if len(filter.ZoneIDs) > 0 {
+			placeholders := make([]string, 0, len(filter.ZoneIDs))
+			for id := range filter.ZoneIDs {
+				placeholders = append(placeholders, "?")
+				args = append(args, id)
+			}
+			scope = append(scope, "zone_id IN ("+strings.Join(placeholders, ", ")+")")
+		}
+		where = append(where, "("+strings.Join(scope, " OR ")+")")
+	}
+	if filter.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.Actor != "" {
+		where = append(where, "actor LIKE ?")
+		args = append(args, "%"+filter.Actor+"%")
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "time >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "time < ?")
+		args = append(args, filter.Until.UnixNano())
+	}
+
+	query := `SELECT time, actor, account, ip, zone_id, zone, action, target, before, after, success, error FROM audit_log`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := l.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var entry Entry
+		var ts int64
+		var before, after sql.NullString
+		if err := rows.Scan(&ts, &entry.Actor, &entry.Account, &entry.IP, &entry.ZoneID, &entry.Zone, &entry.Action,
+			&entry.Target, &before, &after, &entry.Success, &entry.Error); err != nil {
+			return nil, err
+		}
+		entry.Time = time.Unix(0, ts)
+		if before.Valid {
+			entry.Before = []byte(before.String)
+		}
+		if after.Valid {
+			entry.After = []byte(after.String)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Close 关闭数据库
+func (l *SQLite) Close() error {
+	return l.db.Close()
+}
+
+// addAccountColumn 为没有 account 列的旧数据库补充该列（旧条目的 account 为空）并建立索引
+func addAccountColumn(db *sql.DB) error {
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('audit_log') WHERE name = 'account'`).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := db.Exec(`ALTER TABLE audit_log ADD COLUMN account TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec(`CREATE INDEX IF NOT EXISTS audit_log_account ON audit_log (account)`)
+	return err
+}
+
+// nullableJSON 空快照存储为 NULL
+func nullableJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
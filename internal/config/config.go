@@ -7,6 +7,9 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultResolvers 默认的公共解析器（Cloudflare、Google、Quad9、阿里、腾讯）
+var DefaultResolvers = []string{"1.1.1.1", "8.8.8.8", "9.9.9.9", "223.5.5.5", "119.29.29.29"}
+
 type Config struct {
 	Server struct {
 		Host      string `yaml:"host"`
@@ -18,6 +21,11 @@ type Config struct {
 	Session struct {
 		Expire         int `yaml:"expire"`
 		RememberExpire int `yaml:"remember_expire"`
+		Storage        struct {
+			Type       string `yaml:"type"`        // memory / bbolt / sqlite
+			Path       string `yaml:"path"`        // bbolt / sqlite 数据文件路径
+			GCInterval int    `yaml:"gc_interval"` // 过期会话清理间隔（秒）
+		} `yaml:"storage"`
 	} `yaml:"session"`
 
 	RateLimit struct {
@@ -26,8 +34,52 @@ type Config struct {
 	} `yaml:"ratelimit"`
 
 	Cache struct {
-		DNSTTL int `yaml:"dns_ttl"`
+		DNSTTL  int `yaml:"dns_ttl"`
+		ZoneTTL int `yaml:"zone_ttl"` // 域名列表缓存时间（秒）
 	} `yaml:"cache"`
+
+	Audit struct {
+		Type string `yaml:"type"` // jsonl / sqlite
+		Path string `yaml:"path"`
+	} `yaml:"audit"`
+
+	History struct {
+		Path         string `yaml:"path"`          // 快照目录
+		MaxSnapshots int    `yaml:"max_snapshots"` // 每个 Zone 保留的快照数
+	} `yaml:"history"`
+
+	DNS struct {
+		Resolvers []string `yaml:"resolvers"` // 传播检查、邮件安全检查使用的递归解析器（host 或 host:port）
+		Timeout   int      `yaml:"timeout"`   // 单次查询超时（秒）
+	} `yaml:"dns"`
+
+	API struct {
+		BaseURL        string `yaml:"base_url"`        // Cloudflare API 地址（可指向本地模拟服务）
+		Proxy          string `yaml:"proxy"`           // 出站代理，为空时读取 HTTPS_PROXY 环境变量
+		CABundle       string `yaml:"ca_bundle"`       // 额外信任的 CA 证书文件（PEM）
+		ConnectTimeout int    `yaml:"connect_timeout"` // 连接和 TLS 握手超时（秒）
+		Timeout        int    `yaml:"timeout"`         // 等待响应超时（秒）
+		Retry          struct {
+			MaxRetries int `yaml:"max_retries"` // 429 / 5xx 最大重试次数，-1 表示不重试
+			MinBackoff int `yaml:"min_backoff"` // 首次重试等待（秒），之后逐次翻倍
+			MaxBackoff int `yaml:"max_backoff"` // 最长等待（秒）
+		} `yaml:"retry"`
+		Budget struct {
+			Requests int `yaml:"requests"` // 每个凭证在窗口内允许的请求数
+			Window   int `yaml:"window"`   // 配额窗口（秒）
+			MaxWait  int `yaml:"max_wait"` // 配额不足时最长排队时间（秒），-1 表示不排队直接拒绝
+		} `yaml:"budget"`
+		Deadlines struct {
+			Read      int `yaml:"read"`      // 页面加载、搜索等只读操作（秒）
+			Write     int `yaml:"write"`     // 单条修改
+			Bulk      int `yaml:"bulk"`      // 批量操作、导入、恢复快照、应用模板
+			Analytics int `yaml:"analytics"` // 统计报表
+		} `yaml:"deadlines"`
+	} `yaml:"api"`
+
+	Credentials struct {
+		SecretKey string `yaml:"secret_key"` // base64 编码的 32 字节主密钥
+	} `yaml:"credentials"`
 }
 
 func Load(path string) (*Config, error) {
@@ -57,6 +109,15 @@ func Load(path string) (*Config, error) {
 	if cfg.Session.RememberExpire == 0 {
 		cfg.Session.RememberExpire = 31536000
 	}
+	if cfg.Session.Storage.Type == "" {
+		cfg.Session.Storage.Type = "memory"
+	}
+	if cfg.Session.Storage.Path == "" {
+		cfg.Session.Storage.Path = "data/sessions.db"
+	}
+	if cfg.Session.Storage.GCInterval == 0 {
+		cfg.Session.Storage.GCInterval = 600
+	}
 	if cfg.RateLimit.MaxAttempts == 0 {
 		cfg.RateLimit.MaxAttempts = 5
 	}
@@ -66,6 +127,66 @@ func Load(path string) (*Config, error) {
 	if cfg.Cache.DNSTTL == 0 {
 		cfg.Cache.DNSTTL = 172800
 	}
+	if cfg.Cache.ZoneTTL == 0 {
+		cfg.Cache.ZoneTTL = 60
+	}
+	if cfg.Audit.Type == "" {
+		cfg.Audit.Type = "jsonl"
+	}
+	if cfg.Audit.Path == "" {
+		cfg.Audit.Path = "data/audit.jsonl"
+	}
+	if cfg.History.Path == "" {
+		cfg.History.Path = "data/history"
+	}
+	if cfg.History.MaxSnapshots == 0 {
+		cfg.History.MaxSnapshots = 50
+	}
+	if len(cfg.DNS.Resolvers) == 0 {
+		cfg.DNS.Resolvers = DefaultResolvers
+	}
+	if cfg.DNS.Timeout == 0 {
+		cfg.DNS.Timeout = 3
+	}
+	if cfg.API.BaseURL == "" {
+		cfg.API.BaseURL = "https://api.cloudflare.com/client/v4"
+	}
+	if cfg.API.ConnectTimeout == 0 {
+		cfg.API.ConnectTimeout = 10
+	}
+	if cfg.API.Timeout == 0 {
+		cfg.API.Timeout = 30
+	}
+	if cfg.API.Retry.MaxRetries == 0 {
+		cfg.API.Retry.MaxRetries = 3
+	}
+	if cfg.API.Retry.MinBackoff == 0 {
+		cfg.API.Retry.MinBackoff = 1
+	}
+	if cfg.API.Retry.MaxBackoff == 0 {
+		cfg.API.Retry.MaxBackoff = 30
+	}
+	if cfg.API.Budget.Requests == 0 {
+		cfg.API.Budget.Requests = 1200
+	}
+	if cfg.API.Budget.Window == 0 {
+		cfg.API.Budget.Window = 300
+	}
+	if cfg.API.Budget.MaxWait == 0 {
+		cfg.API.Budget.MaxWait = 30
+	}
+	if cfg.API.Deadlines.Read == 0 {
+		cfg.API.Deadlines.Read = 20
+	}
+	if cfg.API.Deadlines.Write == 0 {
+		cfg.API.Deadlines.Write = 30
+	}
+	if cfg.API.Deadlines.Bulk == 0 {
+		cfg.API.Deadlines.Bulk = 300
+	}
+	if cfg.API.Deadlines.Analytics == 0 {
+		cfg.API.Deadlines.Analytics = 60
+	}
 
 	return &cfg, nil
 }
@@ -0,0 +1,206 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrNotFound 凭证不存在或已过期
+var ErrNotFound = errors.New("credential not found")
+
+// keyPrefix 存储键前缀，避免与同一存储中的会话数据冲突
+const keyPrefix = "cred_"
+
+// Credential 一组 Cloudflare 凭证
+type Credential struct {
+	AuthType string `json:"auth_type"`
+	Email    string `json:"email"`
+	Secret   string `json:"secret"` // Global API Key 或 API Token
+	Label    string `json:"label"`  // 账户显示名称
+}
+
+// envelope 加密后的存储结构
+// 每条凭证使用独立的数据密钥（DEK）加密，DEK 再由服务端主密钥（KEK）加密
+type envelope struct {
+	WrappedKey []byte `json:"wrapped_key"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Vault 服务端凭证保险库
+// 会话中只保存引用 ID，凭证本身以 AES-GCM 信封加密的形式保存在 storage 中
+type Vault struct {
+	kek     cipher.AEAD
+	storage fiber.Storage
+}
+
+// NewVault 使用 32 字节主密钥创建保险库
+func NewVault(masterKey []byte, storage fiber.Storage) (*Vault, error) {
+	if len(masterKey) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(masterKey))
+	}
+
+	kek, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Vault{
+		kek:     kek,
+		storage: storage,
+	}, nil
+}
+
+// ParseKey 解析 base64 编码的主密钥
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// GenerateKey 生成随机主密钥
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Put 加密保存凭证，返回不透明的引用 ID
+func (v *Vault) Put(cred Credential, ttl time.Duration) (string, error) {
+	ref, err := randomRef()
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := json.Marshal(cred)
+	if err != nil {
+		return "", err
+	}
+
+	// 生成数据密钥并加密凭证
+	dek, err := GenerateKey()
+	if err != nil {
+		return "", err
+	}
+	dekAEAD, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+
+	// 引用 ID 作为附加认证数据，防止密文被挪用到其他引用
+	env := envelope{
+		WrappedKey: seal(v.kek, dek, []byte(ref)),
+		Ciphertext: seal(dekAEAD, plaintext, []byte(ref)),
+	}
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		return "", err
+	}
+
+	if err := v.storage.Set(keyPrefix+ref, data, ttl); err != nil {
+		return "", fmt.Errorf("failed to store credential: %w", err)
+	}
+
+	return ref, nil
+}
+
+// Get 根据引用 ID 解密凭证
+func (v *Vault) Get(ref string) (Credential, error) {
+	var cred Credential
+	if ref == "" {
+		return cred, ErrNotFound
+	}
+
+	data, err := v.storage.Get(keyPrefix + ref)
+	if err != nil {
+		return cred, fmt.Errorf("failed to read credential: %w", err)
+	}
+	if data == nil {
+		return cred, ErrNotFound
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return cred, fmt.Errorf("corrupted credential: %w", err)
+	}
+
+	dek, err := open(v.kek, env.WrappedKey, []byte(ref))
+	if err != nil {
+		return cred, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	dekAEAD, err := newGCM(dek)
+	if err != nil {
+		return cred, err
+	}
+
+	plaintext, err := open(dekAEAD, env.Ciphertext, []byte(ref))
+	if err != nil {
+		return cred, fmt.Errorf("failed to decrypt credential: %w", err)
+	}
+
+	if err := json.Unmarshal(plaintext, &cred); err != nil {
+		return cred, fmt.Errorf("corrupted credential: %w", err)
+	}
+
+	return cred, nil
+}
+
+// Delete 删除凭证（"忘记我的密钥"）
+func (v *Vault) Delete(ref string) error {
+	if ref == "" {
+		return nil
+	}
+	return v.storage.Delete(keyPrefix + ref)
+}
+
+// newGCM 创建 AES-256-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal 加密，输出格式: nonce || ciphertext
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData)
+}
+
+// open 解密 seal 的输出
+func open(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// randomRef 生成随机引用 ID
+func randomRef() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,784 @@
+// Package fakecf 提供 service.Client 的内存实现，用于在没有网络的情况下
+// 对 Fiber 应用做端到端测试或本地演示。
+//
+// 所有数据保存在 Backend 中，同一个 Backend 创建的 Client 共享状态：
+//
+//	backend := fakecf.New()
+//	zone := backend.AddZone("example.com")
+//	backend.AddRecord(zone.ID, cloudflare.DNSRecord{Type: "A", Name: "www", Content: "192.0.2.1"})
+//	setupRoutes(app, cfg, backend.Clients, nil, auditLog, historyStore)
+package fakecf
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+)
+
+// ErrInvalidCredentials 凭证与 Backend.Secret 不符
+var ErrInvalidCredentials = errors.New("fakecf: invalid credentials")
+
+// DefaultNameServers 新建域名分配的 NS
+var DefaultNameServers = []string{"ada.ns.cloudflare.com", "bob.ns.cloudflare.com"}
+
+// Purge 一次缓存清除请求
+type Purge struct {
+	Everything bool
+	Files      []string
+	Hosts      []string
+	Prefixes   []string
+	Tags       []string
+}
+
+// Backend 内存中的 Cloudflare 账户
+type Backend struct {
+	Secret string // 非空时只接受该密钥（Global API Key 或 API Token）
+	Email  string // UserEmail 返回的邮箱
+
+	mu     sync.Mutex
+	nextID int
+	zones  map[string]*zoneState
+	origin map[string]cloudflare.OriginCACertificate
+}
+
+type zoneState struct {
+	zone          cloudflare.Zone
+	records       map[string]cloudflare.DNSRecord
+	settings      map[string]interface{}
+	dnssec        service.DNSSECDetails
+	edge          []cloudflare.CertificatePack
+	custom        []cloudflare.ZoneCustomSSL
+	verifications []service.SSLVerification
+	purges        []Purge
+}
+
+// New 创建空的 Backend
+func New() *Backend {
+	return &Backend{
+		Email:  "user@example.com",
+		zones:  make(map[string]*zoneState),
+		origin: make(map[string]cloudflare.OriginCACertificate),
+	}
+}
+
+// Clients 实现 service.ClientFactory
+func (b *Backend) Clients(authType, email, secret string) (service.Client, error) {
+	switch authType {
+	case service.AuthTypeAPIKey, service.AuthTypeAPIToken, "":
+	default:
+		return nil, fmt.Errorf("unknown auth type: %s", authType)
+	}
+	return &Client{backend: b, email: email, secret: secret}, nil
+}
+
+// AddZone 添加一个 active 状态的 Full Setup 域名
+func (b *Backend) AddZone(name string) cloudflare.Zone {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	z := b.addZoneLocked(name)
+	z.zone.Status = "active"
+	return z.zone
+}
+
+// AddRecord 向域名添加记录，Name 可以是相对名称或 "@"
+func (b *Backend) AddRecord(zoneID string, record cloudflare.DNSRecord) (cloudflare.DNSRecord, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	z, err := b.zoneLocked(zoneID)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+	return b.insertRecordLocked(z, record)
+}
+
+// AddEdgeCertificate 为域名添加边缘证书包
+func (b *Backend) AddEdgeCertificate(zoneID string, pack cloudflare.CertificatePack) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	z, err := b.zoneLocked(zoneID)
+	if err != nil {
+		return err
+	}
+	if pack.ID == "" {
+		pack.ID = b.newIDLocked()
+	}
+	z.edge = append(z.edge, pack)
+	return nil
+}
+
+// SetSSLVerifications 设置域名的证书验证状态
+func (b *Backend) SetSSLVerifications(zoneID string, verifications []service.SSLVerification) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	z, err := b.zoneLocked(zoneID)
+	if err != nil {
+		return err
+	}
+	z.verifications = verifications
+	return nil
+}
+
+// Records 返回域名当前的全部记录（按类型、名称排序）
+func (b *Backend) Records(zoneID string) []cloudflare.DNSRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	z, err := b.zoneLocked(zoneID)
+	if err != nil {
+		return nil
+	}
+	return sortedRecords(z)
+}
+
+// Purges 返回域名收到的缓存清除请求
+func (b *Backend) Purges(zoneID string) []Purge {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	z, err := b.zoneLocked(zoneID)
+	if err != nil {
+		return nil
+	}
+	return append([]Purge(nil), z.purges...)
+}
+
+func (b *Backend) newIDLocked() string {
+	b.nextID++
+	return fmt.Sprintf("%032x", b.nextID)
+}
+
+func (b *Backend) addZoneLocked(name string) *zoneState {
+	now := time.Now()
+	z := &zoneState{
+		zone: cloudflare.Zone{
+			ID:          b.newIDLocked(),
+			Name:        strings.ToLower(strings.TrimSuffix(name, ".")),
+			Status:      "pending",
+			Type:        "full",
+			NameServers: append([]string(nil), DefaultNameServers...),
+			CreatedOn:   now,
+			ModifiedOn:  now,
+		},
+		records:  make(map[string]cloudflare.DNSRecord),
+		settings: defaultSettings(),
+		dnssec:   service.DNSSECDetails{Status: service.DNSSECDisabled},
+	}
+	z.zone.Plan.ID = "0feeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+	z.zone.Plan.Name = "Free Website"
+	z.zone.Plan.LegacyID = "free"
+	b.zones[z.zone.ID] = z
+	return z
+}
+
+func (b *Backend) zoneLocked(zoneID string) (*zoneState, error) {
+	z, ok := b.zones[zoneID]
+	if !ok {
+		return nil, fmt.Errorf("fakecf: zone %s not found", zoneID)
+	}
+	return z, nil
+}
+
+func (b *Backend) insertRecordLocked(z *zoneState, record cloudflare.DNSRecord) (cloudflare.DNSRecord, error) {
+	record.Type = strings.ToUpper(record.Type)
+	record.Name = fqdn(record.Name, z.zone.Name)
+	if record.TTL == 0 {
+		record.TTL = 1
+	}
+	for _, existing := range z.records {
+		if existing.Type == record.Type && existing.Name == record.Name && existing.Content == record.Content {
+			return cloudflare.DNSRecord{}, errors.New("fakecf: an identical record already exists")
+		}
+		if existing.Name == record.Name && existing.Name != z.zone.Name && (existing.Type == "CNAME") != (record.Type == "CNAME") {
+			return cloudflare.DNSRecord{}, errors.New("fakecf: a CNAME record cannot coexist with other records at the same name")
+		}
+	}
+
+	now := time.Now()
+	record.ID = b.newIDLocked()
+	record.Proxiable = record.Type == "A" || record.Type == "AAAA" || record.Type == "CNAME"
+	if record.Proxied == nil {
+		record.Proxied = cloudflare.BoolPtr(false)
+	}
+	record.CreatedOn = now
+	record.ModifiedOn = now
+	z.records[record.ID] = record
+	return record, nil
+}
+
+// Client service.Client 的内存实现
+type Client struct {
+	backend *Backend
+	email   string
+	secret  string
+}
+
+var _ service.Client = (*Client)(nil)
+
+func (c *Client) lock() func() {
+	c.backend.mu.Lock()
+	return c.backend.mu.Unlock
+}
+
+func (c *Client) zone(rc *cloudflare.ResourceContainer) (*zoneState, error) {
+	if rc == nil || rc.Identifier == "" {
+		return nil, cloudflare.ErrMissingZoneID
+	}
+	return c.backend.zoneLocked(rc.Identifier)
+}
+
+// VerifyCredentials 实现 service.Client
+func (c *Client) VerifyCredentials(ctx context.Context) error {
+	if c.backend.Secret != "" && c.secret != c.backend.Secret {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// UserEmail 实现 service.Client
+func (c *Client) UserEmail(ctx context.Context) (string, error) {
+	if c.email != "" {
+		return c.email, nil
+	}
+	return c.backend.Email, nil
+}
+
+// ListZones 实现 service.Client
+func (c *Client) ListZones(ctx context.Context, q service.ZoneQuery) ([]cloudflare.Zone, *cloudflare.ResultInfo, error) {
+	all, _ := c.ListAllZones(ctx)
+	zones := service.FilterZones(all, q)
+	start, end, resultInfo := service.Paginate(len(zones), q.Normalize().Page, service.ZonesPerPage)
+	return zones[start:end], resultInfo, nil
+}
+
+// ListAllZones 实现 service.Client
+func (c *Client) ListAllZones(ctx context.Context) ([]cloudflare.Zone, error) {
+	defer c.lock()()
+	zones := make([]cloudflare.Zone, 0, len(c.backend.zones))
+	for _, z := range c.backend.zones {
+		zones = append(zones, z.zone)
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Name < zones[j].Name })
+	return zones, nil
+}
+
+// GetZone 实现 service.Client
+func (c *Client) GetZone(ctx context.Context, zoneID string) (cloudflare.Zone, error) {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return cloudflare.Zone{}, err
+	}
+	return z.zone, nil
+}
+
+// ZoneIDByName 实现 service.Client
+func (c *Client) ZoneIDByName(ctx context.Context, name string) (string, error) {
+	defer c.lock()()
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for id, z := range c.backend.zones {
+		if z.zone.Name == name {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("fakecf: zone %s not found", name)
+}
+
+// CreateZone 实现 service.Client，新域名为 pending 状态
+func (c *Client) CreateZone(ctx context.Context, name string) (cloudflare.Zone, error) {
+	defer c.lock()()
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for _, z := range c.backend.zones {
+		if z.zone.Name == name {
+			return cloudflare.Zone{}, fmt.Errorf("fakecf: %s already exists", name)
+		}
+	}
+	return c.backend.addZoneLocked(name).zone, nil
+}
+
+// DeleteZone 实现 service.Client
+func (c *Client) DeleteZone(ctx context.Context, zoneID string) error {
+	defer c.lock()()
+	if _, err := c.backend.zoneLocked(zoneID); err != nil {
+		return err
+	}
+	delete(c.backend.zones, zoneID)
+	return nil
+}
+
+// ListDNSRecords 实现 service.Client，支持按类型、名称、内容和代理状态筛选
+func (c *Client) ListDNSRecords(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.ListDNSRecordsParams) ([]cloudflare.DNSRecord, *cloudflare.ResultInfo, error) {
+	defer c.lock()()
+	z, err := c.zone(rc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []cloudflare.DNSRecord
+	for _, r := range sortedRecords(z) {
+		if params.Type != "" && !strings.EqualFold(r.Type, params.Type) {
+			continue
+		}
+		if params.Name != "" && r.Name != fqdn(params.Name, z.zone.Name) {
+			continue
+		}
+		if params.Content != "" && r.Content != params.Content {
+			continue
+		}
+		if params.Proxied != nil && (r.Proxied == nil || *r.Proxied != *params.Proxied) {
+			continue
+		}
+		records = append(records, r)
+	}
+
+	perPage := params.PerPage
+	if perPage <= 0 {
+		perPage = 100
+	}
+	start, end, resultInfo := service.Paginate(len(records), params.Page, perPage)
+	return records[start:end], resultInfo, nil
+}
+
+// GetDNSRecord 实现 service.Client
+func (c *Client) GetDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, recordID string) (cloudflare.DNSRecord, error) {
+	defer c.lock()()
+	z, err := c.zone(rc)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+	r, ok := z.records[recordID]
+	if !ok {
+		return cloudflare.DNSRecord{}, fmt.Errorf("fakecf: record %s not found", recordID)
+	}
+	return r, nil
+}
+
+// CreateDNSRecord 实现 service.Client
+func (c *Client) CreateDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.CreateDNSRecordParams) (cloudflare.DNSRecord, error) {
+	defer c.lock()()
+	z, err := c.zone(rc)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+	return c.backend.insertRecordLocked(z, cloudflare.DNSRecord{
+		Type:     params.Type,
+		Name:     params.Name,
+		Content:  params.Content,
+		Data:     params.Data,
+		Priority: params.Priority,
+		TTL:      params.TTL,
+		Proxied:  params.Proxied,
+		Comment:  params.Comment,
+		Tags:     params.Tags,
+	})
+}
+
+// UpdateDNSRecord 实现 service.Client，只更新非零值字段
+func (c *Client) UpdateDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.UpdateDNSRecordParams) (cloudflare.DNSRecord, error) {
+	defer c.lock()()
+	z, err := c.zone(rc)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+	r, ok := z.records[params.ID]
+	if !ok {
+		return cloudflare.DNSRecord{}, fmt.Errorf("fakecf: record %s not found", params.ID)
+	}
+
+	if params.Type != "" {
+		r.Type = strings.ToUpper(params.Type)
+	}
+	if params.Name != "" {
+		r.Name = fqdn(params.Name, z.zone.Name)
+	}
+	if params.Content != "" {
+		r.Content = params.Content
+	}
+	if params.Data != nil {
+		r.Data = params.Data
+	}
+	if params.Priority != nil {
+		r.Priority = params.Priority
+	}
+	if params.TTL != 0 {
+		r.TTL = params.TTL
+	}
+	if params.Proxied != nil {
+		if *params.Proxied && !r.Proxiable {
+			return cloudflare.DNSRecord{}, fmt.Errorf("fakecf: %s records cannot be proxied", r.Type)
+		}
+		r.Proxied = params.Proxied
+	}
+	if params.Comment != nil {
+		r.Comment = *params.Comment
+	}
+	if params.Tags != nil {
+		r.Tags = params.Tags
+	}
+	r.ModifiedOn = time.Now()
+	z.records[r.ID] = r
+	return r, nil
+}
+
+// DeleteDNSRecord 实现 service.Client
+func (c *Client) DeleteDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, recordID string) error {
+	defer c.lock()()
+	z, err := c.zone(rc)
+	if err != nil {
+		return err
+	}
+	if _, ok := z.records[recordID]; !ok {
+		return fmt.Errorf("fakecf: record %s not found", recordID)
+	}
+	delete(z.records, recordID)
+	return nil
+}
+
+// GetDNSSEC 实现 service.Client
+func (c *Client) GetDNSSEC(ctx context.Context, zoneID string) (*service.DNSSECDetails, error) {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	details := z.dnssec
+	return &details, nil
+}
+
+// UpdateDNSSEC 实现 service.Client，启用时生成固定的 DS 参数
+func (c *Client) UpdateDNSSEC(ctx context.Context, zoneID string, status string) (*service.DNSSECDetails, error) {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case service.DNSSECActive:
+		z.dnssec = service.DNSSECDetails{
+			Status:          service.DNSSECPending,
+			Flags:           257,
+			Algorithm:       "13",
+			KeyType:         "ECDSAP256SHA256",
+			DigestType:      "2",
+			DigestAlgorithm: "SHA256",
+			Digest:          "2E3B1C0B6C5C0A1E4F6B9D8A7C6E5F4A3B2C1D0E9F8A7B6C5D4E3F2A1B0C9D8E",
+			KeyTag:          2371,
+			ModifiedOn:      time.Now(),
+		}
+		z.dnssec.DS = fmt.Sprintf("%s. 3600 IN DS %d 13 2 %s", z.zone.Name, z.dnssec.KeyTag, z.dnssec.Digest)
+	case service.DNSSECDisabled:
+		z.dnssec = service.DNSSECDetails{Status: service.DNSSECDisabled, ModifiedOn: time.Now()}
+	default:
+		return nil, fmt.Errorf("fakecf: invalid DNSSEC status %q", status)
+	}
+	details := z.dnssec
+	return &details, nil
+}
+
+// GetZoneSettings 实现 service.Client
+func (c *Client) GetZoneSettings(ctx context.Context, zoneID string) ([]cloudflare.ZoneSetting, error) {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	settings := make([]cloudflare.ZoneSetting, 0, len(z.settings))
+	for id, value := range z.settings {
+		settings = append(settings, cloudflare.ZoneSetting{ID: id, Value: value, Editable: true, ModifiedOn: z.zone.ModifiedOn.Format(time.RFC3339)})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].ID < settings[j].ID })
+	return settings, nil
+}
+
+// ZoneSettingValues 实现 service.Client
+func (c *Client) ZoneSettingValues(ctx context.Context, zoneID string, ids []string) (map[string]interface{}, error) {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, len(ids))
+	for _, id := range ids {
+		if value, ok := z.settings[id]; ok {
+			values[id] = value
+		}
+	}
+	return values, nil
+}
+
+// UpdateZoneSetting 实现 service.Client
+func (c *Client) UpdateZoneSetting(ctx context.Context, zoneID, settingID string, value interface{}) error {
+	return c.UpdateZoneSettings(ctx, zoneID, []cloudflare.ZoneSetting{{ID: settingID, Value: value}})
+}
+
+// UpdateZoneSettings 实现 service.Client，未知的设置项返回错误
+func (c *Client) UpdateZoneSettings(ctx context.Context, zoneID string, settings []cloudflare.ZoneSetting) error {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return err
+	}
+	for _, s := range settings {
+		if _, ok := z.settings[s.ID]; !ok {
+			return fmt.Errorf("fakecf: unknown setting %s", s.ID)
+		}
+	}
+	for _, s := range settings {
+		z.settings[s.ID] = s.Value
+	}
+	return nil
+}
+
+func (c *Client) purge(zoneID string, p Purge) error {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return err
+	}
+	z.purges = append(z.purges, p)
+	return nil
+}
+
+// PurgeAllCache 实现 service.Client
+func (c *Client) PurgeAllCache(ctx context.Context, zoneID string) error {
+	return c.purge(zoneID, Purge{Everything: true})
+}
+
+// PurgeCacheByURLs 实现 service.Client
+func (c *Client) PurgeCacheByURLs(ctx context.Context, zoneID string, urls []string) error {
+	return c.purge(zoneID, Purge{Files: urls})
+}
+
+// PurgeCacheByHosts 实现 service.Client
+func (c *Client) PurgeCacheByHosts(ctx context.Context, zoneID string, hosts []string) error {
+	return c.purge(zoneID, Purge{Hosts: hosts})
+}
+
+// PurgeCacheByPrefixes 实现 service.Client
+func (c *Client) PurgeCacheByPrefixes(ctx context.Context, zoneID string, prefixes []string) error {
+	return c.purge(zoneID, Purge{Prefixes: prefixes})
+}
+
+// PurgeCacheByTags 实现 service.Client
+func (c *Client) PurgeCacheByTags(ctx context.Context, zoneID string, tags []string) error {
+	return c.purge(zoneID, Purge{Tags: tags})
+}
+
+// ListEdgeCertificates 实现 service.Client
+func (c *Client) ListEdgeCertificates(ctx context.Context, zoneID string) ([]cloudflare.CertificatePack, error) {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]cloudflare.CertificatePack(nil), z.edge...), nil
+}
+
+// GetEdgeCertificate 实现 service.Client
+func (c *Client) GetEdgeCertificate(ctx context.Context, zoneID, certID string) (cloudflare.CertificatePack, error) {
+	packs, err := c.ListEdgeCertificates(ctx, zoneID)
+	if err != nil {
+		return cloudflare.CertificatePack{}, err
+	}
+	for _, pack := range packs {
+		if pack.ID == certID {
+			return pack, nil
+		}
+	}
+	return cloudflare.CertificatePack{}, fmt.Errorf("fakecf: certificate pack %s not found", certID)
+}
+
+// ListOriginCertificates 实现 service.Client，返回主机名属于该域名的证书
+func (c *Client) ListOriginCertificates(ctx context.Context, zoneID string) ([]cloudflare.OriginCACertificate, error) {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	var certs []cloudflare.OriginCACertificate
+	for _, cert := range c.backend.origin {
+		for _, host := range cert.Hostnames {
+			host = strings.TrimPrefix(host, "*.")
+			if host == z.zone.Name || strings.HasSuffix(host, "."+z.zone.Name) {
+				certs = append(certs, cert)
+				break
+			}
+		}
+	}
+	sort.Slice(certs, func(i, j int) bool { return certs[i].ID < certs[j].ID })
+	return certs, nil
+}
+
+// GetOriginCertificate 实现 service.Client
+func (c *Client) GetOriginCertificate(ctx context.Context, certID string) (*cloudflare.OriginCACertificate, error) {
+	defer c.lock()()
+	cert, ok := c.backend.origin[certID]
+	if !ok {
+		return nil, fmt.Errorf("fakecf: origin certificate %s not found", certID)
+	}
+	return &cert, nil
+}
+
+// CreateOriginCertificate 实现 service.Client，签发自签名的 ECDSA 证书
+func (c *Client) CreateOriginCertificate(ctx context.Context, hostnames []string, requestType string, validityDays int) (*service.OriginCertificateWithKey, error) {
+	if len(hostnames) == 0 {
+		return nil, errors.New("fakecf: at least one hostname is required")
+	}
+	certPEM, keyPEM, expires, err := selfSigned(hostnames, validityDays)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.lock()()
+	cert := cloudflare.OriginCACertificate{
+		ID:              c.backend.newIDLocked(),
+		Certificate:     certPEM,
+		Hostnames:       hostnames,
+		ExpiresOn:       expires,
+		RequestType:     requestType,
+		RequestValidity: validityDays,
+	}
+	c.backend.origin[cert.ID] = cert
+	return &service.OriginCertificateWithKey{OriginCACertificate: cert, PrivateKey: keyPEM}, nil
+}
+
+// RevokeOriginCertificate 实现 service.Client
+func (c *Client) RevokeOriginCertificate(ctx context.Context, certID string) error {
+	defer c.lock()()
+	cert, ok := c.backend.origin[certID]
+	if !ok {
+		return fmt.Errorf("fakecf: origin certificate %s not found", certID)
+	}
+	cert.RevokedAt = time.Now()
+	c.backend.origin[certID] = cert
+	return nil
+}
+
+// ListCustomSSLCertificates 实现 service.Client
+func (c *Client) ListCustomSSLCertificates(ctx context.Context, zoneID string) ([]cloudflare.ZoneCustomSSL, error) {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]cloudflare.ZoneCustomSSL(nil), z.custom...), nil
+}
+
+// GetSSLVerification 实现 service.Client
+func (c *Client) GetSSLVerification(ctx context.Context, zoneID string) ([]service.SSLVerification, error) {
+	defer c.lock()()
+	z, err := c.backend.zoneLocked(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]service.SSLVerification(nil), z.verifications...), nil
+}
+
+// GetAnalytics 实现 service.Client，返回空的统计数据
+func (c *Client) GetAnalytics(ctx context.Context, zoneID string, r service.AnalyticsRange, now time.Time) (*service.AnalyticsData, error) {
+	if _, err := c.GetZone(ctx, zoneID); err != nil {
+		return nil, err
+	}
+	return &service.AnalyticsData{Range: r.Key, Since: now.Add(-r.Duration), Until: now}, nil
+}
+
+// GetDNSAnalytics 实现 service.Client，返回空报表
+func (c *Client) GetDNSAnalytics(ctx context.Context, zoneID string, since, until time.Time) (*service.DNSAnalyticsReport, error) {
+	if _, err := c.GetZone(ctx, zoneID); err != nil {
+		return nil, err
+	}
+	return &service.DNSAnalyticsReport{Since: since, Until: until}, nil
+}
+
+// defaultSettings 新域名的默认设置（与 Cloudflare 免费套餐一致）
+func defaultSettings() map[string]interface{} {
+	return map[string]interface{}{
+		"always_online":     "on",
+		"always_use_https":  "off",
+		"brotli":            "on",
+		"browser_cache_ttl": float64(14400),
+		"development_mode":  "off",
+		"http2":             "on",
+		"http3":             "on",
+		"min_tls_version":   "1.0",
+		"minify":            map[string]interface{}{"css": "off", "html": "off", "js": "off"},
+		"rocket_loader":     "off",
+		"security_level":    "medium",
+		"ssl":               "flexible",
+		"tls_1_3":           "on",
+	}
+}
+
+// fqdn 将相对名称或 "@" 转换为完整域名
+func fqdn(name, zone string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "" || name == "@" {
+		return zone
+	}
+	if name == zone || strings.HasSuffix(name, "."+zone) {
+		return name
+	}
+	return name + "." + zone
+}
+
+func sortedRecords(z *zoneState) []cloudflare.DNSRecord {
+	records := make([]cloudflare.DNSRecord, 0, len(z.records))
+	for _, r := range z.records {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Type != records[j].Type {
+			return records[i].Type < records[j].Type
+		}
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records
+}
+
+// selfSigned 生成自签名证书和私钥（PEM）
+func selfSigned(hostnames []string, validityDays int) (string, string, time.Time, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	if validityDays <= 0 {
+		validityDays = 5475
+	}
+	now := time.Now()
+	expires := now.AddDate(0, 0, validityDays)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano()),
+		Subject:      pkix.Name{CommonName: hostnames[0], Organization: []string{"fakecf Origin CA"}},
+		DNSNames:     hostnames,
+		NotBefore:    now,
+		NotAfter:     expires,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM), expires, nil
+}
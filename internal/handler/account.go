@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/middleware"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+)
+
+// AccountHandler 管理登录会话中绑定的多个 Cloudflare 账户
+type AccountHandler struct {
+	Clients     service.ClientFactory
+	RateLimiter *middleware.RateLimiter
+}
+
+func NewAccountHandler(clients service.ClientFactory, rateLimiter *middleware.RateLimiter) *AccountHandler {
+	return &AccountHandler{
+		Clients:     clients,
+		RateLimiter: rateLimiter,
+	}
+}
+
+// ShowAddAccount 显示添加账户页面
+func (h *AccountHandler) ShowAddAccount(c *fiber.Ctx) error {
+	return c.Render("account/add", fiber.Map{})
+}
+
+// AddAccount 添加账户：验证凭证后绑定到当前会话，并切换为当前账户
+func (h *AccountHandler) AddAccount(c *fiber.Ctx) error {
+	authType := c.FormValue("auth_type", service.AuthTypeAPIKey)
+	email := c.FormValue("cloudflare_email")
+	apiKey := c.FormValue("cloudflare_api")
+	label := strings.TrimSpace(c.FormValue("label"))
+
+	limitKey := email
+	if authType == service.AuthTypeAPIToken {
+		limitKey = "token_" + c.IP()
+	}
+	if !h.RateLimiter.CheckAndIncrement(limitKey) {
+		return c.Render("account/add", fiber.Map{
+			"Error": "验证失败次数过多，请一小时后再试",
+		})
+	}
+
+	sess, err := middleware.Store.Get(c)
+	if err != nil {
+		return c.Redirect("/login")
+	}
+
+	// 新账户与登录账户使用相同的有效期
+	ttl := 24 * time.Hour
+	if seconds, ok := sess.Get("credential_ttl").(int); ok && seconds > 0 {
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	ref, err := storeAccount(c.UserContext(), h.Clients, authType, email, apiKey, label, ttl)
+	if err != nil {
+		return c.Render("account/add", fiber.Map{
+			"Error": err.Error(),
+			"Label": label,
+		})
+	}
+
+	sess.Set("account_refs", append(middleware.AccountRefs(sess), ref))
+	sess.Set("credential_ref", ref)
+	if err := sess.Save(); err != nil {
+		middleware.Vault.Delete(ref)
+		return c.Render("account/add", fiber.Map{
+			"Error": "会话保存失败",
+		})
+	}
+
+	return c.Redirect("/zones")
+}
+
+// SwitchAccount 切换当前账户
+// ref=all 时进入"全部账户"视图；next 为切换后跳转的站内地址
+func (h *AccountHandler) SwitchAccount(c *fiber.Ctx) error {
+	ref := c.Query("ref")
+	next := c.Query("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+		next = "/zones"
+	}
+
+	if ref == "all" {
+		return c.Redirect("/zones?account=all")
+	}
+
+	sess, err := middleware.Store.Get(c)
+	if err != nil {
+		return c.Redirect("/login")
+	}
+
+	if !containsRef(middleware.AccountRefs(sess), ref) {
+		return c.Redirect("/zones")
+	}
+
+	sess.Set("credential_ref", ref)
+	if err := sess.Save(); err != nil {
+		return c.Status(500).SendString("Failed to save session")
+	}
+
+	return c.Redirect(next)
+}
+
+// RemoveAccount 解绑账户并从保险库删除其凭证
+// 移除最后一个账户等同于"忘记我的密钥"
+func (h *AccountHandler) RemoveAccount(c *fiber.Ctx) error {
+	ref := c.FormValue("ref")
+
+	sess, err := middleware.Store.Get(c)
+	if err != nil {
+		return c.Redirect("/login")
+	}
+
+	refs := middleware.AccountRefs(sess)
+	if !containsRef(refs, ref) {
+		return c.Redirect("/zones")
+	}
+
+	if err := middleware.Vault.Delete(ref); err != nil {
+		return c.Status(500).SendString("Failed to delete credential")
+	}
+
+	var remaining []string
+	for _, r := range refs {
+		if r != ref {
+			remaining = append(remaining, r)
+		}
+	}
+	if len(remaining) == 0 {
+		if err := sess.Destroy(); err != nil {
+			return err
+		}
+		return c.Redirect("/")
+	}
+
+	sess.Set("account_refs", remaining)
+	if active, _ := sess.Get("credential_ref").(string); active == ref {
+		sess.Set("credential_ref", remaining[0])
+	}
+	if err := sess.Save(); err != nil {
+		return c.Status(500).SendString("Failed to save session")
+	}
+
+	return c.Redirect("/zones")
+}
+
+// containsRef 判断引用 ID 是否属于当前会话
+func containsRef(refs []string, ref string) bool {
+	if ref == "" {
+		return false
+	}
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
@@ -1,18 +1,21 @@
 package handler
 
 import (
-	"context"
-	"encoding/json"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
 )
 
-type AnalyticsHandler struct{}
+type AnalyticsHandler struct {
+	Clients service.ClientFactory
+}
 
-func NewAnalyticsHandler() *AnalyticsHandler {
-	return &AnalyticsHandler{}
+func NewAnalyticsHandler(clients service.ClientFactory) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		Clients: clients,
+	}
 }
 
 // ShowAnalytics 显示分析统计页面
@@ -24,39 +27,32 @@ func (h *AnalyticsHandler) ShowAnalytics(c *fiber.Ctx) error {
 		return c.Status(400).SendString("Missing zoneid or domain parameter")
 	}
 
-	// 获取凭证
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
-
-	cfService, err := service.NewCloudflareService(email, apiKey)
-	if err != nil {
-		return c.Render("analytics/index", fiber.Map{
-			"Error":  "Failed to initialize Cloudflare service",
-			"ZoneID": zoneID,
-			"Domain": domain,
-		})
+	// 时间范围，无效值使用默认的 24 小时
+	r, ok := service.FindAnalyticsRange(c.Query("range"))
+	if !ok {
+		r, _ = service.FindAnalyticsRange(service.DefaultAnalyticsRange)
 	}
 
-	// 获取过去24小时的数据
-	now := time.Now().Unix()
-	since := now - 86400 // 24小时前
+	data := fiber.Map{
+		"ZoneID": zoneID,
+		"Domain": domain,
+		"Range":  r,
+		"Ranges": service.AnalyticsRanges,
+	}
 
-	analytics, err := cfService.GetAnalytics(context.Background(), zoneID, int(since), int(now))
+	// 获取凭证并创建服务
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
-		return c.Render("analytics/index", fiber.Map{
-			"Error":  "Failed to fetch analytics data",
-			"ZoneID": zoneID,
-			"Domain": domain,
-		})
+		data["Error"] = "Failed to initialize Cloudflare service"
+		return c.Render("analytics/index", data)
 	}
 
-	// 将数据转换为 JSON 供 Chart.js 使用
-	analyticsJSON, _ := json.Marshal(analytics)
+	analytics, err := cfService.GetAnalytics(c.UserContext(), zoneID, r, time.Now())
+	if err != nil {
+		data["Error"] = "Failed to fetch analytics data: " + err.Error()
+		return c.Render("analytics/index", data)
+	}
 
-	return c.Render("analytics/index", fiber.Map{
-		"ZoneID":        zoneID,
-		"Domain":        domain,
-		"Analytics":     analytics,
-		"AnalyticsJSON": string(analyticsJSON),
-	})
+	data["Analytics"] = analytics
+	return c.Render("analytics/index", data)
 }
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+)
+
+// auditPageLimit 审计页面最多显示的条数
+const auditPageLimit = 200
+
+type AuditHandler struct {
+	Clients service.ClientFactory
+	Audit   audit.Logger
+	Zones   *service.ZoneCache // 当前账户可访问的 Zone，用于显示其他凭证对这些 Zone 的操作
+}
+
+func NewAuditHandler(clients service.ClientFactory, auditLog audit.Logger, zoneCache *service.ZoneCache) *AuditHandler {
+	return &AuditHandler{
+		Clients: clients,
+		Audit:   auditLog,
+		Zones:   zoneCache,
+	}
+}
+
+// ShowAudit 审计日志页面，支持按域名、操作、操作者和日期过滤
+// 多个租户共用一个部署，只显示当前凭证写入的日志和当前账户可以访问的 Zone 的日志
+func (h *AuditHandler) ShowAudit(c *fiber.Ctx) error {
+	filter := audit.Filter{
+		Zone:   c.Query("zone"),
+		Action: c.Query("action"),
+		Actor:  c.Query("actor"),
+		Limit:  auditPageLimit,
+	}
+
+	// 日期按服务器本地时区解析，结束日期包含当天
+	if since, err := time.ParseInLocation("2006-01-02", c.Query("since"), time.Local); err == nil {
+		filter.Since = since
+	}
+	if until, err := time.ParseInLocation("2006-01-02", c.Query("until"), time.Local); err == nil {
+		filter.Until = until.AddDate(0, 0, 1)
+	}
+
+	data := fiber.Map{
+		"Filter":  filter,
+		"Since":   c.Query("since"),
+		"Until":   c.Query("until"),
+		"Actions": audit.Actions,
+		"Limit":   auditPageLimit,
+	}
+
+	// 本凭证写入的日志（包括已删除的 Zone 和没有 Zone 的操作）始终可见，
+	// 其他凭证写入的日志只显示当前仍可访问的 Zone
+	filter.Account = auditAccount(c)
+	zoneIDs, err := h.accessibleZones(c)
+	if err != nil {
+		data["Error"] = "获取域名列表失败: " + err.Error()
+		zoneIDs = map[string]bool{}
+	}
+	filter.ZoneIDs = zoneIDs
+
+	entries, err := h.Audit.Query(filter)
+	if err != nil {
+		data["Error"] = "读取审计日志失败: " + err.Error()
+	}
+	data["Entries"] = entries
+
+	return c.Render("audit/index", data)
+}
+
+// accessibleZones 当前账户凭证可以列出的全部 Zone ID
+func (h *AuditHandler) accessibleZones(c *fiber.Ctx) (map[string]bool, error) {
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		return nil, err
+	}
+	zones, err := h.Zones.All(c.UserContext(), credentialRef(c), cfService)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		ids[zone.ID] = true
+	}
+	return ids, nil
+}
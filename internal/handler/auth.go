@@ -2,20 +2,24 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"time"
 
-	"github.com/cloudflare/cloudflare-go"
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/credentials"
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/middleware"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
 )
 
 type AuthHandler struct {
+	Clients     service.ClientFactory
 	RateLimiter *middleware.RateLimiter
 }
 
-func NewAuthHandler(rateLimiter *middleware.RateLimiter) *AuthHandler {
+func NewAuthHandler(clients service.ClientFactory, rateLimiter *middleware.RateLimiter) *AuthHandler {
 	return &AuthHandler{
+		Clients:     clients,
 		RateLimiter: rateLimiter,
 	}
 }
@@ -26,42 +30,50 @@ func (h *AuthHandler) ShowLogin(c *fiber.Ctx) error {
 }
 
 func (h *AuthHandler) PostLogin(c *fiber.Ctx) error {
+	authType := c.FormValue("auth_type", service.AuthTypeAPIKey)
 	email := c.FormValue("cloudflare_email")
 	apiKey := c.FormValue("cloudflare_api")
 	remember := c.FormValue("remember") == "on"
 
-	// 限流检查
-	if !h.RateLimiter.CheckAndIncrement(email) {
+	// 限流检查（API Token 登录不需要邮箱，按 IP 计数）
+	limitKey := email
+	if authType == service.AuthTypeAPIToken {
+		email = ""
+		limitKey = "token_" + c.IP()
+	}
+	if !h.RateLimiter.CheckAndIncrement(limitKey) {
 		return c.Render("home/index", fiber.Map{
 			"Error": "登录失败次数过多，请一小时后再试",
 		})
 	}
 
-	// 验证 API Key
-	api, err := cloudflare.New(apiKey, email)
-	if err != nil {
-		return c.Render("home/index", fiber.Map{
-			"Error": "无效的凭证",
-		})
+	// 会话有效期：记住我 365 天，否则 24 小时
+	expiry := 24 * time.Hour
+	if remember {
+		expiry = 365 * 24 * time.Hour
 	}
 
-	// 验证凭证有效性
-	_, err = api.UserDetails(context.Background())
+	// 验证凭证并加密存入保险库，会话中只保存引用
+	ref, err := storeAccount(c.UserContext(), h.Clients, authType, email, apiKey, "", expiry)
 	if err != nil {
 		return c.Render("home/index", fiber.Map{
-			"Error": "无效的凭证或 API Key",
+			"Error": err.Error(),
 		})
 	}
 
-	// 创建会话
+	// 创建会话（重新登录时清理之前绑定的账户）
 	sess, _ := middleware.Store.Get(c)
-	sess.Set("cloudflare_email", email)
-	sess.Set("user_api_key", apiKey)
+	for _, old := range middleware.AccountRefs(sess) {
+		middleware.Vault.Delete(old)
+	}
+	sess.Set("credential_ref", ref)
+	sess.Set("account_refs", []string{ref})
+	sess.Set("credential_ttl", int(expiry.Seconds()))
 
 	// 设置会话和 Cookie 过期时间
 	if remember {
 		// 记住我：365 天
-		sess.SetExpiry(365 * 24 * time.Hour)
+		sess.SetExpiry(expiry)
 
 		// 设置 Cookie 过期时间为 365 天
 		c.Cookie(&fiber.Cookie{
@@ -73,7 +85,7 @@ func (h *AuthHandler) PostLogin(c *fiber.Ctx) error {
 		})
 	} else {
 		// 不记住：使用会话 Cookie（浏览器关闭后过期）
-		sess.SetExpiry(24 * time.Hour) // Session 本身 24 小时过期
+		sess.SetExpiry(expiry) // Session 本身 24 小时过期
 	}
 
 	if err := sess.Save(); err != nil {
@@ -86,9 +98,64 @@ func (h *AuthHandler) PostLogin(c *fiber.Ctx) error {
 }
 
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
-	sess, _ := middleware.Store.Get(c)
-	if err := sess.Destroy(); err != nil {
+	if err := destroySession(c); err != nil {
 		return err
 	}
 	return c.Redirect("/")
 }
+
+// ForgetKey 从服务器删除已保存的凭证并退出登录
+func (h *AuthHandler) ForgetKey(c *fiber.Ctx) error {
+	if err := destroySession(c); err != nil {
+		return err
+	}
+	return c.Render("home/index", fiber.Map{
+		"Success": "您的 API Key 已从服务器删除",
+	})
+}
+
+// storeAccount 验证凭证并加密存入保险库，返回引用 ID
+// 返回的错误信息可直接展示给用户
+func storeAccount(ctx context.Context, clients service.ClientFactory, authType, email, apiKey, label string, ttl time.Duration) (string, error) {
+	if authType == service.AuthTypeAPIToken {
+		email = ""
+	}
+
+	// 创建客户端
+	cfService, err := clients(authType, email, apiKey)
+	if err != nil {
+		return "", errors.New("无效的凭证")
+	}
+
+	// 验证凭证有效性
+	if err := cfService.VerifyCredentials(ctx); err != nil {
+		return "", errors.New("无效的凭证或 API Key")
+	}
+
+	// API Token 尝试读取账户邮箱用于显示（Token 可能没有该权限，失败时忽略）
+	if authType == service.AuthTypeAPIToken {
+		email, _ = cfService.UserEmail(ctx)
+	}
+
+	ref, err := middleware.Vault.Put(credentials.Credential{
+		AuthType: authType,
+		Email:    email,
+		Secret:   apiKey,
+		Label:    label,
+	}, ttl)
+	if err != nil {
+		return "", errors.New("凭证保存失败")
+	}
+	return ref, nil
+}
+
+// destroySession 删除保险库中当前会话绑定的全部凭证并销毁会话
+func destroySession(c *fiber.Ctx) error {
+	sess, _ := middleware.Store.Get(c)
+	for _, ref := range middleware.AccountRefs(sess) {
+		if err := middleware.Vault.Delete(ref); err != nil {
+			return err
+		}
+	}
+	return sess.Destroy()
+}
@@ -1,26 +1,31 @@
 package handler
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
 )
 
-type CertificateHandler struct{}
+type CertificateHandler struct {
+	Clients service.ClientFactory
+	Audit   audit.Logger
+}
 
-func NewCertificateHandler() *CertificateHandler {
-	return &CertificateHandler{}
+func NewCertificateHandler(clients service.ClientFactory, auditLog audit.Logger) *CertificateHandler {
+	return &CertificateHandler{
+		Clients: clients,
+		Audit:   auditLog,
+	}
 }
 
 // ShowCertificates 显示证书管理页面
 func (h *CertificateHandler) ShowCertificates(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneID := c.Query("zoneid")
 	domain := c.Query("domain")
 	tab := c.Query("tab", "edge") // 默认显示边缘证书
@@ -29,7 +34,7 @@ func (h *CertificateHandler) ShowCertificates(c *fiber.Ctx) error {
 		return c.Redirect("/zones")
 	}
 
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).SendString("Failed to create Cloudflare service")
 	}
@@ -44,7 +49,7 @@ func (h *CertificateHandler) ShowCertificates(c *fiber.Ctx) error {
 	switch tab {
 	case "edge":
 		// 获取边缘证书
-		edgeCerts, err := cfService.ListEdgeCertificates(context.Background(), zoneID)
+		edgeCerts, err := cfService.ListEdgeCertificates(c.UserContext(), zoneID)
 		if err != nil {
 			data["Error"] = "Failed to fetch edge certificates: " + err.Error()
 		} else {
@@ -53,7 +58,7 @@ func (h *CertificateHandler) ShowCertificates(c *fiber.Ctx) error {
 
 	case "origin":
 		// 获取回源证书
-		originCerts, err := cfService.ListOriginCertificates(context.Background(), zoneID)
+		originCerts, err := cfService.ListOriginCertificates(c.UserContext(), zoneID)
 		if err != nil {
 			data["Error"] = "Failed to fetch origin certificates: " + err.Error()
 		} else {
@@ -62,7 +67,7 @@ func (h *CertificateHandler) ShowCertificates(c *fiber.Ctx) error {
 
 	case "custom":
 		// 获取自定义证书
-		customCerts, err := cfService.ListCustomSSLCertificates(context.Background(), zoneID)
+		customCerts, err := cfService.ListCustomSSLCertificates(c.UserContext(), zoneID)
 		if err != nil {
 			data["Error"] = "Failed to fetch custom certificates: " + err.Error()
 		} else {
@@ -75,21 +80,19 @@ func (h *CertificateHandler) ShowCertificates(c *fiber.Ctx) error {
 
 // DownloadOriginCertificate 下载回源证书
 func (h *CertificateHandler) DownloadOriginCertificate(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	certID := c.Params("id")
 
 	if certID == "" {
 		return c.Status(400).SendString("Missing certificate ID")
 	}
 
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).SendString("Failed to create service")
 	}
 
 	// 获取证书详情
-	cert, err := cfService.GetOriginCertificate(context.Background(), certID)
+	cert, err := cfService.GetOriginCertificate(c.UserContext(), certID)
 	if err != nil {
 		return c.Status(500).SendString("Failed to fetch certificate: " + err.Error())
 	}
@@ -153,7 +156,7 @@ func (h *CertificateHandler) CreateOriginCertificate(c *fiber.Ctx) error {
 		})
 	}
 
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		log.Printf("[Certificate Create Error] Failed to create CF service: %v", err)
 		return c.Status(500).JSON(fiber.Map{
@@ -167,7 +170,31 @@ func (h *CertificateHandler) CreateOriginCertificate(c *fiber.Ctx) error {
 		cleanedHostnames, requestType, validity)
 
 	// 创建证书
-	cert, err := cfService.CreateOriginCertificate(context.Background(), cleanedHostnames, requestType, validity)
+	cert, err := cfService.CreateOriginCertificate(c.UserContext(), cleanedHostnames, requestType, validity)
+
+	// 审计日志只记录证书元数据，不记录私钥
+	entry := audit.Entry{
+		ZoneID: c.Query("zoneid"),
+		Zone:   c.Query("domain"),
+		Action: "CreateOriginCertificate",
+		After: audit.Snapshot(map[string]interface{}{
+			"hostnames":          cleanedHostnames,
+			"request_type":       requestType,
+			"requested_validity": validity,
+		}),
+	}
+	if err == nil {
+		entry.Target = cert.ID
+		entry.After = audit.Snapshot(map[string]interface{}{
+			"id":                 cert.ID,
+			"hostnames":          cert.Hostnames,
+			"request_type":       cert.RequestType,
+			"requested_validity": validity,
+			"expires_on":         cert.ExpiresOn,
+		})
+	}
+	recordAudit(c, h.Audit, entry, err)
+
 	if err != nil {
 		// 详细的错误日志
 		log.Printf("[Certificate Create Error] Cloudflare API error: %v", err)
@@ -202,20 +229,36 @@ func (h *CertificateHandler) CreateOriginCertificate(c *fiber.Ctx) error {
 
 // RevokeOriginCertificate 撤销回源证书
 func (h *CertificateHandler) RevokeOriginCertificate(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	certID := c.Params("id")
 
 	if certID == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "Missing certificate ID"})
 	}
 
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create service"})
 	}
 
-	err = cfService.RevokeOriginCertificate(context.Background(), certID)
+	// 撤销前保存证书元数据
+	var before interface{}
+	if cert, err := cfService.GetOriginCertificate(c.UserContext(), certID); err == nil {
+		before = map[string]interface{}{
+			"id":           cert.ID,
+			"hostnames":    cert.Hostnames,
+			"request_type": cert.RequestType,
+			"expires_on":   cert.ExpiresOn,
+		}
+	}
+
+	err = cfService.RevokeOriginCertificate(c.UserContext(), certID)
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: c.Query("zoneid"),
+		Zone:   c.Query("domain"),
+		Action: "RevokeOriginCertificate",
+		Target: certID,
+		Before: audit.Snapshot(before),
+	}, err)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -228,8 +271,6 @@ func (h *CertificateHandler) RevokeOriginCertificate(c *fiber.Ctx) error {
 
 // GetEdgeCertificateDetails 获取边缘证书详情（HTMX）
 func (h *CertificateHandler) GetEdgeCertificateDetails(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneID := c.Query("zoneid")
 	certID := c.Params("id")
 
@@ -237,12 +278,12 @@ func (h *CertificateHandler) GetEdgeCertificateDetails(c *fiber.Ctx) error {
 		return c.Status(400).SendString("Missing parameters")
 	}
 
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).SendString("Failed to create service")
 	}
 
-	cert, err := cfService.GetEdgeCertificate(context.Background(), zoneID, certID)
+	cert, err := cfService.GetEdgeCertificate(c.UserContext(), zoneID, certID)
 	if err != nil {
 		return c.Status(500).SendString("Failed to fetch certificate: " + err.Error())
 	}
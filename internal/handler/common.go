@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/i18n"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/middleware"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+)
+
+// newCloudflareService 根据当前会话中的凭证创建 Cloudflare 服务
+func newCloudflareService(c *fiber.Ctx, clients service.ClientFactory) (service.Client, error) {
+	authType, _ := c.Locals("auth_type").(string)
+	email, _ := c.Locals("cloudflare_email").(string)
+	apiKey, _ := c.Locals("user_api_key").(string)
+	return clients(authType, email, apiKey)
+}
+
+// credentialRef 当前请求使用的账户凭证引用（缓存按账户区分）
+func credentialRef(c *fiber.Ctx) string {
+	ref, _ := c.Locals("credential_ref").(string)
+	return ref
+}
+
+// newCloudflareServiceForAccount 根据保险库中指定账户的凭证创建 Cloudflare 服务
+func newCloudflareServiceForAccount(clients service.ClientFactory, ref string) (service.Client, error) {
+	cred, err := middleware.Vault.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+	return clients(cred.AuthType, cred.Email, cred.Secret)
+}
+
+// recordAudit 追加审计日志，自动填充时间、操作者、来源 IP 和执行结果
+// 写入失败只输出到标准日志，不影响请求结果
+func recordAudit(c *fiber.Ctx, logger audit.Logger, entry audit.Entry, opErr error) {
+	if logger == nil {
+		return
+	}
+
+	entry.Time = time.Now()
+	entry.Actor = auditActor(c)
+	entry.Account = auditAccount(c)
+	entry.IP = c.IP()
+	entry.Success = opErr == nil
+	if opErr != nil {
+		entry.Error = opErr.Error()
+	}
+
+	if err := logger.Append(entry); err != nil {
+		log.Printf("[Audit Error] Failed to append %s entry: %v", entry.Action, err)
+	}
+}
+
+// auditActor 当前操作者：优先使用账户邮箱，其次使用账户名称
+func auditActor(c *fiber.Ctx) string {
+	if email, _ := c.Locals("cloudflare_email").(string); email != "" {
+		return email
+	}
+	if label, _ := c.Locals("account_label").(string); label != "" {
+		return label
+	}
+	return "API Token"
+}
+
+// auditAccount 当前凭证的标识，审计页面按它显示本账户写入的日志
+func auditAccount(c *fiber.Ctx) string {
+	authType, _ := c.Locals("auth_type").(string)
+	email, _ := c.Locals("cloudflare_email").(string)
+	secret, _ := c.Locals("user_api_key").(string)
+	return service.CredentialID(authType, email, secret)
+}
+
+// localizer 当前请求的 Localizer（由 I18n 中间件注入），缺失时使用英文
+func localizer(c *fiber.Ctx) *goi18n.Localizer {
+	if l, ok := c.Locals("localizer").(*goi18n.Localizer); ok && l != nil {
+		return l
+	}
+	return i18n.GetLocalizer("en")
+}
+
+// checkBudget 批量操作前检查当前凭证的 API 配额，预计需要 n 次请求
+// 在请求截止时间内无法获得足够配额时返回错误，避免执行到一半被限流
+func checkBudget(c *fiber.Ctx, n int) error {
+	budget, ok := c.Locals("APIBudget").(*service.Budget)
+	if !ok {
+		return nil
+	}
+	within := time.Duration(0)
+	if deadline, ok := c.UserContext().Deadline(); ok {
+		within = time.Until(deadline)
+	}
+	if available := budget.Available(within); n > available {
+		return fmt.Errorf("Cloudflare API 配额不足：本次操作约需 %d 次请求，截止时间内最多可用 %d 次，请稍后再试", n, available)
+	}
+	return nil
+}
+
+// latestRequests 同一会话对同一资源只保留最新的请求：新请求开始时取消仍在执行的旧请求
+// 用于 HTMX 逐键搜索，避免每次按键触发的 Cloudflare 调用都跑完
+type latestRequests struct {
+	mu      sync.Mutex
+	cancels map[string]*latestRequest
+}
+
+type latestRequest struct {
+	cancel context.CancelFunc
+}
+
+// begin 为当前请求派生可取消的上下文，返回的 done 必须在请求结束时调用
+func (l *latestRequests) begin(c *fiber.Ctx, resource string) (context.Context, func()) {
+	key := c.Cookies("session_id") + "|" + credentialRef(c) + "|" + resource
+	ctx, cancel := context.WithCancel(c.UserContext())
+	current := &latestRequest{cancel: cancel}
+
+	l.mu.Lock()
+	if l.cancels == nil {
+		l.cancels = make(map[string]*latestRequest)
+	}
+	if previous, ok := l.cancels[key]; ok {
+		previous.cancel()
+	}
+	l.cancels[key] = current
+	l.mu.Unlock()
+
+	return ctx, func() {
+		l.mu.Lock()
+		if l.cancels[key] == current {
+			delete(l.cancels, key)
+		}
+		l.mu.Unlock()
+		cancel()
+	}
+}
@@ -1,19 +1,111 @@
 package handler
 
 import (
-	"context"
+	"fmt"
+	"io"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/cloudflare/cloudflare-go"
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/history"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/i18n"
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/validation"
 )
 
-type DNSHandler struct{}
+type DNSHandler struct {
+	Clients service.ClientFactory
+	Audit   audit.Logger
+	History *history.Store
+	Records *service.RecordCache // 修改记录后清除对应 Zone 的缓存
+}
+
+func NewDNSHandler(clients service.ClientFactory, auditLog audit.Logger, historyStore *history.Store, recordCache *service.RecordCache) *DNSHandler {
+	return &DNSHandler{
+		Clients: clients,
+		Audit:   auditLog,
+		History: historyStore,
+		Records: recordCache,
+	}
+}
 
-func NewDNSHandler() *DNSHandler {
-	return &DNSHandler{}
+// recordTypeForm 表单中单个记录类型的字段及其当前值
+type recordTypeForm struct {
+	*service.RecordType
+	Values map[string]string
+}
+
+// recordTypeForms 构造全部记录类型的表单，选中类型使用已提交的值，其余使用默认值
+func recordTypeForms(selected string, values map[string]string) []recordTypeForm {
+	forms := make([]recordTypeForm, 0, len(service.RecordTypes))
+	for i := range service.RecordTypes {
+		rt := &service.RecordTypes[i]
+		v := rt.DefaultFormValues()
+		if rt.Type == selected && values != nil {
+			v = values
+		}
+		forms = append(forms, recordTypeForm{RecordType: rt, Values: v})
+	}
+	return forms
+}
+
+// editRecordType 编辑时使用的类型定义，未收录的类型按纯内容处理
+func editRecordType(recordType string) *service.RecordType {
+	if rt, ok := service.LookupRecordType(recordType); ok {
+		return rt
+	}
+	return &service.RecordType{Type: recordType, HasContent: true, ContentLabel: "内容"}
+}
+
+// recordFormValues 读取表单中的公共字段和该类型的字段
+func recordFormValues(c *fiber.Ctx, rt *service.RecordType) (map[string]string, service.RecordInput) {
+	values := map[string]string{
+		"name":     c.FormValue("name"),
+		"ttl":      c.FormValue("ttl"),
+		"proxied":  c.FormValue("proxied"),
+		"content":  c.FormValue("content"),
+		"priority": c.FormValue("priority"),
+	}
+	in := service.RecordInput{
+		Content:  values["content"],
+		Priority: values["priority"],
+		Data:     make(map[string]string, len(rt.Fields)),
+	}
+	for _, f := range rt.Fields {
+		v := c.FormValue(f.FormName())
+		values[f.FormName()] = v
+		in.Data[f.Key] = v
+	}
+	return values, in
+}
+
+// checkRecord 校验名称、TTL、代理和记录内容，与 Build 的字段错误一起按请求语言翻译
+// 同一字段保留先出现的错误（Build 的错误优先）
+func checkRecord(c *fiber.Ctx, r validation.Record, values map[string]string, buildErrs validation.Errors) (int, map[string]string) {
+	errs := append(validation.Errors{}, buildErrs...)
+	if strings.TrimSpace(values["name"]) == "" {
+		errs = append(errs, validation.Error{Field: "name", MessageID: "validation_name_required"})
+	}
+	errs = append(errs, validation.Validate(r)...)
+	ttl, ttlErr := validation.ParseTTL(values["ttl"])
+	if ttlErr != nil {
+		errs = append(errs, *ttlErr)
+	}
+	return ttl, errs.Localize(localizer(c))
+}
+
+// checkConflicts 查询同名记录，检查 CNAME 共存和重复 SPF
+// 查询失败时不阻止提交，由 Cloudflare 接口做最终校验
+func checkConflicts(c *fiber.Ctx, cfService service.Client, zoneID, domain string, r validation.Record) map[string]string {
+	existing, _, err := cfService.ListDNSRecords(c.UserContext(), cloudflare.ZoneIdentifier(zoneID), cloudflare.ListDNSRecordsParams{Name: r.Name})
+	if err != nil {
+		return nil
+	}
+	return validation.Conflicts(r, domain, existing).Localize(localizer(c))
 }
 
 // ShowAddRecord 显示添加记录页面
@@ -28,88 +120,100 @@ func (h *DNSHandler) ShowAddRecord(c *fiber.Ctx) error {
 		"CurrentPage": "添加记录 - " + domain,
 		"ZoneID":      zoneID,
 		"Domain":      domain,
+		"Type":        "A",
+		"Values":      map[string]string{"ttl": "1"},
+		"Types":       recordTypeForms("A", nil),
 	})
 }
 
 // AddRecord 添加 DNS 记录
 func (h *DNSHandler) AddRecord(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneID := c.FormValue("zoneid")
 	domain := c.FormValue("domain")
+	recordType := c.FormValue("type")
 
-	// 创建 Cloudflare 服务
-	cfService, err := service.NewCloudflareService(email, apiKey)
-	if err != nil {
-		return c.Status(500).SendString("Failed to create Cloudflare service")
+	rt, ok := service.LookupRecordType(recordType)
+	if !ok {
+		return c.Status(400).SendString("Unsupported record type: " + recordType)
 	}
+	values, in := recordFormValues(c, rt)
 
-	// 构建 DNS 记录参数
-	recordType := c.FormValue("type")
-	name := c.FormValue("name")
-	content := c.FormValue("content")
-	ttl, _ := strconv.Atoi(c.FormValue("ttl"))
-	proxied := c.FormValue("proxied") == "true"
+	// 表单出错时保留用户输入
+	renderForm := func(errMsg string, fieldErrors map[string]string) error {
+		return c.Render("dns/add", fiber.Map{
+			"PageTitle":   "添加 DNS 记录",
+			"ShowNav":     true,
+			"AppTitle":    "Cloudflare DNS Manager",
+			"CurrentPage": "添加记录 - " + domain,
+			"ZoneID":      zoneID,
+			"Domain":      domain,
+			"Type":        rt.Type,
+			"Values":      values,
+			"Types":       recordTypeForms(rt.Type, values),
+			"Error":       errMsg,
+			"Errors":      fieldErrors,
+		})
+	}
 
-	params := cloudflare.CreateDNSRecordParams{
-		Type:    recordType,
-		Name:    name,
-		Content: content,
-		TTL:     ttl,
+	// 调用 API 前完成校验
+	built, buildErrs := rt.Build(in)
+	record := validation.Record{
+		Type:    rt.Type,
+		Name:    validation.FQDN(values["name"], domain),
+		Content: built.Content,
+		Proxied: values["proxied"] == "true",
+	}
+	ttl, fieldErrors := checkRecord(c, record, values, buildErrs)
+	if fieldErrors != nil {
+		return renderForm(i18n.T(localizer(c), "validation_failed"), fieldErrors)
 	}
 
-	// 只有 A, AAAA, CNAME 可以启用代理
-	if recordType == "A" || recordType == "AAAA" || recordType == "CNAME" {
-		val := proxied
-		params.Proxied = &val
+	// 创建 Cloudflare 服务
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		return c.Status(500).SendString("Failed to create Cloudflare service")
 	}
 
-	// 处理 MX 记录优先级
-	if recordType == "MX" {
-		priority, _ := strconv.Atoi(c.FormValue("priority"))
-		prio := uint16(priority)
-		params.Priority = &prio
+	if fieldErrors := checkConflicts(c, cfService, zoneID, domain, record); fieldErrors != nil {
+		return renderForm(i18n.T(localizer(c), "validation_failed"), fieldErrors)
 	}
 
-	// 处理 CAA 记录
-	if recordType == "CAA" {
-		params.Data = map[string]interface{}{
-			"tag":   c.FormValue("data_tag"),
-			"value": c.FormValue("data_value"),
-			"flags": 0,
-		}
+	// 构建 DNS 记录参数
+	name := values["name"]
+	params := cloudflare.CreateDNSRecordParams{
+		Type:     rt.Type,
+		Name:     name,
+		Content:  built.Content,
+		Data:     built.Data,
+		Priority: built.Priority,
+		TTL:      ttl,
 	}
 
-	// 处理 SRV 记录
-	if recordType == "SRV" {
-		port, _ := strconv.Atoi(c.FormValue("srv_port"))
-		priority, _ := strconv.Atoi(c.FormValue("srv_priority"))
-		weight, _ := strconv.Atoi(c.FormValue("srv_weight"))
-
-		params.Data = map[string]interface{}{
-			"service":  c.FormValue("srv_service"),
-			"proto":    c.FormValue("srv_proto"),
-			"name":     name,
-			"port":     port,
-			"priority": priority,
-			"weight":   weight,
-			"target":   c.FormValue("srv_target"),
-		}
+	// 只有 A, AAAA, CNAME 可以启用代理
+	if rt.Proxiable {
+		params.Proxied = &record.Proxied
 	}
 
+	// 修改前保存快照
+	snapshotZone(c, h.History, cfService, zoneID, domain, "AddRecord "+name)
+
 	// 创建记录
 	rc := cloudflare.ZoneIdentifier(zoneID)
-	_, err = cfService.CreateDNSRecord(context.Background(), rc, params)
+	created, err := cfService.CreateDNSRecord(c.UserContext(), rc, params)
+	h.Records.Invalidate(zoneID)
+	after := audit.Snapshot(params)
+	if err == nil {
+		after = audit.Snapshot(created)
+	}
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zoneID,
+		Zone:   domain,
+		Action: "AddRecord",
+		Target: created.ID,
+		After:  after,
+	}, err)
 	if err != nil {
-		return c.Render("dns/add", fiber.Map{
-			"PageTitle":   "添加 DNS 记录",
-			"ShowNav":     true,
-			"AppTitle":    "Cloudflare DNS Manager",
-			"CurrentPage": "添加记录 - " + domain,
-			"ZoneID":      zoneID,
-			"Domain":      domain,
-			"Error":       "Failed to add record: " + err.Error(),
-		})
+		return renderForm("Failed to add record: "+err.Error(), nil)
 	}
 
 	return c.Redirect("/zone?zoneid=" + zoneID + "&domain=" + domain)
@@ -117,25 +221,31 @@ func (h *DNSHandler) AddRecord(c *fiber.Ctx) error {
 
 // ShowEditRecord 显示编辑记录页面
 func (h *DNSHandler) ShowEditRecord(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneID := c.Query("zoneid")
 	domain := c.Query("domain")
 	recordID := c.Query("recordid")
 
 	// 创建 Cloudflare 服务
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).SendString("Failed to create Cloudflare service")
 	}
 
 	// 获取记录详情
 	rc := cloudflare.ZoneIdentifier(zoneID)
-	record, err := cfService.GetDNSRecord(context.Background(), rc, recordID)
+	record, err := cfService.GetDNSRecord(c.UserContext(), rc, recordID)
 	if err != nil {
 		return c.Status(500).SendString("Failed to fetch record: " + err.Error())
 	}
 
+	rt := editRecordType(record.Type)
+	values := rt.FormValues(record)
+	values["name"] = record.Name
+	values["ttl"] = strconv.Itoa(record.TTL)
+	if record.Proxied != nil && *record.Proxied {
+		values["proxied"] = "true"
+	}
+
 	return c.Render("dns/edit", fiber.Map{
 		"PageTitle":   "编辑 DNS 记录",
 		"ShowNav":     true,
@@ -144,70 +254,103 @@ func (h *DNSHandler) ShowEditRecord(c *fiber.Ctx) error {
 		"ZoneID":      zoneID,
 		"Domain":      domain,
 		"Record":      record,
+		"RecordType":  recordTypeForm{RecordType: rt, Values: values},
+		"Values":      values,
 	})
 }
 
 // EditRecord 编辑 DNS 记录
 func (h *DNSHandler) EditRecord(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneID := c.FormValue("zoneid")
 	domain := c.FormValue("domain")
 	recordID := c.FormValue("recordid")
 
 	// 创建 Cloudflare 服务
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).SendString("Failed to create Cloudflare service")
 	}
 
 	// 获取原记录
 	rc := cloudflare.ZoneIdentifier(zoneID)
-	record, err := cfService.GetDNSRecord(context.Background(), rc, recordID)
+	record, err := cfService.GetDNSRecord(c.UserContext(), rc, recordID)
 	if err != nil {
 		return c.Status(500).SendString("Failed to fetch record")
 	}
 
-	// 构建更新参数
-	name := c.FormValue("name")
-	content := c.FormValue("content")
-	ttl, _ := strconv.Atoi(c.FormValue("ttl"))
-	proxied := c.FormValue("proxied") == "true"
+	rt := editRecordType(record.Type)
+	values, in := recordFormValues(c, rt)
 
-	params := cloudflare.UpdateDNSRecordParams{
+	// 表单出错时保留用户输入
+	renderForm := func(errMsg string, fieldErrors map[string]string) error {
+		return c.Render("dns/edit", fiber.Map{
+			"PageTitle":   "编辑 DNS 记录",
+			"ShowNav":     true,
+			"AppTitle":    "Cloudflare DNS Manager",
+			"CurrentPage": "编辑记录 - " + domain,
+			"ZoneID":      zoneID,
+			"Domain":      domain,
+			"Record":      record,
+			"RecordType":  recordTypeForm{RecordType: rt, Values: values},
+			"Values":      values,
+			"Error":       errMsg,
+			"Errors":      fieldErrors,
+		})
+	}
+
+	// 调用 API 前完成校验
+	built, buildErrs := rt.Build(in)
+	check := validation.Record{
 		ID:      recordID,
 		Type:    record.Type,
-		Name:    name,
-		Content: content,
-		TTL:     ttl,
+		Name:    validation.FQDN(values["name"], domain),
+		Content: built.Content,
+		Proxied: values["proxied"] == "true",
+	}
+	ttl, fieldErrors := checkRecord(c, check, values, buildErrs)
+	if fieldErrors == nil {
+		fieldErrors = checkConflicts(c, cfService, zoneID, domain, check)
+	}
+	if fieldErrors != nil {
+		return renderForm(i18n.T(localizer(c), "validation_failed"), fieldErrors)
 	}
 
-	// 只有 A, AAAA, CNAME 可以启用代理
-	if record.Type == "A" || record.Type == "AAAA" || record.Type == "CNAME" {
-		val := proxied
-		params.Proxied = &val
+	// 构建更新参数
+	params := cloudflare.UpdateDNSRecordParams{
+		ID:       recordID,
+		Type:     record.Type,
+		Name:     values["name"],
+		Content:  built.Content,
+		Data:     built.Data,
+		Priority: built.Priority,
+		TTL:      ttl,
 	}
 
-	// 处理 MX 记录
-	if record.Type == "MX" {
-		priority, _ := strconv.Atoi(c.FormValue("priority"))
-		prio := uint16(priority)
-		params.Priority = &prio
+	// 只有 A, AAAA, CNAME 可以启用代理
+	if rt.Proxiable {
+		params.Proxied = &check.Proxied
 	}
 
+	// 修改前保存快照
+	snapshotZone(c, h.History, cfService, zoneID, domain, "EditRecord "+record.Name)
+
 	// 更新记录
-	_, err = cfService.UpdateDNSRecord(context.Background(), rc, params)
+	updated, err := cfService.UpdateDNSRecord(c.UserContext(), rc, params)
+	h.Records.Invalidate(zoneID)
+	after := audit.Snapshot(params)
+	if err == nil {
+		after = audit.Snapshot(updated)
+	}
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zoneID,
+		Zone:   domain,
+		Action: "EditRecord",
+		Target: recordID,
+		Before: audit.Snapshot(record),
+		After:  after,
+	}, err)
 	if err != nil {
-		return c.Render("dns/edit", fiber.Map{
-			"PageTitle":   "编辑 DNS 记录",
-			"ShowNav":     true,
-			"AppTitle":    "Cloudflare DNS Manager",
-			"CurrentPage": "编辑记录 - " + domain,
-			"ZoneID":      zoneID,
-			"Domain":      domain,
-			"Record":      record,
-			"Error":       "Failed to update record: " + err.Error(),
-		})
+		return renderForm("Failed to update record: "+err.Error(), nil)
 	}
 
 	return c.Redirect("/zone?zoneid=" + zoneID + "&domain=" + domain)
@@ -215,21 +358,36 @@ func (h *DNSHandler) EditRecord(c *fiber.Ctx) error {
 
 // DeleteRecord 删除 DNS 记录
 func (h *DNSHandler) DeleteRecord(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneID := c.Query("zoneid")
 	domain := c.Query("domain")
 	recordID := c.Query("delete")
 
 	// 创建 Cloudflare 服务
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).SendString("Failed to create Cloudflare service")
 	}
 
-	// 删除记录
+	// 删除前保存记录快照
 	rc := cloudflare.ZoneIdentifier(zoneID)
-	err = cfService.DeleteDNSRecord(context.Background(), rc, recordID)
+	var before interface{}
+	reason := "DeleteRecord " + recordID
+	if record, err := cfService.GetDNSRecord(c.UserContext(), rc, recordID); err == nil {
+		before = record
+		reason = "DeleteRecord " + record.Name
+	}
+	snapshotZone(c, h.History, cfService, zoneID, domain, reason)
+
+	// 删除记录
+	err = cfService.DeleteDNSRecord(c.UserContext(), rc, recordID)
+	h.Records.Invalidate(zoneID)
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zoneID,
+		Zone:   domain,
+		Action: "DeleteRecord",
+		Target: recordID,
+		Before: audit.Snapshot(before),
+	}, err)
 	if err != nil {
 		return c.SendString("Failed to delete record: " + err.Error())
 	}
@@ -239,20 +397,19 @@ func (h *DNSHandler) DeleteRecord(c *fiber.Ctx) error {
 
 // ToggleProxy HTMX API：切换 CDN 代理
 func (h *DNSHandler) ToggleProxy(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneID := c.Query("zoneid")
+	domain := c.Query("domain")
 	recordID := c.Params("id")
 
 	// 创建 Cloudflare 服务
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).SendString("Error")
 	}
 
 	// 获取当前记录
 	rc := cloudflare.ZoneIdentifier(zoneID)
-	record, err := cfService.GetDNSRecord(context.Background(), rc, recordID)
+	record, err := cfService.GetDNSRecord(c.UserContext(), rc, recordID)
 	if err != nil {
 		return c.Status(500).SendString("Error")
 	}
@@ -263,6 +420,9 @@ func (h *DNSHandler) ToggleProxy(c *fiber.Ctx) error {
 		newProxied = !*record.Proxied
 	}
 
+	// 修改前保存快照
+	snapshotZone(c, h.History, cfService, zoneID, domain, "ToggleProxy "+record.Name)
+
 	// 更新记录
 	params := cloudflare.UpdateDNSRecordParams{
 		ID:      recordID,
@@ -273,7 +433,20 @@ func (h *DNSHandler) ToggleProxy(c *fiber.Ctx) error {
 		Proxied: &newProxied,
 	}
 
-	_, err = cfService.UpdateDNSRecord(context.Background(), rc, params)
+	updated, err := cfService.UpdateDNSRecord(c.UserContext(), rc, params)
+	h.Records.Invalidate(zoneID)
+	after := audit.Snapshot(params)
+	if err == nil {
+		after = audit.Snapshot(updated)
+	}
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zoneID,
+		Zone:   domain,
+		Action: "ToggleProxy",
+		Target: recordID,
+		Before: audit.Snapshot(record),
+		After:  after,
+	}, err)
 	if err != nil {
 		return c.Status(500).SendString("Error")
 	}
@@ -286,5 +459,274 @@ func (h *DNSHandler) ToggleProxy(c *fiber.Ctx) error {
 		height = "19"
 	}
 
-	return c.SendString(`<img src="` + imgPath + `" height="` + height + `" hx-post="/api/dns/` + recordID + `/toggle-proxy?zoneid=` + zoneID + `" hx-trigger="click" hx-swap="outerHTML" style="cursor:pointer;" />`)
+	return c.SendString(`<img src="` + imgPath + `" height="` + height + `" hx-post="/api/dns/` + recordID + `/toggle-proxy?zoneid=` + url.QueryEscape(zoneID) + `&domain=` + url.QueryEscape(domain) + `" hx-trigger="click" hx-swap="outerHTML" style="cursor:pointer;" />`)
+}
+
+// ExportZoneFile 导出 BIND 格式的 zone 文件
+func (h *DNSHandler) ExportZoneFile(c *fiber.Ctx) error {
+	zoneID := c.Query("zoneid")
+	domain := c.Query("domain")
+
+	if zoneID == "" || domain == "" {
+		return c.Status(400).SendString("Missing zoneid or domain parameter")
+	}
+
+	// 创建 Cloudflare 服务
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		return c.Status(500).SendString("Failed to create Cloudflare service")
+	}
+
+	// 获取全部记录（自动翻页）
+	records, err := service.ListAllDNSRecords(c.UserContext(), cfService, zoneID)
+	if err != nil {
+		return c.Status(500).SendString("Failed to fetch DNS records: " + err.Error())
+	}
+
+	zoneFile, err := service.BuildZoneFile(domain, records)
+	if err != nil {
+		return c.Status(500).SendString("Failed to build zone file: " + err.Error())
+	}
+
+	// 设置下载响应头
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.zone", domain))
+	c.Set("Content-Type", "text/dns; charset=utf-8")
+
+	return c.SendString(zoneFile)
+}
+
+// ShowImportZoneFile 显示导入 zone 文件页面
+func (h *DNSHandler) ShowImportZoneFile(c *fiber.Ctx) error {
+	zoneID := c.Query("zoneid")
+	domain := c.Query("domain")
+
+	if zoneID == "" || domain == "" {
+		return c.Redirect("/zones")
+	}
+
+	return c.Render("dns/import", fiber.Map{
+		"ZoneID": zoneID,
+		"Domain": domain,
+	})
+}
+
+// ImportZoneFile 解析 zone 文件并生成变更计划；action=apply 时执行变更
+func (h *DNSHandler) ImportZoneFile(c *fiber.Ctx) error {
+	zoneID := c.FormValue("zoneid")
+	domain := c.FormValue("domain")
+	action := c.FormValue("action") // "preview" or "apply"
+	prune := c.FormValue("prune") == "true"
+
+	data := fiber.Map{
+		"ZoneID": zoneID,
+		"Domain": domain,
+		"Prune":  prune,
+	}
+
+	if zoneID == "" || domain == "" {
+		return c.Redirect("/zones")
+	}
+
+	// 读取 zone 文件内容：上传文件优先，否则使用粘贴内容
+	content := c.FormValue("content")
+	if file, err := c.FormFile("zonefile"); err == nil && file.Size > 0 {
+		f, err := file.Open()
+		if err != nil {
+			data["Error"] = "读取上传文件失败: " + err.Error()
+			return c.Render("dns/import", data)
+		}
+		defer f.Close()
+		raw, err := io.ReadAll(f)
+		if err != nil {
+			data["Error"] = "读取上传文件失败: " + err.Error()
+			return c.Render("dns/import", data)
+		}
+		content = string(raw)
+	}
+	data["Content"] = content
+
+	if content == "" {
+		data["Error"] = "请粘贴或上传 zone 文件"
+		return c.Render("dns/import", data)
+	}
+
+	// 解析 zone 文件
+	desired, skipped, err := service.ParseZoneFile(domain, content)
+	if err != nil {
+		data["Error"] = "解析 zone 文件失败: " + err.Error()
+		return c.Render("dns/import", data)
+	}
+	data["Skipped"] = skipped
+
+	// 创建 Cloudflare 服务
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		data["Error"] = "Failed to create Cloudflare service"
+		return c.Render("dns/import", data)
+	}
+
+	// 获取现有记录并生成变更计划
+	current, err := service.ListAllDNSRecords(c.UserContext(), cfService, zoneID)
+	if err != nil {
+		data["Error"] = "Failed to fetch DNS records: " + err.Error()
+		return c.Render("dns/import", data)
+	}
+
+	plan := service.DiffRecords(current, desired, prune)
+	data["Plan"] = plan
+
+	if action != "apply" || plan.Empty() {
+		return c.Render("dns/import", data)
+	}
+
+	if err := checkBudget(c, len(plan.Changes())); err != nil {
+		data["Error"] = err.Error()
+		return c.Render("dns/import", data)
+	}
+
+	// 修改前保存快照
+	snapshotZone(c, h.History, cfService, zoneID, domain, "ImportZoneFile")
+
+	// 执行变更（单条失败不影响其余记录）
+	results := service.ApplyPlan(c.UserContext(), cfService, zoneID, plan)
+	h.Records.Invalidate(zoneID)
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+		target := ""
+		if result.Change.Current != nil {
+			target = result.Change.Current.ID
+		}
+		recordAudit(c, h.Audit, audit.Entry{
+			ZoneID: zoneID,
+			Zone:   domain,
+			Action: "ImportZoneFile",
+			Target: target,
+			Before: audit.Snapshot(result.Change.Current),
+			After:  audit.Snapshot(result.Change.Desired),
+		}, result.Err)
+	}
+	data["Results"] = results
+	data["Failed"] = failed
+	data["Plan"] = nil
+
+	return c.Render("dns/import", data)
+}
+
+// bulkAuditActions 批量操作对应的审计操作名
+var bulkAuditActions = map[string]string{
+	service.BulkDelete:   "DeleteRecord",
+	service.BulkProxyOn:  "ToggleProxy",
+	service.BulkProxyOff: "ToggleProxy",
+	service.BulkTTL:      "EditRecord",
+	service.BulkReplace:  "EditRecord",
+}
+
+// BulkRecords 对选中的记录执行批量操作（HTMX），返回逐条结果
+func (h *DNSHandler) BulkRecords(c *fiber.Ctx) error {
+	zoneID := c.Query("zoneid")
+	domain := c.Query("domain")
+
+	data := fiber.Map{
+		"ZoneID": zoneID,
+		"Domain": domain,
+	}
+
+	var ids []string
+	for _, id := range c.Context().PostArgs().PeekMulti("ids") {
+		ids = append(ids, string(id))
+	}
+	if zoneID == "" || len(ids) == 0 {
+		data["Error"] = "请至少选择一条记录"
+		return c.Render("zone/partials/bulk-results", data, "")
+	}
+
+	op := service.BulkOperation{
+		Action:  c.FormValue("bulk_action"),
+		Find:    c.FormValue("find"),
+		Replace: c.FormValue("replace"),
+	}
+	// 只有修改 TTL 时才读取 TTL，无法解析时报错而不是按 0 处理
+	if op.Action == service.BulkTTL {
+		ttl, ttlErr := validation.ParseTTL(c.FormValue("ttl"))
+		if ttlErr != nil {
+			data["Error"] = validation.Errors{*ttlErr}.Localize(localizer(c))["ttl"]
+			return c.Render("zone/partials/bulk-results", data, "")
+		}
+		op.TTL = ttl
+	}
+	if err := op.Validate(); err != nil {
+		data["Error"] = err.Error()
+		return c.Render("zone/partials/bulk-results", data, "")
+	}
+
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		data["Error"] = "创建 Cloudflare 服务失败"
+		return c.Render("zone/partials/bulk-results", data, "")
+	}
+
+	// 按 ID 选出记录，同时用于快照
+	all, err := service.ListAllDNSRecords(c.UserContext(), cfService, zoneID)
+	if err != nil {
+		data["Error"] = "获取 DNS 记录失败: " + err.Error()
+		return c.Render("zone/partials/bulk-results", data, "")
+	}
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	var records []cloudflare.DNSRecord
+	for _, record := range all {
+		if selected[record.ID] {
+			records = append(records, record)
+		}
+	}
+	if len(records) == 0 {
+		data["Error"] = "选中的记录不存在，请刷新页面"
+		return c.Render("zone/partials/bulk-results", data, "")
+	}
+
+	if err := checkBudget(c, len(records)); err != nil {
+		data["Error"] = err.Error()
+		return c.Render("zone/partials/bulk-results", data, "")
+	}
+
+	snapshotZone(c, h.History, cfService, zoneID, domain, fmt.Sprintf("Bulk %s (%d)", op.Action, len(records)))
+
+	results := service.BulkApply(c.UserContext(), cfService, zoneID, records, op, service.DefaultBulkConcurrency)
+	h.Records.Invalidate(zoneID)
+
+	succeeded, skipped, failed := 0, 0, 0
+	for _, result := range results {
+		switch {
+		case result.Skipped:
+			skipped++
+			continue
+		case result.Err != nil:
+			failed++
+		default:
+			succeeded++
+		}
+
+		entry := audit.Entry{
+			ZoneID: zoneID,
+			Zone:   domain,
+			Action: bulkAuditActions[op.Action],
+			Target: result.Record.ID,
+			Before: audit.Snapshot(result.Record),
+		}
+		if result.After != nil {
+			entry.After = audit.Snapshot(result.After)
+		}
+		recordAudit(c, h.Audit, entry, result.Err)
+	}
+
+	data["Results"] = results
+	data["Succeeded"] = succeeded
+	data["Skipped"] = skipped
+	data["Failed"] = failed
+	return c.Render("zone/partials/bulk-results", data, "")
 }
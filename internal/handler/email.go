@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/history"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/i18n"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+)
+
+// emailErrors 重定向错误码对应的提示
+var emailErrors = map[string]string{
+	"missing_params":      "缺少必要参数",
+	"service_init_failed": "创建 Cloudflare 服务失败",
+	"fetch_failed":        "获取 DNS 记录失败",
+	"invalid_options":     "选项无效",
+	"nothing_selected":    "没有选择需要应用的记录",
+	"apply_failed":        "部分记录应用失败",
+}
+
+// emailLookupTimeout SPF 递归查询的总超时
+const emailLookupTimeout = 10 * time.Second
+
+type EmailHandler struct {
+	Clients  service.ClientFactory
+	Audit    audit.Logger
+	History  *history.Store
+	Resolver service.Resolver
+	Records  *service.RecordCache // 应用向导后清除对应 Zone 的缓存
+}
+
+func NewEmailHandler(clients service.ClientFactory, auditLog audit.Logger, historyStore *history.Store, resolver service.Resolver, recordCache *service.RecordCache) *EmailHandler {
+	return &EmailHandler{
+		Clients:  clients,
+		Audit:    auditLog,
+		History:  historyStore,
+		Resolver: resolver,
+		Records:  recordCache,
+	}
+}
+
+// emailOptions 读取向导选项（查询参数或表单），未提交时使用根据现有记录生成的默认值
+func emailOptions(c *fiber.Ctx, analysis *service.EmailSecurity) service.EmailOptions {
+	if c.FormValue("dmarc_policy") == "" {
+		return analysis.DefaultOptions()
+	}
+	value := func(key string) string {
+		return strings.TrimSpace(c.FormValue(key))
+	}
+	return service.EmailOptions{
+		DMARCPolicy:  value("dmarc_policy"),
+		DMARCReport:  value("dmarc_report"),
+		DKIMSelector: value("dkim_selector"),
+		DKIMKey:      value("dkim_key"),
+		MTASTS:       value("mta_sts") == "true",
+		TLSReport:    value("tls_report"),
+		BIMILogo:     value("bimi_logo"),
+		BIMICert:     value("bimi_cert"),
+	}
+}
+
+// analyzeEmail 获取 Zone 全部记录并检查邮件安全状况，问题说明使用请求的语言
+func (h *EmailHandler) analyzeEmail(c *fiber.Ctx, cfService service.Client, zoneID, domain string) (*service.EmailSecurity, error) {
+	ctx := c.UserContext()
+	records, err := service.ListAllDNSRecords(ctx, cfService, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, emailLookupTimeout)
+	defer cancel()
+	return service.AnalyzeEmailSecurity(lookupCtx, domain, records, h.Resolver, localizer(c)), nil
+}
+
+// ShowEmail 显示邮件安全向导
+func (h *EmailHandler) ShowEmail(c *fiber.Ctx) error {
+	zoneID := c.Query("zoneid")
+	domain := c.Query("domain")
+
+	if zoneID == "" || domain == "" {
+		return c.Status(400).SendString("Missing zoneid or domain parameter")
+	}
+
+	data := fiber.Map{
+		"ZoneID":   zoneID,
+		"Domain":   domain,
+		"Policies": service.DMARCPolicies,
+		"Success":  c.Query("success"),
+		"Count":    c.Query("count"),
+	}
+	if code := c.Query("error"); code != "" {
+		msg := emailErrors[code]
+		if msg == "" {
+			msg = code
+		}
+		if detail := c.Query("detail"); detail != "" {
+			msg += ": " + detail
+		}
+		data["Error"] = msg
+	}
+
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		data["Error"] = emailErrors["service_init_failed"]
+		return c.Render("email/index", data)
+	}
+
+	analysis, err := h.analyzeEmail(c, cfService, zoneID, domain)
+	if err != nil {
+		data["Error"] = emailErrors["fetch_failed"] + ": " + err.Error()
+		return c.Render("email/index", data)
+	}
+
+	opts := emailOptions(c, analysis)
+	data["Analysis"] = analysis
+	data["Options"] = opts
+	if err := opts.Validate(); err != nil {
+		data["OptionsError"] = err.Error()
+	} else {
+		data["Changes"] = analysis.Changes(opts, time.Now())
+	}
+	// 选择器已委派给邮件服务商时不生成 DKIM 记录，提示用户原因
+	if target := analysis.DKIMDelegated(opts.DKIMSelector); opts.DKIMSelector != "" && target != "" {
+		data["DKIMNotice"] = i18n.TData(localizer(c), "email_dkim_cname", map[string]interface{}{
+			"Name":   opts.DKIMSelector + "._domainkey." + domain,
+			"Target": target,
+		})
+	}
+	return c.Render("email/index", data)
+}
+
+// ApplyEmail 应用向导生成的记录
+// 服务端根据提交的选项重新生成记录，只应用勾选的项目
+func (h *EmailHandler) ApplyEmail(c *fiber.Ctx) error {
+	zoneID := c.FormValue("zoneid")
+	domain := c.FormValue("domain")
+
+	back := fmt.Sprintf("/email?zoneid=%s&domain=%s", url.QueryEscape(zoneID), url.QueryEscape(domain))
+	if zoneID == "" || domain == "" {
+		return c.Redirect(back + "&error=missing_params")
+	}
+
+	selected := make(map[string]bool)
+	for _, key := range c.Context().PostArgs().PeekMulti("apply") {
+		selected[string(key)] = true
+	}
+	if len(selected) == 0 {
+		return c.Redirect(back + "&error=nothing_selected")
+	}
+
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		return c.Redirect(back + "&error=service_init_failed")
+	}
+
+	analysis, err := h.analyzeEmail(c, cfService, zoneID, domain)
+	if err != nil {
+		return c.Redirect(back + "&error=fetch_failed&detail=" + url.QueryEscape(err.Error()))
+	}
+	opts := emailOptions(c, analysis)
+	if err := opts.Validate(); err != nil {
+		return c.Redirect(back + "&error=invalid_options&detail=" + url.QueryEscape(err.Error()))
+	}
+
+	var changes []service.EmailChange
+	for _, change := range analysis.Changes(opts, time.Now()) {
+		if selected[change.Key] {
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		return c.Redirect(back + "&error=nothing_selected")
+	}
+
+	snapshotZone(c, h.History, cfService, zoneID, domain, "ApplyEmailSecurity")
+
+	rc := cloudflare.ZoneIdentifier(zoneID)
+	var failures []string
+	for _, change := range changes {
+		if err := h.applyEmailChange(c, cfService, rc, zoneID, domain, change); err != nil {
+			failures = append(failures, change.Name+": "+err.Error())
+		}
+	}
+	h.Records.Invalidate(zoneID)
+
+	if len(failures) > 0 {
+		return c.Redirect(back + "&error=apply_failed&detail=" + url.QueryEscape(strings.Join(failures, "; ")))
+	}
+	return c.Redirect(back + "&success=applied&count=" + strconv.Itoa(len(changes)))
+}
+
+// applyEmailChange 新建或更新一条 TXT 记录，并删除合并掉的重复记录
+func (h *EmailHandler) applyEmailChange(c *fiber.Ctx, cfService service.Client, rc *cloudflare.ResourceContainer, zoneID, domain string, change service.EmailChange) error {
+	ctx := c.UserContext()
+
+	if change.RecordID == "" {
+		params := cloudflare.CreateDNSRecordParams{
+			Type:    "TXT",
+			Name:    change.Name,
+			Content: change.Content,
+			TTL:     1,
+		}
+		created, err := cfService.CreateDNSRecord(ctx, rc, params)
+		after := audit.Snapshot(params)
+		if err == nil {
+			after = audit.Snapshot(created)
+		}
+		recordAudit(c, h.Audit, audit.Entry{
+			ZoneID: zoneID,
+			Zone:   domain,
+			Action: "AddRecord",
+			Target: "TXT " + change.Name,
+			After:  after,
+		}, err)
+		return err
+	}
+
+	before, err := cfService.GetDNSRecord(ctx, rc, change.RecordID)
+	if err != nil {
+		return err
+	}
+	params := cloudflare.UpdateDNSRecordParams{
+		ID:      change.RecordID,
+		Type:    "TXT",
+		Name:    change.Name,
+		Content: change.Content,
+		TTL:     before.TTL,
+	}
+	updated, err := cfService.UpdateDNSRecord(ctx, rc, params)
+	after := audit.Snapshot(params)
+	if err == nil {
+		after = audit.Snapshot(updated)
+	}
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zoneID,
+		Zone:   domain,
+		Action: "EditRecord",
+		Target: change.RecordID,
+		Before: audit.Snapshot(before),
+		After:  after,
+	}, err)
+	if err != nil {
+		return err
+	}
+
+	// 合并后删除重复的 SPF / DMARC 记录
+	for _, id := range change.DeleteIDs {
+		dup, getErr := cfService.GetDNSRecord(ctx, rc, id)
+		err := cfService.DeleteDNSRecord(ctx, rc, id)
+		entry := audit.Entry{
+			ZoneID: zoneID,
+			Zone:   domain,
+			Action: "DeleteRecord",
+			Target: id,
+		}
+		if getErr == nil {
+			entry.Before = audit.Snapshot(dup)
+		}
+		recordAudit(c, h.Audit, entry, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"log"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/history"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/i18n"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+)
+
+// currentSnapshotID 表示 Zone 当前的线上记录（用于对比）
+const currentSnapshotID = "current"
+
+// HistoryHandler DNS 记录快照：历史列表、对比与恢复
+type HistoryHandler struct {
+	Clients service.ClientFactory
+	History *history.Store
+	Audit   audit.Logger
+	Records *service.RecordCache // 恢复快照后清除对应 Zone 的缓存
+}
+
+func NewHistoryHandler(clients service.ClientFactory, historyStore *history.Store, auditLog audit.Logger, recordCache *service.RecordCache) *HistoryHandler {
+	return &HistoryHandler{
+		Clients: clients,
+		History: historyStore,
+		Audit:   auditLog,
+		Records: recordCache,
+	}
+}
+
+// ShowHistory 快照列表；指定 from 和 to 时显示两者之间的差异
+func (h *HistoryHandler) ShowHistory(c *fiber.Ctx) error {
+	zoneID := c.Query("zoneid")
+	domain := c.Query("domain")
+	from := c.Query("from")
+	to := c.Query("to")
+
+	if zoneID == "" || domain == "" {
+		return c.Redirect("/zones")
+	}
+
+	data := fiber.Map{
+		"ZoneID": zoneID,
+		"Domain": domain,
+		"From":   from,
+		"To":     to,
+	}
+
+	cfService, errMsg := h.openZone(c, zoneID)
+	if errMsg != "" {
+		data["Error"] = errMsg
+		return c.Render("zone/history", data)
+	}
+
+	snapshots, err := h.History.List(zoneID)
+	if err != nil {
+		data["Error"] = historyReadError(c, err)
+		return c.Render("zone/history", data)
+	}
+	data["Snapshots"] = snapshots
+
+	if from == "" || to == "" {
+		return c.Render("zone/history", data)
+	}
+
+	fromRecords, err := h.snapshotRecords(c.UserContext(), cfService, zoneID, from)
+	if err != nil {
+		data["Error"] = historyReadError(c, err)
+		return c.Render("zone/history", data)
+	}
+	toRecords, err := h.snapshotRecords(c.UserContext(), cfService, zoneID, to)
+	if err != nil {
+		data["Error"] = historyReadError(c, err)
+		return c.Render("zone/history", data)
+	}
+
+	data["Plan"] = service.DiffRecords(fromRecords, toRecords, true)
+	return c.Render("zone/history", data)
+}
+
+// ShowRestore 预览恢复快照所需的变更
+func (h *HistoryHandler) ShowRestore(c *fiber.Ctx) error {
+	zoneID := c.Query("zoneid")
+	domain := c.Query("domain")
+	id := c.Query("id")
+
+	if zoneID == "" || domain == "" || id == "" {
+		return c.Redirect("/zones")
+	}
+
+	data := fiber.Map{
+		"ZoneID": zoneID,
+		"Domain": domain,
+	}
+
+	cfService, errMsg := h.openZone(c, zoneID)
+	if errMsg != "" {
+		data["Error"] = errMsg
+		return c.Render("zone/restore", data)
+	}
+
+	snap, err := h.History.Get(zoneID, id)
+	if err != nil {
+		data["Error"] = historyReadError(c, err)
+		return c.Render("zone/restore", data)
+	}
+	data["Snapshot"] = snap
+
+	current, err := service.ListAllDNSRecords(c.UserContext(), cfService, zoneID)
+	if err != nil {
+		data["Error"] = i18n.TData(localizer(c), "history_fetch_failed", map[string]interface{}{"Error": err.Error()})
+		return c.Render("zone/restore", data)
+	}
+
+	data["Plan"] = service.DiffRecords(current, snap.Records, true)
+	return c.Render("zone/restore", data)
+}
+
+// Restore 将 Zone 恢复到指定快照：只执行必要的创建、更新、删除
+func (h *HistoryHandler) Restore(c *fiber.Ctx) error {
+	zoneID := c.FormValue("zoneid")
+	domain := c.FormValue("domain")
+	id := c.FormValue("id")
+
+	if zoneID == "" || domain == "" || id == "" {
+		return c.Redirect("/zones")
+	}
+
+	data := fiber.Map{
+		"ZoneID": zoneID,
+		"Domain": domain,
+	}
+
+	cfService, errMsg := h.openZone(c, zoneID)
+	if errMsg != "" {
+		data["Error"] = errMsg
+		return c.Render("zone/restore", data)
+	}
+
+	snap, err := h.History.Get(zoneID, id)
+	if err != nil {
+		data["Error"] = historyReadError(c, err)
+		return c.Render("zone/restore", data)
+	}
+	data["Snapshot"] = snap
+
+	// 恢复前先保存当前状态，便于撤销本次恢复
+	snapshotZone(c, h.History, cfService, zoneID, domain, "RestoreSnapshot "+id)
+
+	// 重新对比，确保基于最新的线上记录
+	current, err := service.ListAllDNSRecords(c.UserContext(), cfService, zoneID)
+	if err != nil {
+		data["Error"] = i18n.TData(localizer(c), "history_fetch_failed", map[string]interface{}{"Error": err.Error()})
+		return c.Render("zone/restore", data)
+	}
+
+	plan := service.DiffRecords(current, snap.Records, true)
+	if err := checkBudget(c, len(plan.Changes())); err != nil {
+		data["Error"] = err.Error()
+		return c.Render("zone/restore", data)
+	}
+	results := service.ApplyPlan(c.UserContext(), cfService, zoneID, plan)
+	h.Records.Invalidate(zoneID)
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+		target := ""
+		if result.Change.Current != nil {
+			target = result.Change.Current.ID
+		}
+		recordAudit(c, h.Audit, audit.Entry{
+			ZoneID: zoneID,
+			Zone:   domain,
+			Action: "RestoreSnapshot",
+			Target: target,
+			Before: audit.Snapshot(result.Change.Current),
+			After:  audit.Snapshot(result.Change.Desired),
+		}, result.Err)
+	}
+
+	data["Results"] = results
+	data["Failed"] = failed
+	return c.Render("zone/restore", data)
+}
+
+// openZone 创建 Cloudflare 服务并确认当前账户可以访问该 Zone，失败时返回本地化的错误信息
+// 快照保存在本地，读取时不经过 Cloudflare 的权限检查，必须先确认，避免按 Zone ID 读取其他租户的记录
+func (h *HistoryHandler) openZone(c *fiber.Ctx, zoneID string) (service.Client, string) {
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		return nil, i18n.T(localizer(c), "history_service_failed")
+	}
+	if _, err := cfService.GetZone(c.UserContext(), zoneID); err != nil {
+		return nil, i18n.TData(localizer(c), "history_zone_denied", map[string]interface{}{"Error": err.Error()})
+	}
+	return cfService, ""
+}
+
+// historyReadError 读取快照失败的提示
+func historyReadError(c *fiber.Ctx, err error) string {
+	return i18n.TData(localizer(c), "history_read_failed", map[string]interface{}{"Error": err.Error()})
+}
+
+// snapshotRecords 读取快照中的记录，id 为 current 时读取线上记录
+func (h *HistoryHandler) snapshotRecords(ctx context.Context, cfService service.Client, zoneID, id string) ([]cloudflare.DNSRecord, error) {
+	if id == currentSnapshotID {
+		return service.ListAllDNSRecords(ctx, cfService, zoneID)
+	}
+	snap, err := h.History.Get(zoneID, id)
+	if err != nil {
+		return nil, err
+	}
+	return snap.Records, nil
+}
+
+// snapshotZone 在修改 DNS 记录前保存 Zone 的完整记录集
+// 快照失败只输出到标准日志，不阻止后续操作
+func snapshotZone(c *fiber.Ctx, store *history.Store, cfService service.Client, zoneID, domain, reason string) {
+	if store == nil {
+		return
+	}
+
+	records, err := service.ListAllDNSRecords(c.UserContext(), cfService, zoneID)
+	if err != nil {
+		log.Printf("[History Error] Failed to fetch records for %s: %v", zoneID, err)
+		return
+	}
+
+	_, err = store.Save(&history.Snapshot{
+		ZoneID:  zoneID,
+		Zone:    domain,
+		Actor:   auditActor(c),
+		Reason:  reason,
+		Records: records,
+	})
+	if err != nil {
+		log.Printf("[History Error] Failed to save snapshot for %s: %v", zoneID, err)
+	}
+}
@@ -15,10 +15,10 @@ func NewHomeHandler() *HomeHandler {
 func (h *HomeHandler) ShowHome(c *fiber.Ctx) error {
 	// 检查用户是否已登录
 	sess, _ := middleware.Store.Get(c)
-	email := sess.Get("cloudflare_email")
+	ref := sess.Get("credential_ref")
 
 	// 如果已登录，直接跳转到域名列表
-	if email != nil {
+	if ref != nil {
 		return c.Redirect("/zones")
 	}
 
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+)
+
+type PropagationHandler struct {
+	Clients   service.ClientFactory
+	Resolvers []string      // 公共解析器（来自配置 dns.resolvers）
+	Timeout   time.Duration // 单次查询超时
+}
+
+func NewPropagationHandler(clients service.ClientFactory, resolvers []string, timeout time.Duration) *PropagationHandler {
+	return &PropagationHandler{
+		Clients:   clients,
+		Resolvers: resolvers,
+		Timeout:   timeout,
+	}
+}
+
+// ShowPropagation 检查一条记录在 Zone 权威服务器和公共解析器上的传播情况
+func (h *PropagationHandler) ShowPropagation(c *fiber.Ctx) error {
+	zoneID := c.Query("zoneid")
+	domain := c.Query("domain")
+	recordID := c.Query("recordid")
+
+	if zoneID == "" || domain == "" || recordID == "" {
+		return c.Status(400).SendString("Missing zoneid, domain or recordid parameter")
+	}
+
+	data := fiber.Map{
+		"ZoneID":   zoneID,
+		"Domain":   domain,
+		"RecordID": recordID,
+	}
+
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		data["Error"] = "Failed to initialize Cloudflare service"
+		return c.Render("dns/propagation", data)
+	}
+
+	ctx := c.UserContext()
+	rc := cloudflare.ZoneIdentifier(zoneID)
+	record, err := cfService.GetDNSRecord(ctx, rc, recordID)
+	if err != nil {
+		data["Error"] = "Failed to fetch record: " + err.Error()
+		return c.Render("dns/propagation", data)
+	}
+	data["Record"] = record
+
+	if _, ok := service.PropagationTypes[record.Type]; !ok {
+		data["Error"] = "暂不支持检查 " + record.Type + " 记录的传播情况"
+		return c.Render("dns/propagation", data)
+	}
+
+	// 同名同类型的全部记录组成一个 RRset，解析器会一起返回
+	rrset, _, err := cfService.ListDNSRecords(ctx, rc, cloudflare.ListDNSRecordsParams{
+		Type: record.Type,
+		Name: record.Name,
+	})
+	if err != nil || len(rrset) == 0 {
+		rrset = []cloudflare.DNSRecord{record}
+	}
+
+	// Zone 分配的权威 NS
+	var nameServers []string
+	if zone, err := cfService.GetZone(ctx, zoneID); err == nil {
+		nameServers = zone.NameServers
+	}
+
+	servers := service.PropagationServers(nameServers, h.Resolvers)
+	checkCtx, cancel := context.WithTimeout(ctx, 2*h.Timeout)
+	defer cancel()
+	report, err := service.CheckPropagation(checkCtx, rrset, domain, servers, h.Timeout)
+	if err != nil {
+		data["Error"] = err.Error()
+		return c.Render("dns/propagation", data)
+	}
+
+	data["Report"] = report
+	return c.Render("dns/propagation", data)
+}
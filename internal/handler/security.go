@@ -3,16 +3,54 @@ package handler
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/cloudflare/cloudflare-go"
 	"github.com/gofiber/fiber/v2"
-	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/middleware"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/history"
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
 )
 
-type SecurityHandler struct{}
+// securityErrors 重定向错误码对应的提示
+var securityErrors = map[string]string{
+	"missing_params":       "缺少必要参数",
+	"service_init_failed":  "创建 Cloudflare 服务失败",
+	"dnssec_update_failed": "DNSSEC 设置更新失败",
+	"dcv_fetch_failed":     "获取 SSL 验证信息失败",
+	"dcv_nothing_to_add":   "没有需要添加的验证记录",
+	"dcv_create_failed":    "部分验证记录创建失败",
+}
+
+type SecurityHandler struct {
+	Clients service.ClientFactory
+	Audit   audit.Logger
+	History *history.Store
+	Records *service.RecordCache // 添加验证记录后清除对应 Zone 的缓存
+}
+
+func NewSecurityHandler(clients service.ClientFactory, auditLog audit.Logger, historyStore *history.Store, recordCache *service.RecordCache) *SecurityHandler {
+	return &SecurityHandler{
+		Clients: clients,
+		Audit:   auditLog,
+		History: historyStore,
+		Records: recordCache,
+	}
+}
+
+// sslVerificationView 证书包验证信息及其 DNS 验证记录是否已存在
+type sslVerificationView struct {
+	service.SSLVerification
+	DNS []dcvRecordView
+}
 
-func NewSecurityHandler() *SecurityHandler {
-	return &SecurityHandler{}
+// dcvRecordView DNS 验证记录及其在 Zone 中的状态
+type dcvRecordView struct {
+	service.DCVRecord
+	Exists bool
 }
 
 // ShowSecurity 显示安全设置页面
@@ -24,11 +62,8 @@ func (h *SecurityHandler) ShowSecurity(c *fiber.Ctx) error {
 		return c.Status(400).SendString("Missing zoneid or domain parameter")
 	}
 
-	// 获取凭证
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
-
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	// 获取凭证并创建服务
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Render("security/index", fiber.Map{
 			"Error":  "Failed to initialize Cloudflare service",
@@ -37,18 +72,40 @@ func (h *SecurityHandler) ShowSecurity(c *fiber.Ctx) error {
 		})
 	}
 
+	data := fiber.Map{
+		"ZoneID":  zoneID,
+		"Domain":  domain,
+		"Success": c.Query("success"),
+		"Count":   c.Query("count"),
+	}
+	if code := c.Query("error"); code != "" {
+		msg, ok := securityErrors[code]
+		if !ok {
+			msg = code
+		}
+		if detail := c.Query("detail"); detail != "" {
+			msg += ": " + detail
+		}
+		data["Error"] = msg
+	}
+
 	// 获取 SSL 验证信息
-	sslRecords, _ := cfService.GetSSLVerification(context.Background(), zoneID)
+	verifications, err := cfService.GetSSLVerification(c.UserContext(), zoneID)
+	if err != nil {
+		data["SSLError"] = err.Error()
+	} else {
+		data["SSLVerifications"] = h.sslVerificationViews(c.UserContext(), cfService, zoneID, verifications)
+	}
 
 	// 获取 DNSSEC 状态
-	dnssec, _ := cfService.GetDNSSEC(context.Background(), zoneID)
-
-	return c.Render("security/index", fiber.Map{
-		"ZoneID":     zoneID,
-		"Domain":     domain,
-		"SSLRecords": sslRecords,
-		"DNSSEC":     dnssec,
-	})
+	dnssec, err := cfService.GetDNSSEC(c.UserContext(), zoneID)
+	if err != nil {
+		data["DNSSECError"] = err.Error()
+	} else {
+		data["DNSSEC"] = dnssec
+	}
+
+	return c.Render("security/index", data)
 }
 
 // ToggleDNSSEC 切换 DNSSEC 状态
@@ -57,30 +114,169 @@ func (h *SecurityHandler) ToggleDNSSEC(c *fiber.Ctx) error {
 	domain := c.FormValue("domain")
 	action := c.FormValue("action") // "enable" or "disable"
 
+	back := fmt.Sprintf("/security?zoneid=%s&domain=%s", url.QueryEscape(zoneID), url.QueryEscape(domain))
 	if zoneID == "" || domain == "" {
-		return c.Redirect(fmt.Sprintf("/security?zoneid=%s&domain=%s&error=missing_params", zoneID, domain))
+		return c.Redirect(back + "&error=missing_params")
 	}
 
-	// 获取凭证
-	sess, _ := middleware.Store.Get(c)
-	email := sess.Get("cloudflare_email").(string)
-	apiKey := sess.Get("user_api_key").(string)
-
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	// 获取凭证并创建服务
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
-		return c.Redirect(fmt.Sprintf("/security?zoneid=%s&domain=%s&error=service_init_failed", zoneID, domain))
+		return c.Redirect(back + "&error=service_init_failed")
 	}
 
 	// 更新 DNSSEC 状态
-	status := "disabled"
+	status := service.DNSSECDisabled
 	if action == "enable" {
-		status = "active"
+		status = service.DNSSECActive
+	}
+
+	// 保存变更前的状态（不记录公钥等大字段）
+	var before interface{}
+	if current, err := cfService.GetDNSSEC(c.UserContext(), zoneID); err == nil {
+		before = map[string]interface{}{"status": current.Status, "key_tag": current.KeyTag}
 	}
 
-	_, err = cfService.UpdateDNSSEC(context.Background(), zoneID, status)
+	result, err := cfService.UpdateDNSSEC(c.UserContext(), zoneID, status)
+	entry := audit.Entry{
+		ZoneID: zoneID,
+		Zone:   domain,
+		Action: "UpdateDNSSEC",
+		Target: status,
+		Before: audit.Snapshot(before),
+		After:  audit.Snapshot(map[string]interface{}{"status": status}),
+	}
+	if err == nil {
+		entry.After = audit.Snapshot(map[string]interface{}{"status": result.Status, "key_tag": result.KeyTag})
+	}
+	recordAudit(c, h.Audit, entry, err)
 	if err != nil {
-		return c.Redirect(fmt.Sprintf("/security?zoneid=%s&domain=%s&error=dnssec_update_failed", zoneID, domain))
+		return c.Redirect(back + "&error=dnssec_update_failed&detail=" + url.QueryEscape(err.Error()))
 	}
 
-	return c.Redirect(fmt.Sprintf("/security?zoneid=%s&domain=%s&success=dnssec_updated", zoneID, domain))
+	return c.Redirect(back + "&success=dnssec_updated")
+}
+
+// sslVerificationViews 标记待验证证书包中已存在于 Zone 的验证记录
+func (h *SecurityHandler) sslVerificationViews(ctx context.Context, cfService service.Client, zoneID string, verifications []service.SSLVerification) []sslVerificationView {
+	views := make([]sslVerificationView, 0, len(verifications))
+	var existing map[string]bool
+	for _, v := range verifications {
+		view := sslVerificationView{SSLVerification: v}
+		if v.Pending() {
+			if existing == nil {
+				existing = existingRecordKeys(ctx, cfService, zoneID)
+			}
+			for _, rec := range v.DNSRecords() {
+				view.DNS = append(view.DNS, dcvRecordView{DCVRecord: rec, Exists: existing[dcvKey(rec)]})
+			}
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// CreateDCVRecords 在当前 Zone 中创建待验证证书包的 TXT/CNAME 验证记录
+func (h *SecurityHandler) CreateDCVRecords(c *fiber.Ctx) error {
+	zoneID := c.FormValue("zoneid")
+	domain := c.FormValue("domain")
+	certPackID := c.FormValue("cert_pack") // 为空时处理全部待验证证书包
+
+	back := fmt.Sprintf("/security?zoneid=%s&domain=%s", url.QueryEscape(zoneID), url.QueryEscape(domain))
+	if zoneID == "" || domain == "" {
+		return c.Redirect(back + "&error=missing_params")
+	}
+
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		return c.Redirect(back + "&error=service_init_failed")
+	}
+
+	// 重新获取验证信息，不信任表单提交的记录内容
+	verifications, err := cfService.GetSSLVerification(c.UserContext(), zoneID)
+	if err != nil {
+		return c.Redirect(back + "&error=dcv_fetch_failed&detail=" + url.QueryEscape(err.Error()))
+	}
+
+	existing := existingRecordKeys(c.UserContext(), cfService, zoneID)
+	var pending []service.DCVRecord
+	for _, v := range verifications {
+		if !v.Pending() || (certPackID != "" && v.CertPackID != certPackID) {
+			continue
+		}
+		for _, rec := range v.DNSRecords() {
+			if existing[dcvKey(rec)] {
+				continue
+			}
+			existing[dcvKey(rec)] = true
+			pending = append(pending, rec)
+		}
+	}
+	if len(pending) == 0 {
+		return c.Redirect(back + "&error=dcv_nothing_to_add")
+	}
+
+	snapshotZone(c, h.History, cfService, zoneID, domain, "CreateDCVRecords")
+
+	rc := cloudflare.ZoneIdentifier(zoneID)
+	var failures []string
+	for _, rec := range pending {
+		params := cloudflare.CreateDNSRecordParams{
+			Type:    rec.Type,
+			Name:    rec.Name,
+			Content: rec.Content,
+			TTL:     1,
+			Comment: "SSL DCV",
+		}
+		if rec.Type == "CNAME" {
+			proxied := false
+			params.Proxied = &proxied
+		}
+		created, err := cfService.CreateDNSRecord(c.UserContext(), rc, params)
+		after := audit.Snapshot(params)
+		if err == nil {
+			after = audit.Snapshot(created)
+		}
+		recordAudit(c, h.Audit, audit.Entry{
+			ZoneID: zoneID,
+			Zone:   domain,
+			Action: "AddRecord",
+			Target: rec.Type + " " + rec.Name,
+			After:  after,
+		}, err)
+		if err != nil {
+			failures = append(failures, rec.Name+": "+err.Error())
+		}
+	}
+	h.Records.Invalidate(zoneID)
+
+	if len(failures) > 0 {
+		return c.Redirect(back + "&error=dcv_create_failed&detail=" + url.QueryEscape(strings.Join(failures, "; ")))
+	}
+	return c.Redirect(back + "&success=dcv_created&count=" + strconv.Itoa(len(pending)))
+}
+
+// existingRecordKeys 返回 Zone 中已有 TXT/CNAME 记录的 key，获取失败时返回空集合
+func existingRecordKeys(ctx context.Context, cfService service.Client, zoneID string) map[string]bool {
+	keys := make(map[string]bool)
+	records, err := service.ListAllDNSRecords(ctx, cfService, zoneID)
+	if err != nil {
+		return keys
+	}
+	for _, r := range records {
+		if r.Type == "TXT" || r.Type == "CNAME" {
+			keys[dcvKey(service.DCVRecord{Type: r.Type, Name: r.Name, Content: r.Content})] = true
+		}
+	}
+	return keys
+}
+
+// dcvKey 记录比较的 key（TXT 内容去掉引号，名称忽略大小写）
+func dcvKey(rec service.DCVRecord) string {
+	name := strings.ToLower(strings.TrimSuffix(rec.Name, "."))
+	content := strings.Trim(strings.TrimSuffix(rec.Content, "."), `"`)
+	if rec.Type == "CNAME" {
+		content = strings.ToLower(content)
+	}
+	return rec.Type + " " + name + " " + content
 }
@@ -1,18 +1,25 @@
 package handler
 
 import (
-	"context"
 	"fmt"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
 )
 
-type SettingsHandler struct{}
+type SettingsHandler struct {
+	Clients service.ClientFactory
+	Audit   audit.Logger
+}
 
-func NewSettingsHandler() *SettingsHandler {
-	return &SettingsHandler{}
+func NewSettingsHandler(clients service.ClientFactory, auditLog audit.Logger) *SettingsHandler {
+	return &SettingsHandler{
+		Clients: clients,
+		Audit:   auditLog,
+	}
 }
 
 // ShowSettings 显示 Zone 设置页面
@@ -24,17 +31,14 @@ func (h *SettingsHandler) ShowSettings(c *fiber.Ctx) error {
 		return c.Status(400).SendString("Missing zoneid or domain parameter")
 	}
 
-	// 获取凭证
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
-
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	// 获取凭证并创建服务
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).SendString("Failed to initialize Cloudflare service")
 	}
 
 	// 获取所有 Zone 设置
-	settings, err := cfService.GetZoneSettings(context.Background(), zoneID)
+	settings, err := cfService.GetZoneSettings(c.UserContext(), zoneID)
 	if err != nil {
 		return c.Render("settings/index", fiber.Map{
 			"Error":  "Failed to fetch zone settings: " + err.Error(),
@@ -65,10 +69,7 @@ func (h *SettingsHandler) ToggleDevelopmentMode(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Missing zoneid"})
 	}
 
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
-
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create service"})
 	}
@@ -79,7 +80,15 @@ func (h *SettingsHandler) ToggleDevelopmentMode(c *fiber.Ctx) error {
 		newValue = "on"
 	}
 
-	err = cfService.UpdateZoneSetting(context.Background(), zoneID, "development_mode", newValue)
+	err = cfService.UpdateZoneSetting(c.UserContext(), zoneID, "development_mode", newValue)
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zoneID,
+		Zone:   c.Query("domain"),
+		Action: "UpdateSetting",
+		Target: "development_mode",
+		Before: audit.Snapshot(map[string]interface{}{"development_mode": currentValue}),
+		After:  audit.Snapshot(map[string]interface{}{"development_mode": newValue}),
+	}, err)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -101,10 +110,7 @@ func (h *SettingsHandler) UpdateSetting(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Missing parameters"})
 	}
 
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
-
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create service"})
 	}
@@ -134,7 +140,18 @@ func (h *SettingsHandler) UpdateSetting(c *fiber.Ctx) error {
 		settingValue = minifyValue
 	}
 
-	err = cfService.UpdateZoneSetting(context.Background(), zoneID, settingID, settingValue)
+	// 保存变更前的值
+	before, _ := cfService.ZoneSettingValues(c.UserContext(), zoneID, []string{settingID})
+
+	err = cfService.UpdateZoneSetting(c.UserContext(), zoneID, settingID, settingValue)
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zoneID,
+		Zone:   c.Query("domain"),
+		Action: "UpdateSetting",
+		Target: settingID,
+		Before: audit.Snapshot(before),
+		After:  audit.Snapshot(map[string]interface{}{settingID: settingValue}),
+	}, err)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -148,24 +165,28 @@ func (h *SettingsHandler) UpdateSetting(c *fiber.Ctx) error {
 // PurgeCache 清除缓存
 func (h *SettingsHandler) PurgeCache(c *fiber.Ctx) error {
 	zoneID := c.Query("zoneid")
-	purgeType := c.FormValue("type") // "all", "urls", "hosts", "prefixes", "tags"
+	purgeType := c.FormValue("type")  // "all", "urls", "hosts", "prefixes", "tags"
 	content := c.FormValue("content") // 统一的内容字段
 
 	if zoneID == "" {
 		return c.Status(400).JSON(fiber.Map{"error": "Missing zoneid"})
 	}
 
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
-
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create service"})
 	}
 
 	// 清除所有缓存
 	if purgeType == "all" {
-		err = cfService.PurgeAllCache(context.Background(), zoneID)
+		err = cfService.PurgeAllCache(c.UserContext(), zoneID)
+		recordAudit(c, h.Audit, audit.Entry{
+			ZoneID: zoneID,
+			Zone:   c.Query("domain"),
+			Action: "PurgeCache",
+			Target: purgeType,
+			After:  audit.Snapshot(map[string]interface{}{"type": purgeType}),
+		}, err)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -198,21 +219,29 @@ func (h *SettingsHandler) PurgeCache(c *fiber.Ctx) error {
 
 	switch purgeType {
 	case "urls":
-		err = cfService.PurgeCacheByURLs(context.Background(), zoneID, cleanedItems)
+		err = cfService.PurgeCacheByURLs(c.UserContext(), zoneID, cleanedItems)
 		message = fmt.Sprintf("已清除 %d 个 URL 的缓存", len(cleanedItems))
 	case "hosts":
-		err = cfService.PurgeCacheByHosts(context.Background(), zoneID, cleanedItems)
+		err = cfService.PurgeCacheByHosts(c.UserContext(), zoneID, cleanedItems)
 		message = fmt.Sprintf("已清除 %d 个主机名的缓存", len(cleanedItems))
 	case "prefixes":
-		err = cfService.PurgeCacheByPrefixes(context.Background(), zoneID, cleanedItems)
+		err = cfService.PurgeCacheByPrefixes(c.UserContext(), zoneID, cleanedItems)
 		message = fmt.Sprintf("已清除 %d 个前缀的缓存", len(cleanedItems))
 	case "tags":
-		err = cfService.PurgeCacheByTags(context.Background(), zoneID, cleanedItems)
+		err = cfService.PurgeCacheByTags(c.UserContext(), zoneID, cleanedItems)
 		message = fmt.Sprintf("已清除 %d 个 Tag 的缓存", len(cleanedItems))
 	default:
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid purge type"})
 	}
 
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zoneID,
+		Zone:   c.Query("domain"),
+		Action: "PurgeCache",
+		Target: purgeType,
+		After:  audit.Snapshot(map[string]interface{}{"type": purgeType, "items": cleanedItems}),
+	}, err)
+
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -232,23 +261,37 @@ func (h *SettingsHandler) ApplyPreset(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Missing parameters"})
 	}
 
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
-
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create service"})
 	}
 
+	// 获取预设信息
+	preset, _ := service.GetPresetInfo(presetName)
+
+	// 保存预设涉及设置项的当前值
+	ids := make([]string, 0, len(preset.Settings))
+	after := make(map[string]interface{}, len(preset.Settings))
+	for _, setting := range preset.Settings {
+		ids = append(ids, setting.ID)
+		after[setting.ID] = setting.Value
+	}
+	before, _ := cfService.ZoneSettingValues(c.UserContext(), zoneID, ids)
+
 	// 应用预设
-	err = cfService.ApplyPreset(context.Background(), zoneID, presetName)
+	err = service.ApplyPreset(c.UserContext(), cfService, zoneID, presetName)
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zoneID,
+		Zone:   c.Query("domain"),
+		Action: "ApplyPreset",
+		Target: presetName,
+		Before: audit.Snapshot(before),
+		After:  audit.Snapshot(after),
+	}, err)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// 获取预设信息
-	preset, _ := service.GetPresetInfo(presetName)
-
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": fmt.Sprintf("已应用「%s」配置模板", preset.Name),
@@ -2,33 +2,58 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudflare/cloudflare-go"
 	"github.com/gofiber/fiber/v2"
 	"github.com/miekg/dns"
 
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/middleware"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/model"
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
 )
 
-type ZoneHandler struct{}
+type ZoneHandler struct {
+	Clients  service.ClientFactory
+	Audit    audit.Logger
+	Resolver *service.DNSResolver // 委派检查使用的递归解析器
+	Zones    *service.ZoneCache   // 按账户缓存的域名列表
+	Records  *service.RecordCache // 按 Zone 缓存的 DNS 记录
 
-func NewZoneHandler() *ZoneHandler {
-	return &ZoneHandler{}
+	searches latestRequests // 同一会话新的搜索会取消未完成的旧搜索
+}
+
+func NewZoneHandler(clients service.ClientFactory, auditLog audit.Logger, resolver *service.DNSResolver, zoneCache *service.ZoneCache, recordCache *service.RecordCache) *ZoneHandler {
+	return &ZoneHandler{
+		Clients:  clients,
+		Audit:    auditLog,
+		Resolver: resolver,
+		Zones:    zoneCache,
+		Records:  recordCache,
+	}
+}
+
+// accountZone 带所属账户信息的域名（"全部账户"视图）
+type accountZone struct {
+	cloudflare.Zone
+	Account model.Account
 }
 
 // ListZones 域名列表页面
+// 支持按名称搜索、按状态和套餐筛选、排序，分页由 Cloudflare 接口完成
+// account=all 时合并展示会话中全部账户的域名
 func (h *ZoneHandler) ListZones(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
-
-	// 创建 Cloudflare 服务
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	sess, err := middleware.Store.Get(c)
 	if err != nil {
-		return c.Status(500).SendString("Failed to create Cloudflare service")
+		return c.Redirect("/login")
 	}
+	accounts := middleware.SessionAccounts(sess)
 
 	// 获取页码
 	page := 1
@@ -36,21 +61,158 @@ func (h *ZoneHandler) ListZones(c *fiber.Ctx) error {
 		page, _ = strconv.Atoi(p)
 	}
 
+	query := service.ZoneQuery{
+		Page:      page,
+		Name:      c.Query("name"),
+		Status:    c.Query("status"),
+		Plan:      c.Query("plan"),
+		Order:     c.Query("order"),
+		Direction: c.Query("direction"),
+	}.Normalize()
+
+	// 翻页链接需要保留的参数
+	linkParams := query.Values()
+	if c.Query("account") == "all" {
+		linkParams.Set("account", "all")
+	}
+	pageLink := "/zones?"
+	if len(linkParams) > 0 {
+		pageLink += linkParams.Encode() + "&"
+	}
+
+	data := fiber.Map{
+		"PageTitle":   "域名列表",
+		"ShowNav":     true,
+		"AppTitle":    "Cloudflare DNS Manager",
+		"CurrentPage": "域名列表",
+		"Accounts":    accounts.Accounts,
+		"ActiveRef":   accounts.ActiveRef,
+		"Page":        query.Page,
+		"Query":       query,
+		"PageLink":    pageLink,
+		"Filtered":    len(query.Values()) > 0,
+		"Statuses":    service.ZoneStatuses,
+		"Plans":       service.ZonePlans,
+	}
+
+	if c.Query("account") == "all" {
+		zones, accountErrors := h.listAccountZones(c.UserContext(), accounts.Accounts, query)
+		start, end, resultInfo := service.Paginate(len(zones), query.Page, service.ZonesPerPage)
+
+		data["AllAccounts"] = true
+		data["Zones"] = zones[start:end]
+		data["ResultInfo"] = resultInfo
+		data["AccountErrors"] = accountErrors
+		return c.Render("zone/list", data)
+	}
+
+	// 创建 Cloudflare 服务
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		return c.Status(500).SendString("Failed to create Cloudflare service")
+	}
+
 	// 获取域名列表
-	zones, resultInfo, err := cfService.ListZones(context.Background(), page)
+	zones, resultInfo, err := h.Zones.List(c.UserContext(), credentialRef(c), cfService, query)
 	if err != nil {
 		return c.Status(500).SendString("Failed to fetch zones: " + err.Error())
 	}
 
-	return c.Render("zone/list", fiber.Map{
-		"PageTitle":  "域名列表",
-		"ShowNav":    true,
-		"AppTitle":   "Cloudflare DNS Manager",
-		"CurrentPage": "域名列表",
-		"Zones":      zones,
-		"ResultInfo": resultInfo,
-		"Page":       page,
+	data["Zones"] = zones
+	data["ResultInfo"] = resultInfo
+	return c.Render("zone/list", data)
+}
+
+// listAccountZones 并发获取多个账户的域名，按条件筛选后排序合并
+// 单个账户获取失败不影响其他账户，错误信息单独返回
+func (h *ZoneHandler) listAccountZones(ctx context.Context, accounts []model.Account, query service.ZoneQuery) ([]accountZone, []string) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		zones    []accountZone
+		failures []string
+	)
+
+	for _, account := range accounts {
+		wg.Add(1)
+		go func(account model.Account) {
+			defer wg.Done()
+
+			var accountZones []cloudflare.Zone
+			cfService, err := newCloudflareServiceForAccount(h.Clients, account.Ref)
+			if err == nil {
+				accountZones, err = h.Zones.All(ctx, account.Ref, cfService)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", account.Label, err))
+				return
+			}
+			for _, zone := range accountZones {
+				if query.Match(zone) {
+					zones = append(zones, accountZone{Zone: zone, Account: account})
+				}
+			}
+		}(account)
+	}
+	wg.Wait()
+
+	// 先按账户排序再稳定排序，同名域名按账户名排列
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].Account.Label < zones[j].Account.Label
 	})
+	sort.SliceStable(zones, func(i, j int) bool {
+		return query.Less(zones[i].Zone, zones[j].Zone)
+	})
+	sort.Strings(failures)
+
+	return zones, failures
+}
+
+// GetDelegation 检查 Full Setup 域名在注册商（上级域名）处的委派情况
+// ref 非空时使用会话中指定账户的凭证（"全部账户"视图）
+func (h *ZoneHandler) GetDelegation(c *fiber.Ctx) error {
+	zoneID := c.Query("zoneid")
+	ref := c.Query("ref")
+	if zoneID == "" {
+		return c.Status(400).SendString("Missing zoneid parameter")
+	}
+
+	var (
+		cfService service.Client
+		err       error
+	)
+	if ref != "" {
+		sess, sessErr := middleware.Store.Get(c)
+		if sessErr != nil || !containsRef(middleware.AccountRefs(sess), ref) {
+			return c.Status(403).SendString("Account not found in session")
+		}
+		cfService, err = newCloudflareServiceForAccount(h.Clients, ref)
+	} else {
+		cfService, err = newCloudflareService(c, h.Clients)
+	}
+	if err != nil {
+		return c.Render("zone/partials/delegation", fiber.Map{"Error": "Failed to initialize Cloudflare service"}, "")
+	}
+
+	ctx := c.UserContext()
+	zone, err := cfService.GetZone(ctx, zoneID)
+	if err != nil {
+		return c.Render("zone/partials/delegation", fiber.Map{"Error": err.Error()}, "")
+	}
+
+	// DNSSEC 状态获取失败时跳过 DS 比较
+	dnssec, _ := cfService.GetDNSSEC(ctx, zoneID)
+
+	checkCtx, cancel := context.WithTimeout(ctx, 4*h.Resolver.Timeout)
+	defer cancel()
+	report, err := service.CheckDelegation(checkCtx, h.Resolver, zone.Name, zone.NameServers, dnssec)
+	if err != nil {
+		return c.Render("zone/partials/delegation", fiber.Map{"Error": err.Error()}, "")
+	}
+	return c.Render("zone/partials/delegation", fiber.Map{"Report": report}, "")
 }
 
 // ShowAddZone 显示添加域名页面
@@ -65,8 +227,6 @@ func (h *ZoneHandler) ShowAddZone(c *fiber.Ctx) error {
 
 // AddZone 添加域名
 func (h *ZoneHandler) AddZone(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneName := c.FormValue("zone_name")
 
 	if zoneName == "" {
@@ -80,7 +240,7 @@ func (h *ZoneHandler) AddZone(c *fiber.Ctx) error {
 	}
 
 	// 创建 Cloudflare 服务
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Render("zone/add", fiber.Map{
 			"PageTitle":   "添加域名",
@@ -92,7 +252,16 @@ func (h *ZoneHandler) AddZone(c *fiber.Ctx) error {
 	}
 
 	// 添加域名
-	zone, err := cfService.CreateZone(context.Background(), zoneName)
+	zone, err := cfService.CreateZone(c.UserContext(), zoneName)
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zone.ID,
+		Zone:   zoneName,
+		Action: "AddZone",
+		After:  audit.Snapshot(zone),
+	}, err)
+	if err == nil {
+		h.Zones.Invalidate(credentialRef(c))
+	}
 	if err != nil {
 		return c.Render("zone/add", fiber.Map{
 			"PageTitle":   "添加域名",
@@ -115,8 +284,6 @@ func (h *ZoneHandler) AddZone(c *fiber.Ctx) error {
 
 // ShowZone 显示域名详情（DNS 记录管理）
 func (h *ZoneHandler) ShowZone(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneID := c.Query("zoneid")
 	domain := c.Query("domain")
 
@@ -125,7 +292,7 @@ func (h *ZoneHandler) ShowZone(c *fiber.Ctx) error {
 	}
 
 	// 创建 Cloudflare 服务
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).SendString("Failed to create Cloudflare service")
 	}
@@ -136,20 +303,16 @@ func (h *ZoneHandler) ShowZone(c *fiber.Ctx) error {
 		page, _ = strconv.Atoi(p)
 	}
 
-	// 获取 DNS 记录
-	rc := cloudflare.ZoneIdentifier(zoneID)
-	records, resultInfo, err := cfService.ListDNSRecords(context.Background(), rc, cloudflare.ListDNSRecordsParams{
-		ResultInfo: cloudflare.ResultInfo{
-			Page:    page,
-			PerPage: 20,
-		},
-	})
+	// 获取 DNS 记录（refresh=1 时忽略缓存），分页在缓存的完整列表上完成
+	cached, err := h.Records.Get(c.UserContext(), credentialRef(c), cfService, zoneID, c.Query("refresh") == "1")
 	if err != nil {
 		return c.Status(500).SendString("Failed to fetch DNS records: " + err.Error())
 	}
+	start, end, resultInfo := service.Paginate(len(cached.Records), page, service.RecordsPerPage)
+	records := cached.Records[start:end]
 
 	// 获取 Zone 信息（包含 NS 记录和类型）
-	zone, err := cfService.GetZone(context.Background(), zoneID)
+	zone, err := cfService.GetZone(c.UserContext(), zoneID)
 	var nsRecords []string
 	var zoneType string
 	if err == nil {
@@ -180,6 +343,8 @@ func (h *ZoneHandler) ShowZone(c *fiber.Ctx) error {
 		"NSRecords":   nsRecords,
 		"AnycastIPs":  anycastIPs,
 		"ZoneType":    zoneType, // 传递 zone type 到模板
+		"CachedAt":    cached.FetchedAt,
+		"CacheStale":  cached.Stale,
 	})
 }
 
@@ -213,117 +378,57 @@ func queryAnycastIPs(hostname string) map[string]interface{} {
 	return result
 }
 
-// queryDNS 执行 DNS 查询
+// queryDNS 执行 DNS 查询，返回第一个有结果的 nameserver 的应答
 func queryDNS(target, recordType string, nameservers []string) []string {
-	var results []string
-
-	c := new(dns.Client)
-	c.Timeout = 2 * time.Second
-
-	var qtype uint16
-	if recordType == "A" {
-		qtype = dns.TypeA
-	} else if recordType == "AAAA" {
-		qtype = dns.TypeAAAA
-	} else {
-		return results
+	qtype, ok := service.PropagationTypes[recordType]
+	if !ok || (recordType != "A" && recordType != "AAAA") {
+		return nil
 	}
 
-	m := new(dns.Msg)
-	m.SetQuestion(dns.Fqdn(target), qtype)
-
 	// 尝试每个 nameserver
 	for _, ns := range nameservers {
-		r, _, err := c.Exchange(m, ns)
-		if err != nil {
+		r, _, err := service.QueryServer(context.Background(), ns, target, qtype, 2*time.Second, true)
+		if err != nil || r.Rcode != dns.RcodeSuccess {
 			continue
 		}
-
-		// 解析响应
-		if r != nil && r.Rcode == dns.RcodeSuccess {
-			for _, ans := range r.Answer {
-				if recordType == "A" {
-					if a, ok := ans.(*dns.A); ok {
-						results = append(results, a.A.String())
-					}
-				} else if recordType == "AAAA" {
-					if aaaa, ok := ans.(*dns.AAAA); ok {
-						results = append(results, aaaa.AAAA.String())
-					}
-				}
-			}
-
-			// 如果找到了结果就返回
-			if len(results) > 0 {
-				break
-			}
+		if results, _ := service.AnswerValues(r, qtype); len(results) > 0 {
+			return results
 		}
 	}
 
-	return results
+	return nil
 }
 
 // SearchDNSRecords 搜索和过滤 DNS 记录
 func (h *ZoneHandler) SearchDNSRecords(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneID := c.Query("zoneid")
-	domain := c.Query("domain")     // 添加 domain 参数
-	query := c.Query("query")       // 搜索关键词
-	recordType := c.Query("type")   // 记录类型过滤
-	proxied := c.Query("proxied")   // CDN 状态过滤
+	domain := c.Query("domain")   // 添加 domain 参数
+	query := c.Query("query")     // 搜索关键词
+	recordType := c.Query("type") // 记录类型过滤
+	proxied := c.Query("proxied") // CDN 状态过滤
 
 	if zoneID == "" {
 		return c.Status(400).SendString("Missing zoneid")
 	}
 
 	// 创建 Cloudflare 服务
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).SendString("Failed to create Cloudflare service")
 	}
 
-	// 构建查询参数
-	params := cloudflare.ListDNSRecordsParams{
-		ResultInfo: cloudflare.ResultInfo{
-			PerPage: 100, // 搜索时返回更多结果
-		},
-	}
-
-	// 添加类型过滤
-	if recordType != "" {
-		params.Type = recordType
-	}
-
-	// 添加 CDN 状态过滤
-	if proxied == "true" {
-		proxiedBool := true
-		params.Proxied = &proxiedBool
-	} else if proxied == "false" {
-		proxiedBool := false
-		params.Proxied = &proxiedBool
+	// 获取记录（逐键搜索时取消上一次未完成的请求），在缓存的完整列表上过滤
+	ctx, done := h.searches.begin(c, zoneID)
+	defer done()
+	cached, err := h.Records.Get(ctx, credentialRef(c), cfService, zoneID, false)
+	if errors.Is(ctx.Err(), context.Canceled) && c.UserContext().Err() == nil {
+		// 已被更新的搜索取代，返回 204 让 HTMX 保留现有内容
+		return c.SendStatus(fiber.StatusNoContent)
 	}
-
-	// 获取记录
-	rc := cloudflare.ZoneIdentifier(zoneID)
-	records, _, err := cfService.ListDNSRecords(context.Background(), rc, params)
 	if err != nil {
 		return c.Status(500).SendString("Failed to fetch DNS records: " + err.Error())
 	}
-
-	// 如果有搜索关键词，进行本地过滤
-	if query != "" {
-		query = strings.ToLower(query)
-		var filtered []cloudflare.DNSRecord
-		for _, record := range records {
-			// 搜索名称或内容
-			if strings.Contains(strings.ToLower(record.Name), query) ||
-				strings.Contains(strings.ToLower(record.Content), query) {
-				filtered = append(filtered, record)
-			}
-		}
-		records = filtered
-	}
+	records := service.FilterRecords(cached.Records, query, recordType, proxied)
 
 	// 渲染记录列表片段
 	return c.Render("zone/partials/records-table", fiber.Map{
@@ -335,8 +440,6 @@ func (h *ZoneHandler) SearchDNSRecords(c *fiber.Ctx) error {
 
 // GetDNSStats 获取 DNS 记录统计信息
 func (h *ZoneHandler) GetDNSStats(c *fiber.Ctx) error {
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
 	zoneID := c.Query("zoneid")
 
 	if zoneID == "" {
@@ -344,21 +447,17 @@ func (h *ZoneHandler) GetDNSStats(c *fiber.Ctx) error {
 	}
 
 	// 创建 Cloudflare 服务
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create service"})
 	}
 
 	// 获取所有记录
-	rc := cloudflare.ZoneIdentifier(zoneID)
-	records, _, err := cfService.ListDNSRecords(context.Background(), rc, cloudflare.ListDNSRecordsParams{
-		ResultInfo: cloudflare.ResultInfo{
-			PerPage: 1000,
-		},
-	})
+	cached, err := h.Records.Get(c.UserContext(), credentialRef(c), cfService, zoneID, false)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+	records := cached.Records
 
 	// 统计数据
 	stats := map[string]interface{}{
@@ -387,6 +486,90 @@ func (h *ZoneHandler) GetDNSStats(c *fiber.Ctx) error {
 	return c.Render("zone/partials/stats-card", stats, "")
 }
 
+// dnsAnalyticsDays DNS 查询统计可选的天数
+var dnsAnalyticsDays = []int{1, 7, 30}
+
+// dnsAnalyticsTopN 名称排行显示的条数
+const dnsAnalyticsTopN = 20
+
+// dnsCleanupDays 超过该天数没有查询的记录列为清理候选
+const dnsCleanupDays = 30
+
+// GetDNSAnalytics 获取 DNS 查询统计（HTMX）
+func (h *ZoneHandler) GetDNSAnalytics(c *fiber.Ctx) error {
+	zoneID := c.Query("zoneid")
+	domain := c.Query("domain")
+
+	if zoneID == "" {
+		return c.Status(400).SendString("Missing zoneid")
+	}
+
+	days := c.QueryInt("days", 7)
+	valid := false
+	for _, d := range dnsAnalyticsDays {
+		if d == days {
+			valid = true
+		}
+	}
+	if !valid {
+		days = 7
+	}
+
+	cfService, err := newCloudflareService(c, h.Clients)
+	if err != nil {
+		return c.Status(500).SendString("Failed to create service")
+	}
+
+	data := fiber.Map{
+		"ZoneID":      zoneID,
+		"Domain":      domain,
+		"Days":        days,
+		"DaysOptions": dnsAnalyticsDays,
+		"CleanupDays": dnsCleanupDays,
+	}
+
+	now := time.Now()
+	report, err := cfService.GetDNSAnalytics(c.UserContext(), zoneID, now.AddDate(0, 0, -days), now)
+	if err != nil {
+		data["Error"] = err.Error()
+		return c.Render("zone/partials/dns-analytics", data, "")
+	}
+	data["Report"] = report
+	data["TopNames"] = topQueryStats(report.ByName(), dnsAnalyticsTopN)
+	data["NXDomain"] = topQueryStats(report.NXDomain(), dnsAnalyticsTopN)
+	data["ByType"] = report.ByType()
+	data["ByResponseCode"] = report.ByResponseCode()
+
+	// 清理候选固定按 30 天统计
+	cleanup := report
+	if days != dnsCleanupDays {
+		cleanup, err = cfService.GetDNSAnalytics(c.UserContext(), zoneID, now.AddDate(0, 0, -dnsCleanupDays), now)
+		if err != nil {
+			data["CleanupError"] = err.Error()
+			return c.Render("zone/partials/dns-analytics", data, "")
+		}
+	}
+
+	// 记录列表走缓存，查看分析不额外消耗一次完整的分页列表请求
+	cached, err := h.Records.Get(c.UserContext(), credentialRef(c), cfService, zoneID, false)
+	if err != nil {
+		data["CleanupError"] = err.Error()
+	} else {
+		data["Unqueried"] = cleanup.UnqueriedRecords(cached.Records)
+		data["CleanupTruncated"] = cleanup.Truncated
+	}
+
+	return c.Render("zone/partials/dns-analytics", data, "")
+}
+
+// topQueryStats 截取前 n 条
+func topQueryStats(stats []service.DNSQueryStat, n int) []service.DNSQueryStat {
+	if len(stats) > n {
+		return stats[:n]
+	}
+	return stats
+}
+
 // DeleteZone 删除域名
 func (h *ZoneHandler) DeleteZone(c *fiber.Ctx) error {
 	zoneID := c.Query("zoneid")
@@ -400,10 +583,7 @@ func (h *ZoneHandler) DeleteZone(c *fiber.Ctx) error {
 	}
 
 	// 获取 Cloudflare Service
-	email := c.Locals("cloudflare_email").(string)
-	apiKey := c.Locals("user_api_key").(string)
-
-	cfService, err := service.NewCloudflareService(email, apiKey)
+	cfService, err := newCloudflareService(c, h.Clients)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
@@ -412,7 +592,7 @@ func (h *ZoneHandler) DeleteZone(c *fiber.Ctx) error {
 	}
 
 	// 验证域名是否匹配（双重验证）
-	ctx := context.Background()
+	ctx := c.UserContext()
 	zone, err := cfService.GetZone(ctx, zoneID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
@@ -431,12 +611,21 @@ func (h *ZoneHandler) DeleteZone(c *fiber.Ctx) error {
 
 	// 执行删除
 	err = cfService.DeleteZone(ctx, zoneID)
+	recordAudit(c, h.Audit, audit.Entry{
+		ZoneID: zoneID,
+		Zone:   domain,
+		Action: "DeleteZone",
+		Target: zoneID,
+		Before: audit.Snapshot(zone),
+	}, err)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"success": false,
 			"error":   "删除域名失败: " + err.Error(),
 		})
 	}
+	h.Zones.Invalidate(credentialRef(c))
+	h.Records.Invalidate(zoneID)
 
 	return c.JSON(fiber.Map{
 		"success": true,
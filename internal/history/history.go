@@ -0,0 +1,193 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// ErrNotFound 快照不存在
+var ErrNotFound = errors.New("snapshot not found")
+
+// idPattern Zone ID 与快照 ID 只允许安全字符，避免路径穿越
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// idLayout 快照 ID 格式（按字典序即按时间排序）
+const idLayout = "20060102T150405.000000000"
+
+// Snapshot 某一时刻 Zone 的完整 DNS 记录集
+type Snapshot struct {
+	ID          string                 `json:"id"`
+	ZoneID      string                 `json:"zone_id"`
+	Zone        string                 `json:"zone"`
+	Time        time.Time              `json:"time"`
+	Actor       string                 `json:"actor"`
+	Reason      string                 `json:"reason"` // 触发快照的操作
+	Fingerprint string                 `json:"fingerprint"`
+	Records     []cloudflare.DNSRecord `json:"records"`
+}
+
+// Store 本地快照存储，每个 Zone 一个目录，每个快照一个 JSON 文件
+type Store struct {
+	mu         sync.Mutex
+	dir        string
+	maxPerZone int
+}
+
+// NewStore 创建快照存储，maxPerZone 为每个 Zone 保留的最大快照数（0 表示不限制）
+func NewStore(dir string, maxPerZone int) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	return &Store{dir: dir, maxPerZone: maxPerZone}, nil
+}
+
+// Save 保存快照
+// 记录集与最近一次快照相同时不重复保存，返回 false
+func (s *Store) Save(snap *Snapshot) (bool, error) {
+	if !idPattern.MatchString(snap.ZoneID) {
+		return false, fmt.Errorf("invalid zone id: %q", snap.ZoneID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap.Fingerprint = fingerprint(snap.Records)
+
+	ids, err := s.ids(snap.ZoneID)
+	if err != nil {
+		return false, err
+	}
+	if len(ids) > 0 {
+		latest, err := s.read(snap.ZoneID, ids[0])
+		if err == nil && latest.Fingerprint == snap.Fingerprint {
+			return false, nil
+		}
+	}
+
+	if snap.Time.IsZero() {
+		snap.Time = time.Now()
+	}
+	snap.ID = snap.Time.UTC().Format(idLayout)
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return false, err
+	}
+
+	zoneDir := filepath.Join(s.dir, snap.ZoneID)
+	if err := os.MkdirAll(zoneDir, 0o700); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(filepath.Join(zoneDir, snap.ID+".json"), data, 0o600); err != nil {
+		return false, err
+	}
+
+	// 清理超出保留数量的旧快照
+	if s.maxPerZone > 0 && len(ids)+1 > s.maxPerZone {
+		for _, id := range ids[s.maxPerZone-1:] {
+			os.Remove(filepath.Join(zoneDir, id+".json"))
+		}
+	}
+
+	return true, nil
+}
+
+// List 按时间倒序返回 Zone 的全部快照
+func (s *Store) List(zoneID string) ([]Snapshot, error) {
+	if !idPattern.MatchString(zoneID) {
+		return nil, fmt.Errorf("invalid zone id: %q", zoneID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.ids(zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]Snapshot, 0, len(ids))
+	for _, id := range ids {
+		snap, err := s.read(zoneID, id)
+		if err != nil {
+			continue // 跳过损坏的文件
+		}
+		snapshots = append(snapshots, *snap)
+	}
+	return snapshots, nil
+}
+
+// Get 读取单个快照
+func (s *Store) Get(zoneID, id string) (*Snapshot, error) {
+	if !idPattern.MatchString(zoneID) || !idPattern.MatchString(id) {
+		return nil, ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(zoneID, id)
+}
+
+// ids 按时间倒序列出 Zone 的快照 ID
+func (s *Store) ids(zoneID string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, zoneID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".json") {
+			ids = append(ids, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// read 读取快照文件
+func (s *Store) read(zoneID, id string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, zoneID, id+".json"))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("corrupted snapshot %s: %w", id, err)
+	}
+	return &snap, nil
+}
+
+// fingerprint 计算记录集指纹（与记录顺序无关）
+func fingerprint(records []cloudflare.DNSRecord) string {
+	sorted := make([]cloudflare.DNSRecord, len(records))
+	copy(sorted, records)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	data, _ := json.Marshal(sorted)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -47,6 +47,21 @@ func T(localizer *i18n.Localizer, messageID string) string {
 	return msg
 }
 
+// TData 带模板参数的翻译函数，如 "TTL 必须在 {{.Min}} ~ {{.Max}} 之间"
+func TData(localizer *i18n.Localizer, messageID string, data map[string]interface{}) string {
+	if localizer == nil {
+		return messageID
+	}
+	msg, err := localizer.Localize(&i18n.LocalizeConfig{
+		MessageID:    messageID,
+		TemplateData: data,
+	})
+	if err != nil {
+		return messageID
+	}
+	return msg
+}
+
 // GetLocalizer 根据语言代码获取 Localizer
 func GetLocalizer(lang string) *i18n.Localizer {
 	if localizer, ok := Localizer[lang]; ok {
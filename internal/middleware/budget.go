@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+)
+
+// APIBudget 将当前凭证的 Cloudflare 请求配额注入模板（.APIBudget），需在 AuthRequired 之后使用
+// 模板在渲染时读取剩余配额，因此会包含本次请求已消耗的部分；pool 为 nil 时不做任何事
+func APIBudget(pool *service.ClientPool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if pool == nil {
+			return c.Next()
+		}
+		authType, _ := c.Locals("auth_type").(string)
+		email, _ := c.Locals("cloudflare_email").(string)
+		secret, _ := c.Locals("user_api_key").(string)
+		if budget := pool.Budget(authType, email, secret); budget != nil {
+			c.Locals("APIBudget", budget)
+		}
+		return c.Next()
+	}
+}
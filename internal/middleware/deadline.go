@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deadline 为请求设置截止时间，handler 通过 c.UserContext() 传给 Cloudflare 调用
+// 上下文基于 fasthttp 的请求上下文，服务关闭时同样会取消；路由上的 Deadline 会覆盖分组上的设置
+// fasthttp 不会在客户端断开连接时取消上下文，离开页面后请求仍会执行到完成或截止时间
+// 超时后若 handler 未返回重定向或客户端错误，响应状态改为 504
+func Deadline(d time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if d <= 0 {
+			return c.Next()
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), d)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fiber.NewError(fiber.StatusGatewayTimeout, "Cloudflare API 请求超时，请稍后重试")
+		}
+		// 以实际生效的上下文为准（路由上的 Deadline 可能替换了分组设置的上下文）
+		if errors.Is(c.UserContext().Err(), context.DeadlineExceeded) {
+			status := c.Response().StatusCode()
+			if status < 300 || status >= 500 {
+				c.Status(fiber.StatusGatewayTimeout)
+			}
+		}
+		return err
+	}
+}
@@ -5,19 +5,27 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/session"
-	"github.com/gofiber/storage/memory/v2"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/credentials"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/model"
 )
 
 var Store *session.Store
 
-func InitSession(expiration time.Duration) {
+// Vault 凭证保险库，会话中只保存凭证引用
+var Vault *credentials.Vault
+
+// InitSession 初始化会话
+// storage 为持久化存储（bbolt / sqlite）时，服务重启后会话依然有效
+func InitSession(expiration time.Duration, storage fiber.Storage, vault *credentials.Vault) {
 	Store = session.New(session.Config{
-		Storage:    memory.New(),
-		Expiration: expiration,
-		KeyLookup:  "cookie:session_id",
+		Storage:        storage,
+		Expiration:     expiration,
+		KeyLookup:      "cookie:session_id",
 		CookieHTTPOnly: true,
 		CookieSameSite: "Lax",
 	})
+	Vault = vault
 }
 
 // AuthRequired 认证中间件
@@ -27,20 +35,67 @@ func AuthRequired(c *fiber.Ctx) error {
 		return handleAuthFailure(c)
 	}
 
-	email := sess.Get("cloudflare_email")
-	apiKey := sess.Get("user_api_key")
+	ref, _ := sess.Get("credential_ref").(string)
+	if ref == "" {
+		return handleAuthFailure(c)
+	}
 
-	if email == nil || apiKey == nil {
+	// 从保险库解密凭证（仅在本次请求内以明文存在）
+	cred, err := Vault.Get(ref)
+	if err != nil {
+		sess.Destroy()
 		return handleAuthFailure(c)
 	}
 
 	// 注入到上下文
-	c.Locals("cloudflare_email", email.(string))
-	c.Locals("user_api_key", apiKey.(string))
+	c.Locals("credential_ref", ref)
+	c.Locals("auth_type", cred.AuthType)
+	c.Locals("cloudflare_email", cred.Email)
+	c.Locals("user_api_key", cred.Secret)
+	c.Locals("account_label", cred.Label)
 
 	return c.Next()
 }
 
+// AccountRefs 返回会话绑定的全部账户引用 ID
+// 会话中 credential_ref 为当前账户，account_refs 为全部账户（含当前账户）
+func AccountRefs(sess *session.Session) []string {
+	if refs, ok := sess.Get("account_refs").([]string); ok && len(refs) > 0 {
+		return refs
+	}
+	if ref, ok := sess.Get("credential_ref").(string); ok && ref != "" {
+		return []string{ref}
+	}
+	return nil
+}
+
+// SessionAccounts 读取会话中绑定的账户信息（不含密钥），已失效的凭证会被跳过
+func SessionAccounts(sess *session.Session) *model.Session {
+	active, _ := sess.Get("credential_ref").(string)
+	result := &model.Session{ActiveRef: active}
+
+	for _, ref := range AccountRefs(sess) {
+		cred, err := Vault.Get(ref)
+		if err != nil {
+			continue
+		}
+		label := cred.Label
+		if label == "" {
+			label = cred.Email
+		}
+		if label == "" {
+			label = "API Token"
+		}
+		result.Accounts = append(result.Accounts, model.Account{
+			Ref:      ref,
+			Label:    label,
+			AuthType: cred.AuthType,
+			Email:    cred.Email,
+		})
+	}
+	return result
+}
+
 // handleAuthFailure 处理认证失败 - 根据请求类型返回不同响应
 func handleAuthFailure(c *fiber.Ctx) error {
 	// 检查是否为 API 请求
@@ -2,13 +2,37 @@ package model
 
 import "time"
 
+// Account 绑定到登录会话的一组 Cloudflare 凭证（不含密钥，密钥保存在凭证保险库中）
+type Account struct {
+	Ref      string // 保险库引用 ID
+	Label    string // 显示名称
+	AuthType string
+	Email    string
+}
+
+// Session 登录会话，可以绑定多个账户，其中一个为当前账户
 type Session struct {
-	CloudflareEmail string
-	UserAPIKey      string
-	CreatedAt       time.Time
-	ExpiresAt       time.Time
+	Accounts  []Account
+	ActiveRef string
+	CreatedAt time.Time
+	ExpiresAt time.Time
 }
 
 func (s *Session) IsExpired() bool {
 	return time.Now().After(s.ExpiresAt)
 }
+
+// Active 返回当前账户
+func (s *Session) Active() *Account {
+	return s.Account(s.ActiveRef)
+}
+
+// Account 根据引用 ID 查找账户
+func (s *Session) Account(ref string) *Account {
+	for i := range s.Accounts {
+		if s.Accounts[i].Ref == ref {
+			return &s.Accounts[i]
+		}
+	}
+	return nil
+}
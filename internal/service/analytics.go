@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AnalyticsRange 统计时间范围
+type AnalyticsRange struct {
+	Key      string        // URL 参数值
+	Label    string        // 页面显示名称
+	Duration time.Duration // 时间跨度
+	Daily    bool          // true 使用 httpRequests1dGroups，否则使用 httpRequests1hGroups
+}
+
+// AnalyticsRanges 支持的时间范围（1h 分组最多只能查询最近 3 天）
+var AnalyticsRanges = []AnalyticsRange{
+	{Key: "6h", Label: "6 小时", Duration: 6 * time.Hour},
+	{Key: "24h", Label: "24 小时", Duration: 24 * time.Hour},
+	{Key: "72h", Label: "3 天", Duration: 72 * time.Hour},
+	{Key: "7d", Label: "7 天", Duration: 7 * 24 * time.Hour, Daily: true},
+	{Key: "30d", Label: "30 天", Duration: 30 * 24 * time.Hour, Daily: true},
+}
+
+// DefaultAnalyticsRange 默认时间范围
+const DefaultAnalyticsRange = "24h"
+
+// FindAnalyticsRange 按 key 查找时间范围
+func FindAnalyticsRange(key string) (AnalyticsRange, bool) {
+	for _, r := range AnalyticsRanges {
+		if r.Key == key {
+			return r, true
+		}
+	}
+	return AnalyticsRange{}, false
+}
+
+// AnalyticsData 分析数据结构
+type AnalyticsData struct {
+	Range            string          `json:"range"`
+	Since            time.Time       `json:"since"`
+	Until            time.Time       `json:"until"`
+	Requests         []DataPoint     `json:"requests"`
+	CachedRequests   []DataPoint     `json:"cached_requests"`
+	UncachedRequests []DataPoint     `json:"uncached_requests"`
+	Bandwidth        []DataPoint     `json:"bandwidth"`        // MB
+	CachedBandwidth  []DataPoint     `json:"cached_bandwidth"` // MB
+	PageViews        []DataPoint     `json:"pageviews"`
+	Uniques          []DataPoint     `json:"uniques"`
+	Threats          []DataPoint     `json:"threats"`
+	StatusCodes      []StatusCount   `json:"status_codes"`
+	Totals           AnalyticsTotals `json:"totals"`
+}
+
+// DataPoint 数据点
+type DataPoint struct {
+	Timestamp string  `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// StatusCount 边缘响应状态码统计
+type StatusCount struct {
+	Status   int   `json:"status"`
+	Requests int64 `json:"requests"`
+}
+
+// AnalyticsTotals 时间范围内的汇总
+type AnalyticsTotals struct {
+	Requests       int64 `json:"requests"`
+	CachedRequests int64 `json:"cached_requests"`
+	Bytes          int64 `json:"bytes"`
+	CachedBytes    int64 `json:"cached_bytes"`
+	PageViews      int64 `json:"pageviews"`
+	Uniques        int64 `json:"uniques"`
+	Threats        int64 `json:"threats"`
+}
+
+// CacheRatio 请求缓存命中率（百分比）
+func (t AnalyticsTotals) CacheRatio() float64 {
+	if t.Requests == 0 {
+		return 0
+	}
+	return float64(t.CachedRequests) * 100 / float64(t.Requests)
+}
+
+// BandwidthMB 总带宽（MB）
+func (t AnalyticsTotals) BandwidthMB() float64 {
+	return float64(t.Bytes) / (1024 * 1024)
+}
+
+// httpRequestsGroup httpRequests1hGroups / httpRequests1dGroups 的单个分组
+type httpRequestsGroup struct {
+	Dimensions struct {
+		Timeslot string `json:"timeslot"`
+	} `json:"dimensions"`
+	Sum struct {
+		Requests          int64 `json:"requests"`
+		CachedRequests    int64 `json:"cachedRequests"`
+		Bytes             int64 `json:"bytes"`
+		CachedBytes       int64 `json:"cachedBytes"`
+		Threats           int64 `json:"threats"`
+		PageViews         int64 `json:"pageViews"`
+		ResponseStatusMap []struct {
+			EdgeResponseStatus int   `json:"edgeResponseStatus"`
+			Requests           int64 `json:"requests"`
+		} `json:"responseStatusMap"`
+	} `json:"sum"`
+	Uniq struct {
+		Uniques int64 `json:"uniques"`
+	} `json:"uniq"`
+}
+
+// httpRequestsQuery 查询模板，按数据集填入名称、时间维度和变量类型
+const httpRequestsQuery = `query ($zoneTag: string, $since: %[3]s, $until: %[3]s, $limit: uint64) {
+  viewer {
+    zones(filter: {zoneTag: $zoneTag}) {
+      groups: %[1]s(limit: $limit, filter: {%[2]s_geq: $since, %[2]s_lt: $until}, orderBy: [%[2]s_ASC]) {
+        dimensions { timeslot: %[2]s }
+        sum {
+          requests
+          cachedRequests
+          bytes
+          cachedBytes
+          threats
+          pageViews
+          responseStatusMap { edgeResponseStatus requests }
+        }
+        uniq { uniques }
+      }
+    }
+  }
+}`
+
+// GetAnalytics 通过 GraphQL Analytics API 获取 Zone 的 HTTP 流量统计
+func (s *CloudflareService) GetAnalytics(ctx context.Context, zoneID string, r AnalyticsRange, now time.Time) (*AnalyticsData, error) {
+	until := now.UTC()
+	since := until.Add(-r.Duration)
+
+	dataset, dimension, varType := "httpRequests1hGroups", "datetime", "Time"
+	vars := map[string]interface{}{
+		"zoneTag": zoneID,
+		"limit":   int(r.Duration/time.Hour) + 1,
+	}
+	labelLayout := "01-02 15:04"
+	if r.Daily {
+		dataset, dimension, varType = "httpRequests1dGroups", "date", "Date"
+		// 按日分组的 until 取次日零点，包含今天的数据
+		until = until.Truncate(24 * time.Hour).Add(24 * time.Hour)
+		since = until.Add(-r.Duration)
+		vars["since"] = since.Format("2006-01-02")
+		vars["until"] = until.Format("2006-01-02")
+		vars["limit"] = int(r.Duration/(24*time.Hour)) + 1
+		labelLayout = "01-02"
+	} else {
+		since = since.Truncate(time.Hour)
+		vars["since"] = since.Format(time.RFC3339)
+		vars["until"] = until.Format(time.RFC3339)
+	}
+
+	var result struct {
+		Viewer struct {
+			Zones []struct {
+				Groups []httpRequestsGroup `json:"groups"`
+			} `json:"zones"`
+		} `json:"viewer"`
+	}
+	query := fmt.Sprintf(httpRequestsQuery, dataset, dimension, varType)
+	if err := s.graphQL(ctx, query, vars, &result); err != nil {
+		return nil, err
+	}
+
+	data := &AnalyticsData{Range: r.Key, Since: since, Until: until}
+	if len(result.Viewer.Zones) == 0 {
+		return data, nil
+	}
+
+	statuses := make(map[int]int64)
+	for _, g := range result.Viewer.Zones[0].Groups {
+		label := g.Dimensions.Timeslot
+		if t, err := parseTimeslot(g.Dimensions.Timeslot); err == nil {
+			// 按日分组的日期是 UTC 日期，不做时区转换
+			if !r.Daily {
+				t = t.Local()
+			}
+			label = t.Format(labelLayout)
+		}
+		sum := g.Sum
+		data.Requests = append(data.Requests, DataPoint{label, float64(sum.Requests)})
+		data.CachedRequests = append(data.CachedRequests, DataPoint{label, float64(sum.CachedRequests)})
+		data.UncachedRequests = append(data.UncachedRequests, DataPoint{label, float64(sum.Requests - sum.CachedRequests)})
+		data.Bandwidth = append(data.Bandwidth, DataPoint{label, bytesToMB(sum.Bytes)})
+		data.CachedBandwidth = append(data.CachedBandwidth, DataPoint{label, bytesToMB(sum.CachedBytes)})
+		data.PageViews = append(data.PageViews, DataPoint{label, float64(sum.PageViews)})
+		data.Uniques = append(data.Uniques, DataPoint{label, float64(g.Uniq.Uniques)})
+		data.Threats = append(data.Threats, DataPoint{label, float64(sum.Threats)})
+
+		data.Totals.Requests += sum.Requests
+		data.Totals.CachedRequests += sum.CachedRequests
+		data.Totals.Bytes += sum.Bytes
+		data.Totals.CachedBytes += sum.CachedBytes
+		data.Totals.PageViews += sum.PageViews
+		data.Totals.Threats += sum.Threats
+		// 各分组的独立访客相加会重复计数，仅作参考
+		data.Totals.Uniques += g.Uniq.Uniques
+
+		for _, st := range sum.ResponseStatusMap {
+			statuses[st.EdgeResponseStatus] += st.Requests
+		}
+	}
+
+	for status, requests := range statuses {
+		data.StatusCodes = append(data.StatusCodes, StatusCount{Status: status, Requests: requests})
+	}
+	sort.Slice(data.StatusCodes, func(i, j int) bool {
+		if data.StatusCodes[i].Requests != data.StatusCodes[j].Requests {
+			return data.StatusCodes[i].Requests > data.StatusCodes[j].Requests
+		}
+		return data.StatusCodes[i].Status < data.StatusCodes[j].Status
+	})
+
+	return data, nil
+}
+
+// graphQL 调用 Cloudflare GraphQL API，将 data 字段解析到 out
+func (s *CloudflareService) graphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal graphql request: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(s.API.BaseURL, "/") + "/graphql"
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	s.setAuthHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	var gqlResp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &gqlResp); err != nil {
+		return fmt.Errorf("unmarshal graphql response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if len(gqlResp.Errors) > 0 {
+		messages := make([]string, 0, len(gqlResp.Errors))
+		for _, e := range gqlResp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("GraphQL error: %s", strings.Join(messages, "; "))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GraphQL request failed: HTTP %d", resp.StatusCode)
+	}
+	if len(gqlResp.Data) == 0 || string(gqlResp.Data) == "null" {
+		return fmt.Errorf("GraphQL response has no data")
+	}
+	return json.Unmarshal(gqlResp.Data, out)
+}
+
+// parseTimeslot 解析 datetime（RFC3339）或 date（2006-01-02）维度
+func parseTimeslot(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// bytesToMB 字节转换为 MB，保留两位小数
+func bytesToMB(b int64) float64 {
+	return float64(int64(float64(b)/(1024*1024)*100)) / 100
+}
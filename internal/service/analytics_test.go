@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// graphQLRequest 测试服务器收到的 GraphQL 请求
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// newGraphQLService 返回以 testdata 中录制的响应应答 /graphql 的服务，并记录最后一次请求
+func newGraphQLService(t *testing.T, fixture string) (*CloudflareService, *graphQLRequest) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got graphQLRequest
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/graphql" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	return s, &got
+}
+
+func TestGetAnalyticsDaily(t *testing.T) {
+	s, req := newGraphQLService(t, "analytics_1d.json")
+	r, _ := FindAnalyticsRange("7d")
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+
+	data, err := s.GetAnalytics(context.Background(), "zone1", r, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(req.Query, "httpRequests1dGroups") || !strings.Contains(req.Query, "date_geq: $since") {
+		t.Errorf("query does not use the daily dataset:\n%s", req.Query)
+	}
+	wantVars := map[string]interface{}{"zoneTag": "zone1", "since": "2026-03-04", "until": "2026-03-11", "limit": float64(8)}
+	if !reflect.DeepEqual(req.Variables, wantVars) {
+		t.Errorf("variables = %v, want %v", req.Variables, wantVars)
+	}
+
+	want := AnalyticsTotals{
+		Requests:       20000,
+		CachedRequests: 14000,
+		Bytes:          786432000,
+		CachedBytes:    576716800,
+		PageViews:      5300,
+		Uniques:        1460,
+		Threats:        15,
+	}
+	if data.Totals != want {
+		t.Errorf("Totals = %+v, want %+v", data.Totals, want)
+	}
+	if data.Totals.CacheRatio() != 70 || data.Totals.BandwidthMB() != 750 {
+		t.Errorf("CacheRatio = %v, BandwidthMB = %v", data.Totals.CacheRatio(), data.Totals.BandwidthMB())
+	}
+
+	wantRequests := []DataPoint{{"03-09", 12000}, {"03-10", 8000}}
+	if !reflect.DeepEqual(data.Requests, wantRequests) {
+		t.Errorf("Requests = %v, want %v", data.Requests, wantRequests)
+	}
+	wantUncached := []DataPoint{{"03-09", 3000}, {"03-10", 3000}}
+	if !reflect.DeepEqual(data.UncachedRequests, wantUncached) {
+		t.Errorf("UncachedRequests = %v, want %v", data.UncachedRequests, wantUncached)
+	}
+	wantBandwidth := []DataPoint{{"03-09", 500}, {"03-10", 250}}
+	if !reflect.DeepEqual(data.Bandwidth, wantBandwidth) {
+		t.Errorf("Bandwidth = %v, want %v", data.Bandwidth, wantBandwidth)
+	}
+
+	// 状态码跨分组合并，按请求数降序
+	wantStatus := []StatusCount{{200, 18500}, {404, 800}, {301, 400}, {503, 300}}
+	if !reflect.DeepEqual(data.StatusCodes, wantStatus) {
+		t.Errorf("StatusCodes = %v, want %v", data.StatusCodes, wantStatus)
+	}
+}
+
+func TestGetAnalyticsHourly(t *testing.T) {
+	s, req := newGraphQLService(t, "analytics_1h.json")
+	r, _ := FindAnalyticsRange("6h")
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+
+	data, err := s.GetAnalytics(context.Background(), "zone1", r, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(req.Query, "httpRequests1hGroups") || !strings.Contains(req.Query, "datetime_geq: $since") {
+		t.Errorf("query does not use the hourly dataset:\n%s", req.Query)
+	}
+	// since 向前取整到整点
+	wantVars := map[string]interface{}{"zoneTag": "zone1", "since": "2026-03-10T09:00:00Z", "until": "2026-03-10T15:30:00Z", "limit": float64(7)}
+	if !reflect.DeepEqual(req.Variables, wantVars) {
+		t.Errorf("variables = %v, want %v", req.Variables, wantVars)
+	}
+
+	// 按小时分组的时间显示为本地时间
+	label := func(v string) string {
+		ts, _ := time.Parse(time.RFC3339, v)
+		return ts.Local().Format("01-02 15:04")
+	}
+	wantCached := []DataPoint{{label("2026-03-10T13:00:00Z"), 400}, {label("2026-03-10T14:00:00Z"), 100}}
+	if !reflect.DeepEqual(data.CachedRequests, wantCached) {
+		t.Errorf("CachedRequests = %v, want %v", data.CachedRequests, wantCached)
+	}
+	if data.Totals.Requests != 800 || data.Totals.Threats != 1 || data.Totals.Uniques != 65 {
+		t.Errorf("Totals = %+v", data.Totals)
+	}
+	wantStatus := []StatusCount{{200, 790}, {403, 10}}
+	if !reflect.DeepEqual(data.StatusCodes, wantStatus) {
+		t.Errorf("StatusCodes = %v, want %v", data.StatusCodes, wantStatus)
+	}
+}
+
+func TestGetAnalyticsError(t *testing.T) {
+	s, _ := newGraphQLService(t, "analytics_error.json")
+	r, _ := FindAnalyticsRange(DefaultAnalyticsRange)
+
+	_, err := s.GetAnalytics(context.Background(), "zone1", r, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "does not have access to the path") {
+		t.Fatalf("GetAnalytics error = %v, want the GraphQL error message", err)
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// 批量操作类型
+const (
+	BulkDelete   = "delete"
+	BulkProxyOn  = "proxy_on"
+	BulkProxyOff = "proxy_off"
+	BulkTTL      = "ttl"
+	BulkReplace  = "replace"
+)
+
+// DefaultBulkConcurrency 批量操作默认并发数
+const DefaultBulkConcurrency = 4
+
+// BulkOperation 批量操作参数
+type BulkOperation struct {
+	Action  string
+	TTL     int    // BulkTTL 使用
+	Find    string // BulkReplace 使用
+	Replace string // BulkReplace 使用
+}
+
+// Validate 检查操作参数
+func (op BulkOperation) Validate() error {
+	switch op.Action {
+	case BulkDelete, BulkProxyOn, BulkProxyOff:
+		return nil
+	case BulkTTL:
+		if op.TTL != 1 && (op.TTL < 60 || op.TTL > 86400) {
+			return fmt.Errorf("TTL 必须为自动（1）或 60 ~ 86400 秒")
+		}
+		return nil
+	case BulkReplace:
+		if op.Find == "" {
+			return fmt.Errorf("查找内容不能为空")
+		}
+		if op.Find == op.Replace {
+			return fmt.Errorf("查找内容与替换内容相同")
+		}
+		return nil
+	default:
+		return fmt.Errorf("未知的批量操作: %s", op.Action)
+	}
+}
+
+// Plan 计算单条记录的变更结果，返回 false 表示该记录无需处理及原因
+func (op BulkOperation) Plan(record cloudflare.DNSRecord) (cloudflare.DNSRecord, bool, string) {
+	updated := record
+	switch op.Action {
+	case BulkDelete:
+		return record, true, ""
+
+	case BulkProxyOn, BulkProxyOff:
+		if record.Type != "A" && record.Type != "AAAA" && record.Type != "CNAME" {
+			return record, false, "该类型不支持代理"
+		}
+		want := op.Action == BulkProxyOn
+		if record.Proxied != nil && *record.Proxied == want {
+			return record, false, "代理状态未变化"
+		}
+		updated.Proxied = &want
+		if want {
+			updated.TTL = 1
+		}
+
+	case BulkTTL:
+		if record.TTL == op.TTL {
+			return record, false, "TTL 未变化"
+		}
+		if record.Proxied != nil && *record.Proxied && op.TTL != 1 {
+			return record, false, "已代理记录的 TTL 固定为自动"
+		}
+		updated.TTL = op.TTL
+
+	case BulkReplace:
+		if record.Data != nil {
+			return record, false, "结构化记录请单独编辑"
+		}
+		if record.Type != "TXT" {
+			// IP、主机名等只能整体匹配，避免 203.0.113.5 误改 203.0.113.50
+			if !strings.EqualFold(strings.TrimSuffix(record.Content, "."), strings.TrimSuffix(op.Find, ".")) {
+				return record, false, "内容与查找内容不一致"
+			}
+			if op.Replace == "" {
+				return record, false, "替换内容不能为空"
+			}
+			updated.Content = op.Replace
+			break
+		}
+		content, n := replaceTokens(record.Content, op.Find, op.Replace)
+		if n == 0 {
+			return record, false, "内容不包含查找内容"
+		}
+		updated.Content = content
+	}
+	return updated, true, ""
+}
+
+// replaceTokens 替换 TXT 内容中完整出现的 find（两侧为边界或非标识符字符），返回替换次数
+// "." 视为边界，因此 example.com 可以匹配 _spf.example.com，但 ip4:203.0.113.5 不会匹配 ip4:203.0.113.50
+func replaceTokens(s, find, replace string) (string, int) {
+	var b strings.Builder
+	count, last := 0, 0
+	for pos := 0; pos <= len(s)-len(find); {
+		i := strings.Index(s[pos:], find)
+		if i < 0 {
+			break
+		}
+		i += pos
+		end := i + len(find)
+		leftOK := i == 0 || !isTokenChar(find[0]) || !isTokenChar(s[i-1])
+		rightOK := end == len(s) || !isTokenChar(find[len(find)-1]) || !isTokenChar(s[end])
+		if !leftOK || !rightOK {
+			pos = i + 1
+			continue
+		}
+		b.WriteString(s[last:i])
+		b.WriteString(replace)
+		count++
+		last, pos = end, end
+	}
+	b.WriteString(s[last:])
+	return b.String(), count
+}
+
+// isTokenChar 主机名标签和 IP 地址中的连续字符
+func isTokenChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c >= 0x80
+}
+
+// BulkResult 单条记录的执行结果
+type BulkResult struct {
+	Record  cloudflare.DNSRecord  // 操作前的记录
+	After   *cloudflare.DNSRecord // 更新后的记录，删除和跳过时为 nil
+	Skipped bool
+	Reason  string // 跳过原因
+	Err     error
+}
+
+// BulkApply 以有限并发对记录执行批量操作，单条失败不会中断其他记录
+// 结果顺序与传入的记录一致
+func BulkApply(ctx context.Context, s Client, zoneID string, records []cloudflare.DNSRecord, op BulkOperation, concurrency int) []BulkResult {
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	rc := cloudflare.ZoneIdentifier(zoneID)
+
+	results := make([]BulkResult, len(records))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, record := range records {
+		updated, ok, reason := op.Plan(record)
+		if !ok {
+			results[i] = BulkResult{Record: record, Skipped: true, Reason: reason}
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, record, updated cloudflare.DNSRecord) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := BulkResult{Record: record}
+			if op.Action == BulkDelete {
+				result.Err = s.DeleteDNSRecord(ctx, rc, record.ID)
+			} else {
+				after, err := s.UpdateDNSRecord(ctx, rc, UpdateParamsFromRecord(record.ID, updated))
+				result.Err = err
+				if err == nil {
+					result.After = &after
+				}
+			}
+			results[i] = result
+		}(i, record, updated)
+	}
+	wg.Wait()
+
+	return results
+}
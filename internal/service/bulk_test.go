@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func TestBulkReplacePlan(t *testing.T) {
+	op := BulkOperation{Action: BulkReplace, Find: "203.0.113.5", Replace: "198.51.100.7"}
+	tests := []struct {
+		name    string
+		record  cloudflare.DNSRecord
+		ok      bool
+		content string
+	}{
+		{"exact A", cloudflare.DNSRecord{Type: "A", Content: "203.0.113.5"}, true, "198.51.100.7"},
+		{"A prefix collision", cloudflare.DNSRecord{Type: "A", Content: "203.0.113.50"}, false, "203.0.113.50"},
+		{"A suffix collision", cloudflare.DNSRecord{Type: "A", Content: "10.203.0.113.5"}, false, "10.203.0.113.5"},
+		{"TXT token", cloudflare.DNSRecord{Type: "TXT", Content: "v=spf1 ip4:203.0.113.5 ip4:203.0.113.59 -all"}, true, "v=spf1 ip4:198.51.100.7 ip4:203.0.113.59 -all"},
+		{"TXT only collisions", cloudflare.DNSRecord{Type: "TXT", Content: "v=spf1 ip4:203.0.113.50 -all"}, false, "v=spf1 ip4:203.0.113.50 -all"},
+		{"TXT end of content", cloudflare.DNSRecord{Type: "TXT", Content: "ip4:203.0.113.5"}, true, "ip4:198.51.100.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, ok, reason := op.Plan(tt.record)
+			if ok != tt.ok || updated.Content != tt.content {
+				t.Errorf("Plan = %q, %v (%s); want %q, %v", updated.Content, ok, reason, tt.content, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBulkReplaceHostnames(t *testing.T) {
+	op := BulkOperation{Action: BulkReplace, Find: "example.com", Replace: "example.net"}
+
+	// CNAME 整体匹配，忽略大小写和末尾的点
+	if updated, ok, _ := op.Plan(cloudflare.DNSRecord{Type: "CNAME", Content: "Example.com."}); !ok || updated.Content != "example.net" {
+		t.Errorf("CNAME = %q, %v", updated.Content, ok)
+	}
+	if _, ok, _ := op.Plan(cloudflare.DNSRecord{Type: "CNAME", Content: "cdn.example.com"}); ok {
+		t.Error("CNAME subdomain should not match a whole-content replace")
+	}
+	// TXT 按标签边界匹配
+	updated, ok, _ := op.Plan(cloudflare.DNSRecord{Type: "TXT", Content: "v=spf1 include:_spf.example.com include:myexample.com ~all"})
+	if !ok || updated.Content != "v=spf1 include:_spf.example.net include:myexample.com ~all" {
+		t.Errorf("TXT = %q, %v", updated.Content, ok)
+	}
+}
+
+func TestReplaceTokens(t *testing.T) {
+	tests := []struct {
+		s, find, replace, want string
+		count                  int
+	}{
+		{"a a a", "a", "b", "b b b", 3},
+		{"aaa", "a", "b", "aaa", 0},
+		{"key=old;other=older", "old", "new", "key=new;other=older", 1},
+		{"x, y", ", ", "|", "x|y", 1},
+	}
+	for _, tt := range tests {
+		got, n := replaceTokens(tt.s, tt.find, tt.replace)
+		if got != tt.want || n != tt.count {
+			t.Errorf("replaceTokens(%q, %q) = %q, %d; want %q, %d", tt.s, tt.find, got, n, tt.want, tt.count)
+		}
+	}
+}
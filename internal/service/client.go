@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// Client handler 使用的 Cloudflare 操作
+// CloudflareService 为真实实现，fakecf.Backend 提供不访问网络的内存实现
+type Client interface {
+	// 凭证
+	VerifyCredentials(ctx context.Context) error
+	UserEmail(ctx context.Context) (string, error)
+
+	// 域名
+	ListZones(ctx context.Context, q ZoneQuery) ([]cloudflare.Zone, *cloudflare.ResultInfo, error)
+	ListAllZones(ctx context.Context) ([]cloudflare.Zone, error)
+	GetZone(ctx context.Context, zoneID string) (cloudflare.Zone, error)
+	ZoneIDByName(ctx context.Context, name string) (string, error)
+	CreateZone(ctx context.Context, name string) (cloudflare.Zone, error)
+	DeleteZone(ctx context.Context, zoneID string) error
+
+	// DNS 记录
+	ListDNSRecords(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.ListDNSRecordsParams) ([]cloudflare.DNSRecord, *cloudflare.ResultInfo, error)
+	GetDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, recordID string) (cloudflare.DNSRecord, error)
+	CreateDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.CreateDNSRecordParams) (cloudflare.DNSRecord, error)
+	UpdateDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.UpdateDNSRecordParams) (cloudflare.DNSRecord, error)
+	DeleteDNSRecord(ctx context.Context, rc *cloudflare.ResourceContainer, recordID string) error
+
+	// DNSSEC
+	GetDNSSEC(ctx context.Context, zoneID string) (*DNSSECDetails, error)
+	UpdateDNSSEC(ctx context.Context, zoneID string, status string) (*DNSSECDetails, error)
+
+	// Zone 设置
+	GetZoneSettings(ctx context.Context, zoneID string) ([]cloudflare.ZoneSetting, error)
+	ZoneSettingValues(ctx context.Context, zoneID string, ids []string) (map[string]interface{}, error)
+	UpdateZoneSetting(ctx context.Context, zoneID, settingID string, value interface{}) error
+	UpdateZoneSettings(ctx context.Context, zoneID string, settings []cloudflare.ZoneSetting) error
+
+	// 缓存
+	PurgeAllCache(ctx context.Context, zoneID string) error
+	PurgeCacheByURLs(ctx context.Context, zoneID string, urls []string) error
+	PurgeCacheByHosts(ctx context.Context, zoneID string, hosts []string) error
+	PurgeCacheByPrefixes(ctx context.Context, zoneID string, prefixes []string) error
+	PurgeCacheByTags(ctx context.Context, zoneID string, tags []string) error
+
+	// 证书
+	ListEdgeCertificates(ctx context.Context, zoneID string) ([]cloudflare.CertificatePack, error)
+	GetEdgeCertificate(ctx context.Context, zoneID, certID string) (cloudflare.CertificatePack, error)
+	ListOriginCertificates(ctx context.Context, zoneID string) ([]cloudflare.OriginCACertificate, error)
+	GetOriginCertificate(ctx context.Context, certID string) (*cloudflare.OriginCACertificate, error)
+	CreateOriginCertificate(ctx context.Context, hostnames []string, requestType string, validityDays int) (*OriginCertificateWithKey, error)
+	RevokeOriginCertificate(ctx context.Context, certID string) error
+	ListCustomSSLCertificates(ctx context.Context, zoneID string) ([]cloudflare.ZoneCustomSSL, error)
+	GetSSLVerification(ctx context.Context, zoneID string) ([]SSLVerification, error)
+
+	// 统计
+	GetAnalytics(ctx context.Context, zoneID string, r AnalyticsRange, now time.Time) (*AnalyticsData, error)
+	GetDNSAnalytics(ctx context.Context, zoneID string, since, until time.Time) (*DNSAnalyticsReport, error)
+}
+
+var _ Client = (*CloudflareService)(nil)
+
+// ClientFactory 根据凭证创建 Client，由 main 注入 handler，测试时替换为内存实现
+type ClientFactory func(authType, email, secret string) (Client, error)
+
+// NewClient 使用默认网络设置的 ClientFactory，创建访问 Cloudflare API 的 CloudflareService
+func NewClient(authType, email, secret string) (Client, error) {
+	s, err := NewCloudflareServiceWithAuth(authType, email, secret)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
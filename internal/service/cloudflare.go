@@ -14,64 +14,154 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudflare/cloudflare-go"
+	"github.com/miekg/dns"
+)
+
+// 凭证类型
+const (
+	AuthTypeAPIKey   = "api_key"   // 邮箱 + Global API Key
+	AuthTypeAPIToken = "api_token" // 范围受限的 API Token
 )
 
 type CloudflareService struct {
-	API    *cloudflare.API
-	Email  string
-	APIKey string
+	API        *cloudflare.API
+	HTTPClient *http.Client // 原始 HTTP 调用使用，与 SDK 共用
+	AuthType   string
+	Email      string
+	APIKey     string
+	APIToken   string
 }
 
+// NewCloudflareService 使用邮箱 + Global API Key 创建服务
 func NewCloudflareService(email, apiKey string) (*CloudflareService, error) {
-	api, err := cloudflare.New(apiKey, email)
+	return NewCloudflareServiceWithAuth(AuthTypeAPIKey, email, apiKey)
+}
+
+// NewCloudflareServiceWithToken 使用 API Token 创建服务
+func NewCloudflareServiceWithToken(token string) (*CloudflareService, error) {
+	return NewCloudflareServiceWithAuth(AuthTypeAPIToken, "", token)
+}
+
+// NewCloudflareServiceWithAuth 根据凭证类型创建服务，使用默认网络设置
+// authType 为空时按 Global API Key 处理（兼容旧会话）
+func NewCloudflareServiceWithAuth(authType, email, secret string) (*CloudflareService, error) {
+	client, err := defaultHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	return NewCloudflareServiceWithOptions(authType, email, secret, DefaultHTTPOptions, client)
+}
+
+// defaultHTTPClient 按 DefaultHTTPOptions 创建的共享客户端
+var defaultHTTPClient = sync.OnceValues(func() (*http.Client, error) {
+	return NewHTTPClient(DefaultHTTPOptions)
+})
+
+// NewCloudflareServiceWithOptions 使用指定的网络设置和 HTTP 客户端创建服务
+// client 应由 NewHTTPClient(o) 创建，多个服务共用同一个 client 以复用连接
+func NewCloudflareServiceWithOptions(authType, email, secret string, o HTTPOptions, client *http.Client) (*CloudflareService, error) {
+	s := &CloudflareService{
+		HTTPClient: client,
+		AuthType:   authType,
+		Email:      email,
+	}
+
+	var api *cloudflare.API
+	var err error
+	opts := sdkOptions(o, client)
+	switch authType {
+	case AuthTypeAPIToken:
+		s.APIToken = secret
+		api, err = cloudflare.NewWithAPIToken(secret, opts...)
+	case AuthTypeAPIKey, "":
+		s.AuthType = AuthTypeAPIKey
+		s.APIKey = secret
+		api, err = cloudflare.New(secret, email, opts...)
+	default:
+		return nil, fmt.Errorf("unknown auth type: %s", authType)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Cloudflare API client: %w", err)
 	}
 
-	return &CloudflareService{
-		API:    api,
-		Email:  email,
-		APIKey: apiKey,
-	}, nil
+	s.API = api
+	return s, nil
 }
 
 // VerifyCredentials 验证凭证是否有效
+// API Token 通过 /user/tokens/verify 校验，Global API Key 通过读取用户信息校验
 func (s *CloudflareService) VerifyCredentials(ctx context.Context) error {
+	if s.AuthType == AuthTypeAPIToken {
+		result, err := s.API.VerifyAPIToken(ctx)
+		if err != nil {
+			return err
+		}
+		if result.Status != "active" {
+			return fmt.Errorf("API token is %s", result.Status)
+		}
+		return nil
+	}
+
 	_, err := s.API.UserDetails(ctx)
 	return err
 }
 
-// ListZones 获取域名列表
-func (s *CloudflareService) ListZones(ctx context.Context, page int) ([]cloudflare.Zone, *cloudflare.ResultInfo, error) {
-	zones, err := s.API.ListZones(ctx)
+// UserEmail 获取当前账户邮箱（API Token 可能没有读取用户信息的权限）
+func (s *CloudflareService) UserEmail(ctx context.Context) (string, error) {
+	if s.Email != "" {
+		return s.Email, nil
+	}
+	user, err := s.API.UserDetails(ctx)
 	if err != nil {
-		return nil, nil, err
+		return "", err
 	}
+	return user.Email, nil
+}
 
-	// 简单分页处理
-	perPage := 20
+// setAuthHeaders 为原始 HTTP 请求设置认证头
+func (s *CloudflareService) setAuthHeaders(req *http.Request) {
+	if s.AuthType == AuthTypeAPIToken {
+		req.Header.Set("Authorization", "Bearer "+s.APIToken)
+		return
+	}
+	req.Header.Set("X-Auth-Email", s.Email)
+	req.Header.Set("X-Auth-Key", s.APIKey)
+}
+
+// ListAllZones 获取账户下的全部域名
+func (s *CloudflareService) ListAllZones(ctx context.Context) ([]cloudflare.Zone, error) {
+	return s.API.ListZones(ctx)
+}
+
+// Paginate 简单分页处理，返回当前页在列表中的区间 [start, end)
+func Paginate(total, page, perPage int) (int, int, *cloudflare.ResultInfo) {
+	if page < 1 {
+		page = 1
+	}
 	start := (page - 1) * perPage
 	end := start + perPage
 
 	resultInfo := &cloudflare.ResultInfo{
 		Page:       page,
 		PerPage:    perPage,
-		TotalPages: (len(zones) + perPage - 1) / perPage,
-		Count:      len(zones),
-		Total:      len(zones),
+		TotalPages: (total + perPage - 1) / perPage,
+		Count:      total,
+		Total:      total,
 	}
 
-	if start >= len(zones) {
-		return []cloudflare.Zone{}, resultInfo, nil
+	if start > total {
+		start = total
 	}
-	if end > len(zones) {
-		end = len(zones)
+	if end > total {
+		end = total
 	}
-
-	return zones[start:end], resultInfo, nil
+	return start, end, resultInfo
 }
 
 // GetZone 获取单个域名信息
@@ -121,104 +211,81 @@ func (s *CloudflareService) DeleteDNSRecord(ctx context.Context, rc *cloudflare.
 	return s.API.DeleteDNSRecord(ctx, rc, recordID)
 }
 
-// GetSSLVerification 获取 SSL 验证信息
-func (s *CloudflareService) GetSSLVerification(ctx context.Context, zoneID string) ([]cloudflare.SSLValidationRecord, error) {
-	// 使用原始 API 调用
-	// 注意：cloudflare-go SDK 可能没有直接的 SSL 验证方法，需要使用原始请求
-	return nil, fmt.Errorf("not implemented yet")
-}
-
-// AnalyticsData 分析数据结构
-type AnalyticsData struct {
-	Requests   []DataPoint `json:"requests"`
-	Bandwidth  []DataPoint `json:"bandwidth"`
-	PageViews  []DataPoint `json:"pageviews"`
-	Uniques    []DataPoint `json:"uniques"`
-	Threats    []DataPoint `json:"threats"`
-}
-
-// DataPoint 数据点
-type DataPoint struct {
-	Timestamp string  `json:"timestamp"`
-	Value     float64 `json:"value"`
-}
-
-// GetAnalytics 获取分析数据
-func (s *CloudflareService) GetAnalytics(ctx context.Context, zoneID string, since, until int) (*AnalyticsData, error) {
-	// TODO: 使用 Cloudflare Analytics API 获取实际数据
-	// 目前返回模拟数据用于演示
-	analytics := &AnalyticsData{
-		Requests: []DataPoint{
-			{Timestamp: "00:00", Value: 1200},
-			{Timestamp: "04:00", Value: 800},
-			{Timestamp: "08:00", Value: 2400},
-			{Timestamp: "12:00", Value: 3600},
-			{Timestamp: "16:00", Value: 2800},
-			{Timestamp: "20:00", Value: 1600},
-		},
-		Bandwidth: []DataPoint{
-			{Timestamp: "00:00", Value: 52.4},
-			{Timestamp: "04:00", Value: 35.2},
-			{Timestamp: "08:00", Value: 98.6},
-			{Timestamp: "12:00", Value: 142.8},
-			{Timestamp: "16:00", Value: 112.3},
-			{Timestamp: "20:00", Value: 68.5},
-		},
-		PageViews: []DataPoint{
-			{Timestamp: "00:00", Value: 450},
-			{Timestamp: "04:00", Value: 320},
-			{Timestamp: "08:00", Value: 890},
-			{Timestamp: "12:00", Value: 1250},
-			{Timestamp: "16:00", Value: 960},
-			{Timestamp: "20:00", Value: 580},
-		},
-		Uniques: []DataPoint{
-			{Timestamp: "00:00", Value: 320},
-			{Timestamp: "04:00", Value: 240},
-			{Timestamp: "08:00", Value: 650},
-			{Timestamp: "12:00", Value: 890},
-			{Timestamp: "16:00", Value: 720},
-			{Timestamp: "20:00", Value: 420},
-		},
-		Threats: []DataPoint{
-			{Timestamp: "00:00", Value: 12},
-			{Timestamp: "04:00", Value: 8},
-			{Timestamp: "08:00", Value: 24},
-			{Timestamp: "12:00", Value: 35},
-			{Timestamp: "16:00", Value: 28},
-			{Timestamp: "20:00", Value: 16},
-		},
-	}
-
-	return analytics, nil
-}
+// DNSSEC 状态
+const (
+	DNSSECActive          = "active"
+	DNSSECPending         = "pending"
+	DNSSECDisabled        = "disabled"
+	DNSSECPendingDisabled = "pending-disabled"
+)
 
-// DNSSECDetails 结构体
+// DNSSECDetails Zone 的 DNSSEC 详情（含提交给注册商的 DS 记录）
 type DNSSECDetails struct {
-	Status       string `json:"status"`
-	DNSSECPresent bool  `json:"dnssec_present"`
-	DS           string `json:"ds"`
-	DNSKey       string `json:"dnskey"`
+	Status          string    `json:"status"`
+	Flags           int       `json:"flags"`
+	Algorithm       string    `json:"algorithm"`
+	KeyType         string    `json:"key_type"`
+	DigestType      string    `json:"digest_type"`
+	DigestAlgorithm string    `json:"digest_algorithm"`
+	Digest          string    `json:"digest"`
+	DS              string    `json:"ds"`
+	KeyTag          int       `json:"key_tag"`
+	PublicKey       string    `json:"public_key"`
+	ModifiedOn      time.Time `json:"modified_on"`
+}
+
+// Enabled 是否已启用（包括等待注册商 DS 记录生效的 pending 状态）
+func (d *DNSSECDetails) Enabled() bool {
+	return d.Status == DNSSECActive || d.Status == DNSSECPending
+}
+
+// AlgorithmName 签名算法名称，如 13 → ECDSAP256SHA256
+func (d *DNSSECDetails) AlgorithmName() string {
+	n, err := strconv.Atoi(d.Algorithm)
+	if err != nil {
+		return d.Algorithm
+	}
+	if name, ok := dns.AlgorithmToString[uint8(n)]; ok {
+		return name
+	}
+	return d.Algorithm
 }
 
 // GetDNSSEC 获取 DNSSEC 状态
 func (s *CloudflareService) GetDNSSEC(ctx context.Context, zoneID string) (*DNSSECDetails, error) {
-	// TODO: 使用 Cloudflare API 获取 DNSSEC 详情
-	// 目前返回占位符数据
-	return &DNSSECDetails{
-		Status:       "disabled",
-		DNSSECPresent: false,
-	}, nil
+	result, err := s.API.ZoneDNSSECSetting(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return dnssecDetails(result), nil
 }
 
-// UpdateDNSSEC 更新 DNSSEC 状态
+// UpdateDNSSEC 更新 DNSSEC 状态（active 启用，disabled 禁用）
 func (s *CloudflareService) UpdateDNSSEC(ctx context.Context, zoneID string, status string) (*DNSSECDetails, error) {
-	// TODO: 使用 Cloudflare API 更新 DNSSEC 状态
-	// 目前返回占位符数据
+	result, err := s.API.UpdateZoneDNSSEC(ctx, zoneID, cloudflare.ZoneDNSSECUpdateOptions{
+		Status: status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dnssecDetails(result), nil
+}
+
+// dnssecDetails 转换 SDK 返回的 DNSSEC 详情
+func dnssecDetails(result cloudflare.ZoneDNSSEC) *DNSSECDetails {
 	return &DNSSECDetails{
-		Status:       status,
-		DNSSECPresent: status == "active",
-	}, nil
+		Status:          result.Status,
+		Flags:           result.Flags,
+		Algorithm:       result.Algorithm,
+		KeyType:         result.KeyType,
+		DigestType:      result.DigestType,
+		DigestAlgorithm: result.DigestAlgorithm,
+		Digest:          result.Digest,
+		DS:              result.DS,
+		KeyTag:          result.KeyTag,
+		PublicKey:       result.PublicKey,
+		ModifiedOn:      result.ModifiedOn,
+	}
 }
 
 // GetZoneSettings 获取所有 Zone 设置
@@ -230,6 +297,24 @@ func (s *CloudflareService) GetZoneSettings(ctx context.Context, zoneID string)
 	return settings.Result, nil
 }
 
+// ZoneSettingValues 获取指定设置项的当前值
+func (s *CloudflareService) ZoneSettingValues(ctx context.Context, zoneID string, ids []string) (map[string]interface{}, error) {
+	settings, err := s.GetZoneSettings(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(ids))
+	for _, setting := range settings {
+		for _, id := range ids {
+			if setting.ID == id {
+				values[id] = setting.Value
+			}
+		}
+	}
+	return values, nil
+}
+
 // UpdateZoneSetting 更新单个设置
 func (s *CloudflareService) UpdateZoneSetting(ctx context.Context, zoneID, settingID string, value interface{}) error {
 	_, err := s.API.UpdateZoneSettings(ctx, zoneID, []cloudflare.ZoneSetting{
@@ -238,6 +323,12 @@ func (s *CloudflareService) UpdateZoneSetting(ctx context.Context, zoneID, setti
 	return err
 }
 
+// UpdateZoneSettings 批量更新设置
+func (s *CloudflareService) UpdateZoneSettings(ctx context.Context, zoneID string, settings []cloudflare.ZoneSetting) error {
+	_, err := s.API.UpdateZoneSettings(ctx, zoneID, settings)
+	return err
+}
+
 // PurgeAllCache 清除所有缓存
 func (s *CloudflareService) PurgeAllCache(ctx context.Context, zoneID string) error {
 	_, err := s.API.PurgeEverything(ctx, zoneID)
@@ -397,18 +488,16 @@ func (s *CloudflareService) CreateOriginCertificate(ctx context.Context, hostnam
 	}
 
 	// 步骤3: 调用 Cloudflare API
-	url := "https://api.cloudflare.com/client/v4/certificates"
+	url := strings.TrimSuffix(s.API.BaseURL, "/") + "/certificates"
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payloadBytes))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
-	req.Header.Set("X-Auth-Email", s.Email)
-	req.Header.Set("X-Auth-Key", s.APIKey)
+	s.setAuthHeaders(req)
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := s.HTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("send request: %w", err)
 	}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestService 创建指向本地 HTTP 服务器的 CloudflareService（API Token 为 test-token）
+func newTestService(t *testing.T, handler http.Handler) *CloudflareService {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	s, err := NewCloudflareServiceWithOptions(AuthTypeAPIToken, "", "test-token", HTTPOptions{BaseURL: srv.URL}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// writeAPIResult 按 Cloudflare API 的格式返回 result
+func writeAPIResult(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"errors":   []interface{}{},
+		"messages": []interface{}{},
+		"result":   result,
+	})
+}
+
+func dnssecResult(status string) map[string]interface{} {
+	return map[string]interface{}{
+		"status":           status,
+		"flags":            257,
+		"algorithm":        "13",
+		"key_type":         "ECDSAP256SHA256",
+		"digest_type":      "2",
+		"digest_algorithm": "SHA256",
+		"digest":           "1F987CC6583E92DF0890718C42C8B9DF5EAF5BD6F6E3D6F1B1CF09E8B0B3C6A1",
+		"ds":               "example.com. 3600 IN DS 2371 13 2 1F987CC6583E92DF0890718C42C8B9DF5EAF5BD6F6E3D6F1B1CF09E8B0B3C6A1",
+		"key_tag":          2371,
+		"public_key":       "mdsswUyr3DPW132mOi8V9xESWE8jTo0dxCjjnopKl+GqJxpVXckHAeF+KkxLbxILfDLUT0rAK9iUzy1L53eKGQ==",
+		"modified_on":      "2026-01-02T03:04:05Z",
+	}
+}
+
+func TestGetDNSSEC(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/zones/zone1/dnssec" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		writeAPIResult(w, dnssecResult(DNSSECActive))
+	}))
+
+	d, err := s.GetDNSSEC(context.Background(), "zone1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Enabled() || d.KeyTag != 2371 || d.Flags != 257 || d.DigestType != "2" {
+		t.Fatalf("GetDNSSEC = %+v", d)
+	}
+	if d.AlgorithmName() != "ECDSAP256SHA256" {
+		t.Errorf("AlgorithmName = %q", d.AlgorithmName())
+	}
+	if d.ModifiedOn.IsZero() {
+		t.Error("ModifiedOn was not decoded")
+	}
+}
+
+func TestUpdateDNSSEC(t *testing.T) {
+	var body map[string]interface{}
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/zones/zone1/dnssec" {
+			http.NotFound(w, r)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		writeAPIResult(w, dnssecResult(DNSSECPendingDisabled))
+	}))
+
+	d, err := s.UpdateDNSSEC(context.Background(), "zone1", DNSSECDisabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["status"] != DNSSECDisabled {
+		t.Errorf("request body = %v, want status=disabled", body)
+	}
+	if d.Status != DNSSECPendingDisabled || d.Enabled() {
+		t.Errorf("UpdateDNSSEC = %+v", d)
+	}
+}
+
+func TestGetDNSSECError(t *testing.T) {
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"errors":  []map[string]interface{}{{"code": 9109, "message": "Unauthorized to access requested resource"}},
+		})
+	}))
+
+	if _, err := s.GetDNSSEC(context.Background(), "zone1"); err == nil {
+		t.Fatal("GetDNSSEC should fail on 403")
+	}
+}
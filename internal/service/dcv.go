@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// SSLVerification 单个证书包的域名验证（DCV）状态
+type SSLVerification struct {
+	CertPackID         string                           `json:"cert_pack_uuid"`
+	CertificateStatus  string                           `json:"certificate_status"`
+	ValidationMethod   string                           `json:"validation_method"` // txt / http / cname / email
+	VerificationType   string                           `json:"verification_type"`
+	VerificationStatus bool                             `json:"verification_status"`
+	Hosts              []string                         `json:"hosts,omitempty"`
+	Records            []cloudflare.SSLValidationRecord `json:"records"`
+}
+
+// Pending 是否仍在等待验证
+func (v SSLVerification) Pending() bool {
+	return !v.VerificationStatus && v.CertificateStatus != "active"
+}
+
+// DCVRecord 可以直接在 Zone 中创建的验证记录
+type DCVRecord struct {
+	Type    string `json:"type"` // TXT / CNAME
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// DNSRecords 返回需要在 DNS 中添加的验证记录（HTTP 与邮件验证不需要 DNS 记录）
+func (v SSLVerification) DNSRecords() []DCVRecord {
+	var records []DCVRecord
+	seen := make(map[DCVRecord]bool)
+	for _, r := range v.Records {
+		var rec DCVRecord
+		switch {
+		case r.TxtName != "" && r.TxtValue != "":
+			rec = DCVRecord{Type: "TXT", Name: r.TxtName, Content: r.TxtValue}
+		case r.CnameName != "" && r.CnameTarget != "":
+			rec = DCVRecord{Type: "CNAME", Name: r.CnameName, Content: r.CnameTarget}
+		default:
+			continue
+		}
+		rec.Name = strings.TrimSuffix(rec.Name, ".")
+		rec.Content = strings.TrimSuffix(rec.Content, ".")
+		if !seen[rec] {
+			seen[rec] = true
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// sslVerificationDetails /ssl/verification 返回的单项
+// verification_info 在不同验证方式下可能是对象或数组
+type sslVerificationDetails struct {
+	CertificateStatus  string          `json:"certificate_status"`
+	VerificationType   string          `json:"verification_type"`
+	ValidationMethod   string          `json:"validation_method"`
+	CertPackUUID       string          `json:"cert_pack_uuid"`
+	VerificationStatus bool            `json:"verification_status"`
+	VerificationInfo   json.RawMessage `json:"verification_info"`
+}
+
+// GetSSLVerification 获取 Zone 各证书包的 SSL 域名验证信息
+// 证书包自带的 validation_records 按主机名列出全部记录，优先使用
+func (s *CloudflareService) GetSSLVerification(ctx context.Context, zoneID string) ([]SSLVerification, error) {
+	raw, err := s.API.Raw(ctx, "GET", "/zones/"+zoneID+"/ssl/verification", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch SSL verification: %w", err)
+	}
+
+	var details []sslVerificationDetails
+	if err := json.Unmarshal(raw.Result, &details); err != nil {
+		return nil, fmt.Errorf("unmarshal SSL verification: %w", err)
+	}
+
+	// 证书包列表失败时仍返回 verification_info 中的记录
+	packs := make(map[string]cloudflare.CertificatePack)
+	if list, err := s.ListEdgeCertificates(ctx, zoneID); err == nil {
+		for _, pack := range list {
+			packs[pack.ID] = pack
+		}
+	}
+
+	verifications := make([]SSLVerification, 0, len(details))
+	for _, d := range details {
+		v := SSLVerification{
+			CertPackID:         d.CertPackUUID,
+			CertificateStatus:  d.CertificateStatus,
+			ValidationMethod:   d.ValidationMethod,
+			VerificationType:   d.VerificationType,
+			VerificationStatus: d.VerificationStatus,
+		}
+		if pack, ok := packs[d.CertPackUUID]; ok {
+			v.Hosts = pack.Hosts
+			v.Records = pack.ValidationRecords
+		}
+		if len(v.Records) == 0 {
+			v.Records = decodeValidationInfo(d.VerificationInfo)
+		}
+		verifications = append(verifications, v)
+	}
+	return verifications, nil
+}
+
+// decodeValidationInfo 解析对象或数组形式的 verification_info
+func decodeValidationInfo(raw json.RawMessage) []cloudflare.SSLValidationRecord {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if raw[0] == '[' {
+		var records []cloudflare.SSLValidationRecord
+		if err := json.Unmarshal(raw, &records); err == nil {
+			return records
+		}
+		return nil
+	}
+	var record cloudflare.SSLValidationRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil
+	}
+	return []cloudflare.SSLValidationRecord{record}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// 委派状态，按严重程度从高到低
+const (
+	DelegationUnregistered = "unregistered"  // 上级域名返回 NXDOMAIN，域名未注册或已过期
+	DelegationNotDelegated = "not_delegated" // 上级 NS 中没有任何 Cloudflare 分配的 NS
+	DelegationDSMismatch   = "ds_mismatch"   // DS 与 DNSSEC 状态不一致，验证解析器会返回 SERVFAIL
+	DelegationLame         = "lame"          // 有 NS 无法给出权威应答
+	DelegationPartial      = "partial"       // 委派了分配的 NS，但缺少部分或残留旧服务商 NS
+	DelegationOK           = "ok"
+)
+
+// authPort 查询上级和权威服务器使用的端口
+var authPort = "53"
+
+// DelegationReport Zone 在上级域名中的委派情况
+type DelegationReport struct {
+	Zone         string
+	Parent       string   // 上级域名（如 com、co.uk）
+	ParentServer string   // 实际查询的上级权威服务器
+	Assigned     []string // Cloudflare 分配的 NS
+	Delegated    []string // 上级域名中的 NS
+	Missing      []string // 分配了但未出现在上级中的 NS
+	Extra        []string // 上级中有但不属于分配的 NS（通常是旧服务商残留）
+	Lame         []string // 无法给出权威应答的 NS
+	DS           []string // 上级中的 DS 记录（key_tag algorithm digest_type digest）
+	DNSSEC       string   // Cloudflare DNSSEC 状态
+	Status       string
+	Problems     []string
+}
+
+// CheckDelegation 从上级域名的权威服务器查询 Zone 的 NS 和 DS 委派，
+// 与 Cloudflare 分配的 NS 和 DNSSEC 设置比较，并逐个检查 NS 是否为跛脚委派
+func CheckDelegation(ctx context.Context, resolver *DNSResolver, zone string, assigned []string, dnssec *DNSSECDetails) (*DelegationReport, error) {
+	zone = normalizeHost(zone)
+	report := &DelegationReport{Zone: zone}
+	for _, ns := range assigned {
+		report.Assigned = append(report.Assigned, normalizeHost(ns))
+	}
+	sort.Strings(report.Assigned)
+	if dnssec != nil {
+		report.DNSSEC = dnssec.Status
+	}
+
+	parent, parentServers, err := findParent(ctx, resolver, zone)
+	if err != nil {
+		return nil, err
+	}
+	report.Parent = parent
+
+	// 依次询问上级权威服务器，直到拿到明确应答
+	var referral *dns.Msg
+	for _, server := range parentServers {
+		resp, _, err := QueryServer(ctx, server, zone, dns.TypeNS, resolver.Timeout, false)
+		if err != nil || (resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError) {
+			continue
+		}
+		referral = resp
+		report.ParentServer = server
+		break
+	}
+	if referral == nil {
+		return nil, fmt.Errorf("no authoritative server of %s answered", parent)
+	}
+	if referral.Rcode == dns.RcodeNameError {
+		report.Status = DelegationUnregistered
+		report.Problems = append(report.Problems, fmt.Sprintf("%s 的权威服务器返回 NXDOMAIN，域名未注册或已过期", parent))
+		return report, nil
+	}
+
+	// 委派通常在 Authority 段；上级与子域由同一服务器托管时在 Answer 段
+	seen := map[string]bool{}
+	for _, rr := range append(referral.Answer, referral.Ns...) {
+		if ns, ok := rr.(*dns.NS); ok && normalizeHost(ns.Hdr.Name) == zone {
+			host := normalizeHost(ns.Ns)
+			if !seen[host] {
+				seen[host] = true
+				report.Delegated = append(report.Delegated, host)
+			}
+		}
+	}
+	sort.Strings(report.Delegated)
+	report.Missing = subtract(report.Assigned, report.Delegated)
+	report.Extra = subtract(report.Delegated, report.Assigned)
+
+	if resp, _, err := QueryServer(ctx, report.ParentServer, zone, dns.TypeDS, resolver.Timeout, false); err == nil {
+		for _, rr := range resp.Answer {
+			if ds, ok := rr.(*dns.DS); ok {
+				report.DS = append(report.DS, fmt.Sprintf("%d %d %d %s", ds.KeyTag, ds.Algorithm, ds.DigestType, strings.ToUpper(ds.Digest)))
+			}
+		}
+	}
+
+	report.Lame = lameServers(ctx, resolver, zone, report.Delegated)
+
+	report.evaluate(dnssec)
+	return report, nil
+}
+
+// evaluate 根据检查结果给出状态和问题说明
+func (r *DelegationReport) evaluate(dnssec *DNSSECDetails) {
+	var statuses []string
+
+	if len(r.Delegated) == 0 {
+		statuses = append(statuses, DelegationNotDelegated)
+		r.Problems = append(r.Problems, fmt.Sprintf("%s 中没有 %s 的 NS 记录，请在注册商处设置 NS", r.Parent, r.Zone))
+	} else if len(r.Missing) == len(r.Assigned) {
+		statuses = append(statuses, DelegationNotDelegated)
+		r.Problems = append(r.Problems, "注册商处的 NS 不是 Cloudflare 分配的 NS："+strings.Join(r.Delegated, ", "))
+	} else {
+		if len(r.Missing) > 0 {
+			statuses = append(statuses, DelegationPartial)
+			r.Problems = append(r.Problems, "注册商处缺少 Cloudflare 分配的 NS："+strings.Join(r.Missing, ", "))
+		}
+		if len(r.Extra) > 0 {
+			statuses = append(statuses, DelegationPartial)
+			r.Problems = append(r.Problems, "注册商处残留其他 NS（可能是旧服务商）："+strings.Join(r.Extra, ", "))
+		}
+	}
+
+	if len(r.Lame) > 0 {
+		statuses = append(statuses, DelegationLame)
+		r.Problems = append(r.Problems, "以下 NS 无法给出权威应答（跛脚委派）："+strings.Join(r.Lame, ", "))
+	}
+
+	enabled := dnssec != nil && dnssec.Enabled()
+	switch {
+	case dnssec == nil:
+		// DNSSEC 状态获取失败时不做比较
+	case len(r.DS) > 0 && !enabled:
+		statuses = append(statuses, DelegationDSMismatch)
+		r.Problems = append(r.Problems, "注册商处仍有 DS 记录，但 Cloudflare 未启用 DNSSEC，验证解析器将无法解析该域名")
+	case len(r.DS) == 0 && enabled:
+		if dnssec.Status == DNSSECActive {
+			statuses = append(statuses, DelegationDSMismatch)
+		}
+		r.Problems = append(r.Problems, "Cloudflare 已启用 DNSSEC，但注册商处尚未添加 DS 记录")
+	case len(r.DS) > 0 && !dsMatches(r.DS, dnssec):
+		statuses = append(statuses, DelegationDSMismatch)
+		r.Problems = append(r.Problems, "注册商处的 DS 记录与 Cloudflare 的 DNSSEC 密钥不匹配")
+	}
+
+	r.Status = DelegationOK
+	for _, status := range []string{DelegationNotDelegated, DelegationDSMismatch, DelegationLame, DelegationPartial} {
+		if containsString(statuses, status) {
+			r.Status = status
+			break
+		}
+	}
+}
+
+// findParent 向上逐级查找存在 NS 的上级域名，返回域名和其权威服务器地址
+func findParent(ctx context.Context, resolver *DNSResolver, zone string) (string, []string, error) {
+	labels := dns.SplitDomainName(zone)
+	if len(labels) < 2 {
+		return "", nil, fmt.Errorf("invalid zone name: %s", zone)
+	}
+	for i := 1; i < len(labels); i++ {
+		parent := strings.Join(labels[i:], ".")
+		resp, err := resolver.Exchange(ctx, parent, dns.TypeNS)
+		if err != nil {
+			return "", nil, err
+		}
+		var hosts []string
+		for _, rr := range resp.Answer {
+			if ns, ok := rr.(*dns.NS); ok {
+				hosts = append(hosts, normalizeHost(ns.Ns))
+			}
+		}
+		if len(hosts) == 0 {
+			continue
+		}
+		sort.Strings(hosts)
+
+		var servers []string
+		for _, host := range hosts {
+			addrs, err := resolver.LookupHost(ctx, host)
+			if err != nil {
+				continue
+			}
+			servers = append(servers, net.JoinHostPort(addrs[0], authPort))
+		}
+		if len(servers) == 0 {
+			return "", nil, fmt.Errorf("cannot resolve name servers of %s", parent)
+		}
+		return parent, servers, nil
+	}
+	return "", nil, fmt.Errorf("no parent zone found for %s", zone)
+}
+
+// lameServers 向每个 NS 查询 Zone 的 SOA，无应答、非权威应答或返回错误码的视为跛脚
+func lameServers(ctx context.Context, resolver *DNSResolver, zone string, nameServers []string) []string {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		lame []string
+	)
+	for _, ns := range nameServers {
+		wg.Add(1)
+		go func(ns string) {
+			defer wg.Done()
+			if !authoritativeFor(ctx, resolver, zone, ns) {
+				mu.Lock()
+				lame = append(lame, ns)
+				mu.Unlock()
+			}
+		}(ns)
+	}
+	wg.Wait()
+	sort.Strings(lame)
+	return lame
+}
+
+func authoritativeFor(ctx context.Context, resolver *DNSResolver, zone, ns string) bool {
+	addrs, err := resolver.LookupHost(ctx, ns)
+	if err != nil {
+		return false
+	}
+	resp, _, err := QueryServer(ctx, net.JoinHostPort(addrs[0], authPort), zone, dns.TypeSOA, resolver.Timeout, false)
+	return err == nil && resp.Authoritative && resp.Rcode == dns.RcodeSuccess
+}
+
+// dsMatches 上级中是否有一条 DS 与 Cloudflare 的密钥一致
+func dsMatches(records []string, dnssec *DNSSECDetails) bool {
+	keyTag := strconv.Itoa(dnssec.KeyTag)
+	digest := strings.ToUpper(strings.ReplaceAll(dnssec.Digest, " ", ""))
+	for _, ds := range records {
+		fields := strings.Fields(ds)
+		if len(fields) == 4 && fields[0] == keyTag && (digest == "" || fields[3] == digest) {
+			return true
+		}
+	}
+	return false
+}
+
+// subtract 返回 a 中不在 b 里的元素
+func subtract(a, b []string) []string {
+	var out []string
+	for _, s := range a {
+		if !containsString(b, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
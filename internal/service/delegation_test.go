@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// serveDNS 在 127.0.0.1 的随机端口上启动一个 DNS 服务器，按 records 应答，返回监听地址
+// 已知名称没有对应类型的记录时返回空应答，未知名称返回 NXDOMAIN；有应答时标记为权威
+func serveDNS(t *testing.T, records ...string) string {
+	t.Helper()
+	byName := map[string][]dns.RR{}
+	for _, s := range records {
+		rr, err := dns.NewRR(s)
+		if err != nil {
+			t.Fatalf("bad record %q: %v", s, err)
+		}
+		name := strings.ToLower(rr.Header().Name)
+		byName[name] = append(byName[name], rr)
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        pc,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+			resp := new(dns.Msg)
+			resp.SetReply(req)
+			q := req.Question[0]
+			rrs, ok := byName[strings.ToLower(q.Name)]
+			if !ok {
+				resp.Rcode = dns.RcodeNameError
+			}
+			for _, rr := range rrs {
+				if rr.Header().Rrtype == q.Qtype {
+					resp.Answer = append(resp.Answer, rr)
+				}
+			}
+			resp.Authoritative = len(resp.Answer) > 0
+			w.WriteMsg(resp)
+		}),
+	}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+// startDNSServer 启动测试 DNS 服务器，并把 authPort 指向该端口，使上级和权威查询也发往这个服务器
+func startDNSServer(t *testing.T, records ...string) *DNSResolver {
+	t.Helper()
+	addr := serveDNS(t, records...)
+
+	_, port, _ := net.SplitHostPort(addr)
+	saved := authPort
+	authPort = port
+	t.Cleanup(func() { authPort = saved })
+
+	resolver := NewDNSResolver(addr)
+	resolver.Timeout = time.Second
+	return resolver
+}
+
+// delegationRecords 上级 test 由 ns.nic.test 托管，所有名称服务器都指向测试服务器
+var delegationRecords = []string{
+	"test. 300 IN NS ns.nic.test.",
+	"ns.nic.test. 300 IN A 127.0.0.1",
+	"ns1.cf.test. 300 IN A 127.0.0.1",
+	"ns2.cf.test. 300 IN A 127.0.0.1",
+	"old.dns.test. 300 IN A 127.0.0.1",
+
+	"ok.test. 300 IN NS ns1.cf.test.",
+	"ok.test. 300 IN NS ns2.cf.test.",
+	"ok.test. 300 IN SOA ns1.cf.test. dns.cf.test. 1 10000 2400 604800 300",
+
+	"partial.test. 300 IN NS ns1.cf.test.",
+	"partial.test. 300 IN NS old.dns.test.",
+	"partial.test. 300 IN SOA ns1.cf.test. dns.cf.test. 1 10000 2400 604800 300",
+
+	"elsewhere.test. 300 IN NS old.dns.test.",
+	"elsewhere.test. 300 IN SOA old.dns.test. hostmaster.dns.test. 1 10000 2400 604800 300",
+
+	"nons.test. 300 IN A 192.0.2.1",
+
+	"lame.test. 300 IN NS ns1.cf.test.",
+	"lame.test. 300 IN NS ns2.cf.test.",
+
+	"signed.test. 300 IN NS ns1.cf.test.",
+	"signed.test. 300 IN NS ns2.cf.test.",
+	"signed.test. 300 IN SOA ns1.cf.test. dns.cf.test. 1 10000 2400 604800 300",
+	"signed.test. 300 IN DS 2371 13 2 1F987CC6583E92DF0890718C42C8B9DF5EAF5BD6F6E3D6F1B1CF09E8B0B3C6A1",
+}
+
+func TestCheckDelegation(t *testing.T) {
+	resolver := startDNSServer(t, delegationRecords...)
+	assigned := []string{"NS2.cf.test", "ns1.cf.test."}
+
+	tests := []struct {
+		zone    string
+		dnssec  *DNSSECDetails
+		status  string
+		missing []string
+		extra   []string
+	}{
+		{zone: "ok.test", status: DelegationOK},
+		{zone: "partial.test", status: DelegationPartial, missing: []string{"ns2.cf.test"}, extra: []string{"old.dns.test"}},
+		{zone: "elsewhere.test", status: DelegationNotDelegated},
+		{zone: "nons.test", status: DelegationNotDelegated},
+		{zone: "gone.test", status: DelegationUnregistered},
+		{zone: "lame.test", status: DelegationLame},
+		{zone: "signed.test", dnssec: &DNSSECDetails{Status: DNSSECActive, KeyTag: 2371, Digest: "1f987cc6583e92df0890718c42c8b9df5eaf5bd6f6e3d6f1b1cf09e8b0b3c6a1"}, status: DelegationOK},
+		{zone: "signed.test", dnssec: &DNSSECDetails{Status: DNSSECActive, KeyTag: 1}, status: DelegationDSMismatch},
+		{zone: "signed.test", dnssec: &DNSSECDetails{Status: "disabled"}, status: DelegationDSMismatch},
+		{zone: "ok.test", dnssec: &DNSSECDetails{Status: DNSSECActive, KeyTag: 2371}, status: DelegationDSMismatch},
+	}
+	for _, tt := range tests {
+		report, err := CheckDelegation(context.Background(), resolver, tt.zone, assigned, tt.dnssec)
+		if err != nil {
+			t.Errorf("CheckDelegation(%s): %v", tt.zone, err)
+			continue
+		}
+		if report.Status != tt.status {
+			t.Errorf("CheckDelegation(%s) status = %s, want %s (problems: %v)", tt.zone, report.Status, tt.status, report.Problems)
+		}
+		if report.Parent != "test" {
+			t.Errorf("CheckDelegation(%s) parent = %q, want test", tt.zone, report.Parent)
+		}
+		if tt.missing != nil && strings.Join(report.Missing, ",") != strings.Join(tt.missing, ",") {
+			t.Errorf("CheckDelegation(%s) missing = %v, want %v", tt.zone, report.Missing, tt.missing)
+		}
+		if tt.extra != nil && strings.Join(report.Extra, ",") != strings.Join(tt.extra, ",") {
+			t.Errorf("CheckDelegation(%s) extra = %v, want %v", tt.zone, report.Extra, tt.extra)
+		}
+	}
+}
+
+func TestCheckDelegationNoParent(t *testing.T) {
+	resolver := startDNSServer(t, "ns.nic.test. 300 IN A 127.0.0.1")
+	if _, err := CheckDelegation(context.Background(), resolver, "example.invalid", nil, nil); err == nil {
+		t.Fatal("CheckDelegation should fail when no parent zone has NS records")
+	}
+}
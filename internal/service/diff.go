@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// 变更动作
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// RecordChange 单条记录变更
+type RecordChange struct {
+	Action  string
+	Current *cloudflare.DNSRecord // 现有记录（create 时为空）
+	Desired *cloudflare.DNSRecord // 目标记录（delete 时为空）
+}
+
+// Record 返回用于展示的记录（优先目标记录）
+func (c RecordChange) Record() *cloudflare.DNSRecord {
+	if c.Desired != nil {
+		return c.Desired
+	}
+	return c.Current
+}
+
+// ChangePlan 变更计划
+type ChangePlan struct {
+	Creates   []RecordChange
+	Updates   []RecordChange
+	Deletes   []RecordChange
+	Unchanged int
+}
+
+// Empty 是否没有任何变更
+func (p *ChangePlan) Empty() bool {
+	return len(p.Creates) == 0 && len(p.Updates) == 0 && len(p.Deletes) == 0
+}
+
+// Changes 按 删除 → 更新 → 创建 的顺序返回全部变更
+// 先删除可以避免 CNAME 与其他记录冲突
+func (p *ChangePlan) Changes() []RecordChange {
+	changes := make([]RecordChange, 0, len(p.Creates)+len(p.Updates)+len(p.Deletes))
+	changes = append(changes, p.Deletes...)
+	changes = append(changes, p.Updates...)
+	changes = append(changes, p.Creates...)
+	return changes
+}
+
+// ChangeResult 变更执行结果
+type ChangeResult struct {
+	Change RecordChange
+	Err    error
+}
+
+// DiffRecords 对比现有记录与目标记录，生成变更计划
+// 记录按 (类型, 名称) 分组，组内内容相同的视为同一条记录；
+// prune 为 true 时删除目标中不存在的现有记录
+func DiffRecords(current, desired []cloudflare.DNSRecord, prune bool) *ChangePlan {
+	plan := &ChangePlan{}
+
+	currentGroups := groupRecords(current)
+	desiredGroups := groupRecords(desired)
+
+	keys := make(map[string]struct{})
+	for key := range currentGroups {
+		keys[key] = struct{}{}
+	}
+	for key := range desiredGroups {
+		keys[key] = struct{}{}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, key := range sortedKeys {
+		cur := currentGroups[key]
+		want := desiredGroups[key]
+
+		// 第一轮：内容完全相同的记录配对
+		matched := make([]bool, len(cur))
+		var unmatchedWant []cloudflare.DNSRecord
+		for i := range want {
+			found := -1
+			for j := range cur {
+				if !matched[j] && RecordContentKey(cur[j]) == RecordContentKey(want[i]) {
+					found = j
+					break
+				}
+			}
+			if found < 0 {
+				unmatchedWant = append(unmatchedWant, want[i])
+				continue
+			}
+			matched[found] = true
+			if recordAttrsEqual(cur[found], want[i]) {
+				plan.Unchanged++
+				continue
+			}
+			plan.Updates = append(plan.Updates, RecordChange{
+				Action:  ChangeUpdate,
+				Current: &cur[found],
+				Desired: mergeDesired(cur[found], want[i]),
+			})
+		}
+
+		var unmatchedCur []*cloudflare.DNSRecord
+		for j := range cur {
+			if !matched[j] {
+				unmatchedCur = append(unmatchedCur, &cur[j])
+			}
+		}
+
+		// 第二轮：剩余记录按顺序配对为更新
+		// 仅在允许修改现有记录，或该类型同名只能存在一条时（CNAME）
+		for i := range unmatchedWant {
+			if (prune || unmatchedWant[i].Type == "CNAME") && len(unmatchedCur) > 0 {
+				existing := unmatchedCur[0]
+				unmatchedCur = unmatchedCur[1:]
+				plan.Updates = append(plan.Updates, RecordChange{
+					Action:  ChangeUpdate,
+					Current: existing,
+					Desired: mergeDesired(*existing, unmatchedWant[i]),
+				})
+				continue
+			}
+			rec := unmatchedWant[i]
+			plan.Creates = append(plan.Creates, RecordChange{
+				Action:  ChangeCreate,
+				Desired: &rec,
+			})
+		}
+
+		if prune {
+			for _, existing := range unmatchedCur {
+				plan.Deletes = append(plan.Deletes, RecordChange{
+					Action:  ChangeDelete,
+					Current: existing,
+				})
+			}
+		}
+	}
+
+	return plan
+}
+
+// ApplyPlan 执行变更计划，单条失败不会中断后续变更
+func ApplyPlan(ctx context.Context, s Client, zoneID string, plan *ChangePlan) []ChangeResult {
+	rc := cloudflare.ZoneIdentifier(zoneID)
+
+	var results []ChangeResult
+	for _, change := range plan.Changes() {
+		var err error
+		switch change.Action {
+		case ChangeDelete:
+			err = s.DeleteDNSRecord(ctx, rc, change.Current.ID)
+		case ChangeUpdate:
+			_, err = s.UpdateDNSRecord(ctx, rc, UpdateParamsFromRecord(change.Current.ID, *change.Desired))
+		case ChangeCreate:
+			_, err = s.CreateDNSRecord(ctx, rc, CreateParamsFromRecord(*change.Desired))
+		}
+		results = append(results, ChangeResult{Change: change, Err: err})
+	}
+
+	return results
+}
+
+// CreateParamsFromRecord 由记录构造创建参数
+func CreateParamsFromRecord(record cloudflare.DNSRecord) cloudflare.CreateDNSRecordParams {
+	return cloudflare.CreateDNSRecordParams{
+		Type:     record.Type,
+		Name:     record.Name,
+		Content:  record.Content,
+		Data:     record.Data,
+		TTL:      record.TTL,
+		Priority: record.Priority,
+		Proxied:  record.Proxied,
+		Comment:  record.Comment,
+		Tags:     record.Tags,
+	}
+}
+
+// UpdateParamsFromRecord 由记录构造更新参数
+func UpdateParamsFromRecord(recordID string, record cloudflare.DNSRecord) cloudflare.UpdateDNSRecordParams {
+	comment := record.Comment
+	return cloudflare.UpdateDNSRecordParams{
+		ID:       recordID,
+		Type:     record.Type,
+		Name:     record.Name,
+		Content:  record.Content,
+		Data:     record.Data,
+		TTL:      record.TTL,
+		Priority: record.Priority,
+		Proxied:  record.Proxied,
+		Comment:  &comment,
+		Tags:     record.Tags,
+	}
+}
+
+// RecordContentKey 返回记录内容的规范化表示，用于判断两条记录是否相同
+func RecordContentKey(record cloudflare.DNSRecord) string {
+	if record.Type == "TXT" {
+		return strings.Join(SplitTXT(record.Content), "")
+	}
+
+	rr, err := RecordToRR(record)
+	if err != nil {
+		return strings.ToLower(strings.TrimSuffix(record.Content, "."))
+	}
+	rdata := strings.TrimPrefix(rr.String(), rr.Header().String())
+	return strings.ToLower(rdata)
+}
+
+// groupRecords 按 (类型, 名称) 分组
+func groupRecords(records []cloudflare.DNSRecord) map[string][]cloudflare.DNSRecord {
+	groups := make(map[string][]cloudflare.DNSRecord)
+	for _, record := range records {
+		key := record.Type + " " + normalizeName(record.Name)
+		groups[key] = append(groups[key], record)
+	}
+	return groups
+}
+
+// recordAttrsEqual 比较内容以外的属性（TTL、代理状态、备注）
+// 目标记录中未设置的属性视为"保持不变"
+func recordAttrsEqual(current, desired cloudflare.DNSRecord) bool {
+	curProxied := current.Proxied != nil && *current.Proxied
+	if desired.Proxied != nil && *desired.Proxied != curProxied {
+		return false
+	}
+
+	// 代理记录的 TTL 固定为自动，不参与比较
+	if !curProxied && desired.TTL != 0 && effectiveTTL(current.TTL) != effectiveTTL(desired.TTL) {
+		return false
+	}
+
+	if desired.Comment != "" && desired.Comment != current.Comment {
+		return false
+	}
+
+	return true
+}
+
+// mergeDesired 以现有记录为基础合并目标记录中设置的属性
+func mergeDesired(current, desired cloudflare.DNSRecord) *cloudflare.DNSRecord {
+	merged := desired
+	merged.ID = current.ID
+	if merged.TTL == 0 {
+		merged.TTL = current.TTL
+	}
+	if merged.Proxied == nil && current.Proxied != nil && current.Proxiable {
+		merged.Proxied = current.Proxied
+	}
+	if merged.Comment == "" {
+		merged.Comment = current.Comment
+	}
+	if merged.Tags == nil {
+		merged.Tags = current.Tags
+	}
+	return &merged
+}
+
+// effectiveTTL 将 "自动" TTL 统一为实际值
+func effectiveTTL(ttl int) int {
+	if ttl <= 1 {
+		return autoTTL
+	}
+	return ttl
+}
+
+// normalizeName 规范化记录名称（小写、去掉末尾的点）
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
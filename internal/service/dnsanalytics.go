@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// DNSAnalyticsMaxRows 单次报表返回的最大行数
+const DNSAnalyticsMaxRows = 10000
+
+// DNSQueryStat 按查询名称、类型和响应码统计的查询数
+type DNSQueryStat struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	ResponseCode string `json:"response_code"`
+	Queries      int64  `json:"queries"`
+}
+
+// DNSCount 单一维度的查询数
+type DNSCount struct {
+	Key     string `json:"key"`
+	Queries int64  `json:"queries"`
+}
+
+// DNSAnalyticsReport DNS 查询统计报表
+type DNSAnalyticsReport struct {
+	Since     time.Time      `json:"since"`
+	Until     time.Time      `json:"until"`
+	Total     int64          `json:"total"`
+	Rows      []DNSQueryStat `json:"rows"`      // 按查询数降序
+	Truncated bool           `json:"truncated"` // 行数达到上限，低查询量的组合可能缺失
+}
+
+// GetDNSAnalytics 通过 DNS Analytics 报表接口获取查询统计
+func (s *CloudflareService) GetDNSAnalytics(ctx context.Context, zoneID string, since, until time.Time) (*DNSAnalyticsReport, error) {
+	q := url.Values{}
+	q.Set("dimensions", "queryName,queryType,responseCode")
+	q.Set("metrics", "queryCount")
+	q.Set("sort", "-queryCount")
+	q.Set("limit", strconv.Itoa(DNSAnalyticsMaxRows))
+	q.Set("since", since.UTC().Format(time.RFC3339))
+	q.Set("until", until.UTC().Format(time.RFC3339))
+
+	raw, err := s.API.Raw(ctx, "GET", "/zones/"+zoneID+"/dns_analytics/report?"+q.Encode(), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch DNS analytics: %w", err)
+	}
+
+	var result struct {
+		Rows int `json:"rows"`
+		Data []struct {
+			Dimensions []string  `json:"dimensions"`
+			Metrics    []float64 `json:"metrics"`
+		} `json:"data"`
+		Totals struct {
+			QueryCount float64 `json:"queryCount"`
+		} `json:"totals"`
+	}
+	if err := json.Unmarshal(raw.Result, &result); err != nil {
+		return nil, fmt.Errorf("unmarshal DNS analytics: %w", err)
+	}
+
+	report := &DNSAnalyticsReport{
+		Since:     since,
+		Until:     until,
+		Total:     int64(result.Totals.QueryCount),
+		Truncated: len(result.Data) >= DNSAnalyticsMaxRows,
+	}
+	for _, row := range result.Data {
+		if len(row.Dimensions) < 3 || len(row.Metrics) < 1 {
+			continue
+		}
+		report.Rows = append(report.Rows, DNSQueryStat{
+			Name:         normalizeQueryName(row.Dimensions[0]),
+			Type:         row.Dimensions[1],
+			ResponseCode: row.Dimensions[2],
+			Queries:      int64(row.Metrics[0]),
+		})
+	}
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		return report.Rows[i].Queries > report.Rows[j].Queries
+	})
+	return report, nil
+}
+
+// ByName 按查询名称和类型汇总（忽略响应码）
+func (r *DNSAnalyticsReport) ByName() []DNSQueryStat {
+	index := make(map[string]int)
+	var stats []DNSQueryStat
+	for _, row := range r.Rows {
+		key := row.Name + " " + row.Type
+		if i, ok := index[key]; ok {
+			stats[i].Queries += row.Queries
+			continue
+		}
+		index[key] = len(stats)
+		stats = append(stats, DNSQueryStat{Name: row.Name, Type: row.Type, Queries: row.Queries})
+	}
+	sortQueryStats(stats)
+	return stats
+}
+
+// ByType 按查询类型汇总
+func (r *DNSAnalyticsReport) ByType() []DNSCount {
+	return r.countBy(func(row DNSQueryStat) string { return row.Type })
+}
+
+// ByResponseCode 按响应码汇总
+func (r *DNSAnalyticsReport) ByResponseCode() []DNSCount {
+	return r.countBy(func(row DNSQueryStat) string { return row.ResponseCode })
+}
+
+// NXDomain 返回 NXDOMAIN 的查询名称（按名称和类型汇总）
+func (r *DNSAnalyticsReport) NXDomain() []DNSQueryStat {
+	index := make(map[string]int)
+	var stats []DNSQueryStat
+	for _, row := range r.Rows {
+		if row.ResponseCode != "NXDOMAIN" {
+			continue
+		}
+		key := row.Name + " " + row.Type
+		if i, ok := index[key]; ok {
+			stats[i].Queries += row.Queries
+			continue
+		}
+		index[key] = len(stats)
+		stats = append(stats, row)
+	}
+	sortQueryStats(stats)
+	return stats
+}
+
+// countBy 按指定维度汇总
+func (r *DNSAnalyticsReport) countBy(key func(DNSQueryStat) string) []DNSCount {
+	totals := make(map[string]int64)
+	for _, row := range r.Rows {
+		totals[key(row)] += row.Queries
+	}
+	counts := make([]DNSCount, 0, len(totals))
+	for k, v := range totals {
+		counts = append(counts, DNSCount{Key: k, Queries: v})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Queries != counts[j].Queries {
+			return counts[i].Queries > counts[j].Queries
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	return counts
+}
+
+// UnqueriedRecords 返回报表时间范围内没有任何查询的记录，作为清理候选
+// CNAME 记录按名称匹配任意查询类型；通配符记录无法从查询名称判断，不参与统计
+func (r *DNSAnalyticsReport) UnqueriedRecords(records []cloudflare.DNSRecord) []cloudflare.DNSRecord {
+	names := make(map[string]bool)
+	nameTypes := make(map[string]bool)
+	for _, row := range r.Rows {
+		if row.Queries == 0 {
+			continue
+		}
+		names[row.Name] = true
+		nameTypes[row.Name+" "+row.Type] = true
+	}
+
+	var unqueried []cloudflare.DNSRecord
+	for _, record := range records {
+		name := normalizeQueryName(record.Name)
+		if strings.HasPrefix(name, "*.") {
+			continue
+		}
+		if record.Type == "CNAME" {
+			if !names[name] {
+				unqueried = append(unqueried, record)
+			}
+			continue
+		}
+		if !nameTypes[name+" "+record.Type] {
+			unqueried = append(unqueried, record)
+		}
+	}
+	return unqueried
+}
+
+// sortQueryStats 按查询数降序排列
+func sortQueryStats(stats []DNSQueryStat) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Queries > stats[j].Queries
+	})
+}
+
+// normalizeQueryName 统一为小写且不带末尾的点
+func normalizeQueryName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
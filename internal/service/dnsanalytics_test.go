@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func TestGetDNSAnalytics(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "dns_analytics_report.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var query map[string][]string
+	s := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/zones/zone1/dns_analytics/report" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+
+	until := time.Date(2026, 3, 10, 23, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	since := until.AddDate(0, 0, -7)
+	report, err := s.GetDNSAnalytics(context.Background(), "zone1", since, until)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantQuery := map[string][]string{
+		"dimensions": {"queryName,queryType,responseCode"},
+		"metrics":    {"queryCount"},
+		"sort":       {"-queryCount"},
+		"limit":      {"10000"},
+		"since":      {"2026-03-03T15:30:00Z"},
+		"until":      {"2026-03-10T15:30:00Z"},
+	}
+	if !reflect.DeepEqual(query, wantQuery) {
+		t.Errorf("query = %v, want %v", query, wantQuery)
+	}
+
+	// 维度不完整的行被跳过，名称统一为小写且不带末尾的点
+	if report.Total != 2177 || len(report.Rows) != 9 || report.Truncated {
+		t.Fatalf("report = total %d, %d rows, truncated %v", report.Total, len(report.Rows), report.Truncated)
+	}
+	if first := report.Rows[0]; first != (DNSQueryStat{"www.example.com", "A", "NOERROR", 1200}) {
+		t.Errorf("first row = %+v, want the most queried", first)
+	}
+
+	wantByName := []DNSQueryStat{
+		{Name: "www.example.com", Type: "A", Queries: 1205},
+		{Name: "example.com", Type: "MX", Queries: 450},
+		{Name: "www.example.com", Type: "AAAA", Queries: 300},
+		{Name: "old.example.com", Type: "A", Queries: 100},
+		{Name: "blog.example.com", Type: "AAAA", Queries: 60},
+		{Name: "example.com", Type: "TXT", Queries: 30},
+		{Name: "typo.example.com", Type: "A", Queries: 25},
+	}
+	if got := report.ByName(); !reflect.DeepEqual(got, wantByName) {
+		t.Errorf("ByName = %+v\nwant %+v", got, wantByName)
+	}
+
+	wantByType := []DNSCount{{"A", 1330}, {"MX", 450}, {"AAAA", 360}, {"TXT", 30}}
+	if got := report.ByType(); !reflect.DeepEqual(got, wantByType) {
+		t.Errorf("ByType = %+v, want %+v", got, wantByType)
+	}
+	wantByCode := []DNSCount{{"NOERROR", 2040}, {"NXDOMAIN", 125}, {"SERVFAIL", 5}}
+	if got := report.ByResponseCode(); !reflect.DeepEqual(got, wantByCode) {
+		t.Errorf("ByResponseCode = %+v, want %+v", got, wantByCode)
+	}
+	wantNX := []DNSQueryStat{
+		{Name: "old.example.com", Type: "A", ResponseCode: "NXDOMAIN", Queries: 100},
+		{Name: "typo.example.com", Type: "A", ResponseCode: "NXDOMAIN", Queries: 25},
+	}
+	if got := report.NXDomain(); !reflect.DeepEqual(got, wantNX) {
+		t.Errorf("NXDomain = %+v, want %+v", got, wantNX)
+	}
+
+	// CNAME 按名称匹配任意查询类型，通配符记录不参与统计
+	records := []cloudflare.DNSRecord{
+		{ID: "www-a", Type: "A", Name: "www.example.com"},
+		{ID: "www-aaaa", Type: "AAAA", Name: "www.example.com"},
+		{ID: "mx", Type: "MX", Name: "example.com"},
+		{ID: "txt", Type: "TXT", Name: "example.com"},
+		{ID: "blog", Type: "CNAME", Name: "blog.example.com"},
+		{ID: "ftp", Type: "A", Name: "ftp.example.com"},
+		{ID: "caa", Type: "CAA", Name: "example.com"},
+		{ID: "wildcard", Type: "A", Name: "*.example.com"},
+		{ID: "shop", Type: "CNAME", Name: "shop.example.com"},
+	}
+	var unqueried []string
+	for _, r := range report.UnqueriedRecords(records) {
+		unqueried = append(unqueried, r.ID)
+	}
+	if want := []string{"ftp", "caa", "shop"}; !reflect.DeepEqual(unqueried, want) {
+		t.Errorf("UnqueriedRecords = %v, want %v", unqueried, want)
+	}
+}
@@ -0,0 +1,739 @@
+package service
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/i18n"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/validation"
+)
+
+// 问题级别（与 Bootstrap 颜色对应）
+const (
+	EmailIssueError   = "danger"
+	EmailIssueWarning = "warning"
+	EmailIssueInfo    = "info"
+)
+
+// DMARC 策略
+var DMARCPolicies = []string{"none", "quarantine", "reject"}
+
+// EmailIssue 检查发现的问题
+type EmailIssue struct {
+	Level   string
+	Area    string // SPF、DMARC、DKIM、MTA-STS、BIMI
+	Message string
+}
+
+// EmailRecord 邮件安全相关的记录，Text 为拼接后的 TXT 文本
+type EmailRecord struct {
+	ID   string
+	Type string
+	Name string
+	Text string
+	Tags map[string]string // tag=value 形式的参数，key 为小写
+}
+
+// DKIMKey DKIM 选择器及其记录
+type DKIMKey struct {
+	EmailRecord
+	Selector string
+	Bits     int // RSA 公钥长度，无法解析时为 0
+}
+
+// EmailSecurity 域名的邮件安全状况
+type EmailSecurity struct {
+	Domain     string
+	MX         []string
+	SPF        []EmailRecord
+	SPFLookups int
+	DMARC      []EmailRecord
+	DKIM       []DKIMKey
+	MTASTS     []EmailRecord
+	MTASTSHost bool // mta-sts.<domain> 是否存在，用于托管策略文件
+	TLSRPT     []EmailRecord
+	BIMI       []EmailRecord
+	Issues     []EmailIssue
+
+	localizer *goi18n.Localizer // 问题说明使用的语言
+}
+
+// HasMX 是否配置了 MX（接收邮件）
+func (e *EmailSecurity) HasMX() bool {
+	return len(e.MX) > 0
+}
+
+// DMARCPolicy 当前 DMARC 策略，未配置时为空
+func (e *EmailSecurity) DMARCPolicy() string {
+	if len(e.DMARC) == 0 {
+		return ""
+	}
+	return e.DMARC[0].Tags["p"]
+}
+
+// Errors 严重问题数量
+func (e *EmailSecurity) Errors() int {
+	n := 0
+	for _, issue := range e.Issues {
+		if issue.Level == EmailIssueError {
+			n++
+		}
+	}
+	return n
+}
+
+// issue 按 messageID 翻译并记录一个问题
+func (e *EmailSecurity) issue(level, area, messageID string, data map[string]interface{}) {
+	e.Issues = append(e.Issues, EmailIssue{Level: level, Area: area, Message: i18n.TData(e.localizer, messageID, data)})
+}
+
+// AnalyzeEmailSecurity 根据 Zone 中的记录检查 SPF、DKIM、DMARC、MTA-STS 和 BIMI
+// SPF 的 include / redirect 目标通过 resolver 查询，用于统计 DNS 查询次数；问题说明按 localizer 翻译
+func AnalyzeEmailSecurity(ctx context.Context, domain string, records []cloudflare.DNSRecord, resolver Resolver, localizer *goi18n.Localizer) *EmailSecurity {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	e := &EmailSecurity{Domain: domain, localizer: localizer}
+
+	for _, r := range records {
+		name := strings.ToLower(strings.TrimSuffix(r.Name, "."))
+		switch r.Type {
+		case "MX":
+			if name == domain {
+				e.MX = append(e.MX, r.Content)
+			}
+			continue
+		case "A", "AAAA", "CNAME":
+			if name == "mta-sts."+domain {
+				e.MTASTSHost = true
+			}
+			if r.Type != "CNAME" {
+				continue
+			}
+		case "TXT":
+		default:
+			continue
+		}
+
+		rec := EmailRecord{ID: r.ID, Type: r.Type, Name: name, Text: txtText(r)}
+		lower := strings.ToLower(rec.Text)
+		switch {
+		case strings.HasSuffix(name, "._domainkey."+domain):
+			rec.Tags = parseTags(rec.Text)
+			key := DKIMKey{EmailRecord: rec, Selector: strings.TrimSuffix(name, "._domainkey."+domain)}
+			if r.Type == "TXT" {
+				key.Bits = rsaKeyBits(rec.Tags["p"])
+			}
+			e.DKIM = append(e.DKIM, key)
+		case r.Type != "TXT":
+		case name == domain && validation.IsSPF(rec.Text):
+			e.SPF = append(e.SPF, rec)
+		case name == "_dmarc."+domain && strings.HasPrefix(lower, "v=dmarc1"):
+			rec.Tags = parseTags(rec.Text)
+			e.DMARC = append(e.DMARC, rec)
+		case name == "_mta-sts."+domain && strings.HasPrefix(lower, "v=stsv1"):
+			rec.Tags = parseTags(rec.Text)
+			e.MTASTS = append(e.MTASTS, rec)
+		case name == "_smtp._tls."+domain && strings.HasPrefix(lower, "v=tlsrptv1"):
+			rec.Tags = parseTags(rec.Text)
+			e.TLSRPT = append(e.TLSRPT, rec)
+		case name == "default._bimi."+domain && strings.HasPrefix(lower, "v=bimi1"):
+			rec.Tags = parseTags(rec.Text)
+			e.BIMI = append(e.BIMI, rec)
+		}
+	}
+
+	e.checkSPF(ctx, resolver)
+	e.checkDMARC()
+	e.checkDKIM()
+	e.checkMTASTS()
+	e.checkBIMI()
+	return e
+}
+
+func (e *EmailSecurity) checkSPF(ctx context.Context, resolver Resolver) {
+	switch {
+	case len(e.SPF) == 0 && e.HasMX():
+		e.issue(EmailIssueError, "SPF", "email_spf_missing_mx", nil)
+		return
+	case len(e.SPF) == 0:
+		e.issue(EmailIssueWarning, "SPF", "email_spf_missing", nil)
+		return
+	case len(e.SPF) > 1:
+		e.issue(EmailIssueError, "SPF", "email_spf_multiple", map[string]interface{}{"Count": len(e.SPF)})
+	}
+
+	text := e.SPF[0].Text
+	// 查询次数在下面递归统计，这里只提示语法问题
+	for _, err := range validation.CheckSPF(text) {
+		if err.MessageID == "validation_spf_lookups" {
+			continue
+		}
+		e.issue(EmailIssueError, "SPF", err.MessageID, err.Data)
+	}
+
+	if resolver != nil {
+		var problems validation.Errors
+		e.SPFLookups, problems = CountSPFLookups(ctx, resolver, text)
+		if e.SPFLookups > validation.MaxSPFLookups {
+			e.issue(EmailIssueError, "SPF", "email_spf_lookups", map[string]interface{}{"Max": validation.MaxSPFLookups})
+		}
+		for _, p := range problems {
+			e.issue(EmailIssueWarning, "SPF", p.MessageID, p.Data)
+		}
+	}
+
+	switch spfAll(text) {
+	case "+all":
+		e.issue(EmailIssueError, "SPF", "email_spf_pass_all", nil)
+	case "?all":
+		e.issue(EmailIssueWarning, "SPF", "email_spf_neutral_all", nil)
+	case "":
+		if !strings.Contains(strings.ToLower(text), "redirect=") {
+			e.issue(EmailIssueWarning, "SPF", "email_spf_no_all", nil)
+		}
+	}
+}
+
+func (e *EmailSecurity) checkDMARC() {
+	if len(e.DMARC) == 0 {
+		e.issue(EmailIssueError, "DMARC", "email_dmarc_missing", map[string]interface{}{"Domain": e.Domain})
+		return
+	}
+	if len(e.DMARC) > 1 {
+		e.issue(EmailIssueError, "DMARC", "email_dmarc_multiple", map[string]interface{}{"Count": len(e.DMARC)})
+	}
+
+	tags := e.DMARC[0].Tags
+	switch tags["p"] {
+	case "quarantine", "reject":
+	case "none":
+		e.issue(EmailIssueWarning, "DMARC", "email_dmarc_none", nil)
+	case "":
+		e.issue(EmailIssueError, "DMARC", "email_dmarc_no_policy", nil)
+	default:
+		e.issue(EmailIssueError, "DMARC", "email_dmarc_invalid_policy", map[string]interface{}{"Policy": tags["p"]})
+	}
+	if pct, ok := tags["pct"]; ok {
+		if n, err := strconv.Atoi(pct); err != nil || n < 100 {
+			e.issue(EmailIssueWarning, "DMARC", "email_dmarc_pct", map[string]interface{}{"Pct": pct})
+		}
+	}
+	if tags["rua"] == "" {
+		e.issue(EmailIssueInfo, "DMARC", "email_dmarc_no_rua", nil)
+	}
+}
+
+func (e *EmailSecurity) checkDKIM() {
+	if len(e.DKIM) == 0 {
+		if e.HasMX() || len(e.SPF) > 0 {
+			e.issue(EmailIssueWarning, "DKIM", "email_dkim_missing", map[string]interface{}{"Domain": e.Domain})
+		}
+		return
+	}
+	for _, key := range e.DKIM {
+		if key.Type != "TXT" {
+			continue
+		}
+		data := map[string]interface{}{"Selector": key.Selector, "Bits": key.Bits}
+		switch p, ok := key.Tags["p"]; {
+		case !ok:
+			e.issue(EmailIssueError, "DKIM", "email_dkim_no_key", data)
+		case p == "":
+			e.issue(EmailIssueInfo, "DKIM", "email_dkim_revoked", data)
+		case key.Bits > 0 && key.Bits < 1024:
+			e.issue(EmailIssueError, "DKIM", "email_dkim_weak", data)
+		case key.Bits > 0 && key.Bits < 2048:
+			e.issue(EmailIssueInfo, "DKIM", "email_dkim_short", data)
+		}
+	}
+}
+
+// DKIMDelegated 选择器已由 CNAME 委派给邮件服务商时返回 CNAME 目标，否则返回空
+// 同名不能再添加 TXT 记录，向导不会为其生成 DKIM 变更
+func (e *EmailSecurity) DKIMDelegated(selector string) string {
+	name := strings.ToLower(selector) + "._domainkey." + e.Domain
+	for _, k := range e.DKIM {
+		if k.Name == name && k.Type == "CNAME" {
+			return k.Text
+		}
+	}
+	return ""
+}
+
+func (e *EmailSecurity) checkMTASTS() {
+	if len(e.MTASTS) == 0 {
+		if e.HasMX() {
+			e.issue(EmailIssueInfo, "MTA-STS", "email_mtasts_missing", nil)
+		}
+		return
+	}
+	if e.MTASTS[0].Tags["id"] == "" {
+		e.issue(EmailIssueError, "MTA-STS", "email_mtasts_no_id", nil)
+	}
+	if !e.MTASTSHost {
+		e.issue(EmailIssueWarning, "MTA-STS", "email_mtasts_no_host", map[string]interface{}{"Domain": e.Domain})
+	}
+	if len(e.TLSRPT) == 0 {
+		e.issue(EmailIssueInfo, "MTA-STS", "email_tlsrpt_missing", map[string]interface{}{"Domain": e.Domain})
+	}
+}
+
+func (e *EmailSecurity) checkBIMI() {
+	if len(e.BIMI) == 0 {
+		return
+	}
+	if e.BIMI[0].Tags["l"] == "" {
+		e.issue(EmailIssueError, "BIMI", "email_bimi_no_logo", nil)
+	}
+	if p := e.DMARCPolicy(); p != "quarantine" && p != "reject" {
+		e.issue(EmailIssueWarning, "BIMI", "email_bimi_dmarc", nil)
+	}
+}
+
+// CountSPFLookups 递归统计 SPF 求值所需的 DNS 查询次数（RFC 7208 4.6.4）
+// include 和 redirect 的目标通过 resolver 查询，返回的错误用于提示。
+// 与收件方一样，超过 MaxSPFLookups 次后立即停止（此时返回 MaxSPFLookups+1），不再继续查询
+func CountSPFLookups(ctx context.Context, resolver Resolver, spf string) (int, validation.Errors) {
+	var problems validation.Errors
+	problem := func(messageID, target string, data map[string]interface{}) {
+		if data == nil {
+			data = make(map[string]interface{}, 1)
+		}
+		data["Target"] = target
+		problems = append(problems, validation.Error{Field: "content", MessageID: messageID, Data: data})
+	}
+	path := make(map[string]bool)
+	total := 0
+
+	// walk 返回 false 表示已超过查询上限
+	var walk func(text string) bool
+	walk = func(text string) bool {
+		terms := strings.Fields(text)
+		for _, term := range terms[1:] {
+			t := strings.ToLower(strings.TrimLeft(term, "+-~?"))
+			target := ""
+			switch {
+			case strings.HasPrefix(t, "include:"):
+				target = t[len("include:"):]
+			case strings.HasPrefix(t, "redirect="):
+				target = t[len("redirect="):]
+			case spfMechanism(t, "a"), spfMechanism(t, "mx"), spfMechanism(t, "ptr"), spfMechanism(t, "exists"):
+			default:
+				continue
+			}
+			total++
+			if total > validation.MaxSPFLookups {
+				return false
+			}
+
+			// 含宏的目标需要发件信息才能展开
+			if target == "" || strings.Contains(target, "%") {
+				continue
+			}
+			if path[target] {
+				problem("email_spf_loop", target, nil)
+				continue
+			}
+
+			txts, err := resolver.LookupTXT(ctx, target)
+			if err != nil {
+				problem("email_spf_lookup_failed", target, map[string]interface{}{"Error": err.Error()})
+				continue
+			}
+			nested := ""
+			for _, txt := range txts {
+				if validation.IsSPF(txt) {
+					nested = txt
+					break
+				}
+			}
+			if nested == "" {
+				problem("email_spf_include_missing", target, nil)
+				continue
+			}
+
+			path[target] = true
+			ok := walk(nested)
+			delete(path, target)
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+	walk(spf)
+	return total, problems
+}
+
+// spfMechanism 判断 term 是否为指定机制（name、name:xxx 或 name/xx）
+func spfMechanism(term, name string) bool {
+	return term == name || strings.HasPrefix(term, name+":") || strings.HasPrefix(term, name+"/")
+}
+
+// spfAll 返回 SPF 中带限定符的 all（如 ~all），没有 all 时返回空
+func spfAll(text string) string {
+	for _, term := range strings.Fields(strings.ToLower(text)) {
+		switch term {
+		case "all", "+all":
+			return "+all"
+		case "-all", "~all", "?all":
+			return term
+		}
+	}
+	return ""
+}
+
+// txtText 返回记录的文本，TXT 记录的多个字符串直接拼接
+func txtText(r cloudflare.DNSRecord) string {
+	if r.Type != "TXT" {
+		return r.Content
+	}
+	chunks, err := validation.TXTStrings(r.Content)
+	if err != nil {
+		return r.Content
+	}
+	return strings.Join(chunks, "")
+}
+
+// parseTags 解析 "k1=v1; k2=v2" 形式的参数
+func parseTags(text string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(text, ";") {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		tags[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	return tags
+}
+
+// rsaKeyBits DKIM 公钥的位数，无法解析时返回 0
+func rsaKeyBits(p string) int {
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(p), ""))
+	if err != nil || len(der) == 0 {
+		return 0
+	}
+	if pub, err := x509.ParsePKIXPublicKey(der); err == nil {
+		if key, ok := pub.(*rsa.PublicKey); ok {
+			return key.N.BitLen()
+		}
+		return 0
+	}
+	if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return key.N.BitLen()
+	}
+	return 0
+}
+
+// EmailOptions 向导中用户填写的选项
+type EmailOptions struct {
+	DMARCPolicy  string
+	DMARCReport  string // rua 邮箱
+	DKIMSelector string
+	DKIMKey      string // base64 公钥或 PEM
+	MTASTS       bool
+	TLSReport    string // TLS-RPT rua 邮箱
+	BIMILogo     string // SVG 徽标 https 地址
+	BIMICert     string // VMC 证书 https 地址（可选）
+}
+
+// DefaultOptions 根据现有记录生成默认选项：保留已有的 quarantine / reject，否则建议 quarantine
+func (e *EmailSecurity) DefaultOptions() EmailOptions {
+	opts := EmailOptions{DMARCPolicy: "quarantine"}
+	if p := e.DMARCPolicy(); p == "quarantine" || p == "reject" {
+		opts.DMARCPolicy = p
+	}
+	if len(e.DMARC) > 0 {
+		opts.DMARCReport = strings.TrimPrefix(firstURI(e.DMARC[0].Tags["rua"]), "mailto:")
+	}
+	if len(e.BIMI) > 0 {
+		opts.BIMILogo = e.BIMI[0].Tags["l"]
+		opts.BIMICert = e.BIMI[0].Tags["a"]
+	}
+	if len(e.TLSRPT) > 0 {
+		opts.TLSReport = strings.TrimPrefix(firstURI(e.TLSRPT[0].Tags["rua"]), "mailto:")
+	}
+	opts.MTASTS = len(e.MTASTS) > 0
+	return opts
+}
+
+// Validate 检查选项
+func (o EmailOptions) Validate() error {
+	valid := false
+	for _, p := range DMARCPolicies {
+		if o.DMARCPolicy == p {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("DMARC 策略无效: %s", o.DMARCPolicy)
+	}
+	for _, addr := range []string{o.DMARCReport, o.TLSReport} {
+		if addr == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("邮箱地址无效: %s", addr)
+		}
+	}
+	if (o.DKIMSelector == "") != (o.DKIMKey == "") {
+		return fmt.Errorf("DKIM 选择器和公钥需要同时填写")
+	}
+	if o.DKIMSelector != "" && !validDKIMSelector(o.DKIMSelector) {
+		return fmt.Errorf("DKIM 选择器无效: %s", o.DKIMSelector)
+	}
+	if o.DKIMKey != "" {
+		if _, err := base64.StdEncoding.DecodeString(dkimKeyBase64(o.DKIMKey)); err != nil {
+			return fmt.Errorf("DKIM 公钥不是有效的 base64")
+		}
+	}
+	for _, u := range []string{o.BIMILogo, o.BIMICert} {
+		if u == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return fmt.Errorf("BIMI 地址必须是 https 链接: %s", u)
+		}
+	}
+	if o.BIMICert != "" && o.BIMILogo == "" {
+		return fmt.Errorf("填写 BIMI 证书地址时需要同时填写徽标地址")
+	}
+	return nil
+}
+
+func validDKIMSelector(s string) bool {
+	for _, label := range strings.Split(s, ".") {
+		if label == "" {
+			return false
+		}
+		for _, ch := range label {
+			if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '-' || ch == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dkimKeyBase64 去掉 PEM 头尾和空白
+func dkimKeyBase64(key string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(key, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "-----") {
+			continue
+		}
+		b.WriteString(strings.Join(strings.Fields(line), ""))
+	}
+	return b.String()
+}
+
+// dkimRecord 生成 DKIM 记录，32 字节的公钥视为 Ed25519，其余视为 RSA
+func dkimRecord(key string) string {
+	p := dkimKeyBase64(key)
+	k := "rsa"
+	if der, err := base64.StdEncoding.DecodeString(p); err == nil && len(der) == 32 {
+		k = "ed25519"
+	}
+	return "v=DKIM1; k=" + k + "; p=" + p
+}
+
+// firstURI 取逗号分隔的 URI 列表中的第一个
+func firstURI(list string) string {
+	first, _, _ := strings.Cut(list, ",")
+	return strings.TrimSpace(first)
+}
+
+// EmailChange 向导生成的一条记录变更
+type EmailChange struct {
+	Key       string // spf、dmarc、dkim、mta-sts、tls-rpt、bimi
+	Area      string
+	RecordID  string // 为空时新建
+	Name      string
+	Content   string
+	Before    string
+	DeleteIDs []string // 合并后需要删除的重复记录
+	Reason    string
+}
+
+// Changes 根据当前状况和选项生成修正后的记录，内容未变化的记录不会出现在结果中
+func (e *EmailSecurity) Changes(opts EmailOptions, now time.Time) []EmailChange {
+	var changes []EmailChange
+	add := func(c EmailChange, existing []EmailRecord) {
+		if len(existing) > 0 {
+			c.RecordID = existing[0].ID
+			c.Before = existing[0].Text
+			for _, dup := range existing[1:] {
+				c.DeleteIDs = append(c.DeleteIDs, dup.ID)
+			}
+			if c.Before == c.Content && len(c.DeleteIDs) == 0 {
+				return
+			}
+		}
+		changes = append(changes, c)
+	}
+
+	// SPF
+	spf := EmailChange{Key: "spf", Area: "SPF", Name: e.Domain}
+	switch {
+	case len(e.SPF) == 0 && e.HasMX():
+		spf.Content = "v=spf1 mx ~all"
+		spf.Reason = "新建 SPF，允许 MX 服务器发信"
+	case len(e.SPF) == 0:
+		spf.Content = "v=spf1 -all"
+		spf.Reason = "新建 SPF，声明本域不发送邮件"
+	default:
+		spf.Content = mergeSPF(e.SPF)
+		spf.Reason = "规范 SPF 结尾的 all"
+		if len(e.SPF) > 1 {
+			spf.Reason = fmt.Sprintf("将 %d 条 SPF 合并为一条", len(e.SPF))
+		}
+	}
+	add(spf, e.SPF)
+
+	// DMARC
+	var existingDMARC map[string]string
+	if len(e.DMARC) > 0 {
+		existingDMARC = e.DMARC[0].Tags
+	}
+	add(EmailChange{
+		Key:     "dmarc",
+		Area:    "DMARC",
+		Name:    "_dmarc." + e.Domain,
+		Content: buildDMARC(existingDMARC, opts),
+		Reason:  "DMARC 策略 p=" + opts.DMARCPolicy,
+	}, e.DMARC)
+
+	// DKIM
+	if opts.DKIMSelector != "" && opts.DKIMKey != "" && e.DKIMDelegated(opts.DKIMSelector) == "" {
+		name := strings.ToLower(opts.DKIMSelector) + "._domainkey." + e.Domain
+		var existing []EmailRecord
+		for _, k := range e.DKIM {
+			if k.Name == name && k.Type == "TXT" {
+				existing = append(existing, k.EmailRecord)
+			}
+		}
+		add(EmailChange{
+			Key:     "dkim",
+			Area:    "DKIM",
+			Name:    name,
+			Content: dkimRecord(opts.DKIMKey),
+			Reason:  "发布 DKIM 公钥（选择器 " + opts.DKIMSelector + "）",
+		}, existing)
+	}
+
+	// MTA-STS：策略更新时必须修改 id，这里使用当前时间
+	if opts.MTASTS {
+		content := "v=STSv1; id=" + now.UTC().Format("20060102150405")
+		if len(e.MTASTS) == 1 && e.MTASTS[0].Tags["id"] != "" {
+			content = e.MTASTS[0].Text
+		}
+		add(EmailChange{
+			Key:     "mta-sts",
+			Area:    "MTA-STS",
+			Name:    "_mta-sts." + e.Domain,
+			Content: content,
+			Reason:  "启用 MTA-STS（需要在 https://mta-sts." + e.Domain + "/.well-known/mta-sts.txt 托管策略文件）",
+		}, e.MTASTS)
+	}
+	if opts.TLSReport != "" {
+		add(EmailChange{
+			Key:     "tls-rpt",
+			Area:    "MTA-STS",
+			Name:    "_smtp._tls." + e.Domain,
+			Content: "v=TLSRPTv1; rua=mailto:" + opts.TLSReport,
+			Reason:  "接收 TLS 投递失败报告",
+		}, e.TLSRPT)
+	}
+
+	// BIMI
+	if opts.BIMILogo != "" {
+		content := "v=BIMI1; l=" + opts.BIMILogo
+		if opts.BIMICert != "" {
+			content += "; a=" + opts.BIMICert
+		}
+		add(EmailChange{
+			Key:     "bimi",
+			Area:    "BIMI",
+			Name:    "default._bimi." + e.Domain,
+			Content: content,
+			Reason:  "在支持的邮箱客户端中显示品牌徽标",
+		}, e.BIMI)
+	}
+
+	return changes
+}
+
+// mergeSPF 合并多条 SPF：保留全部机制（去重），结尾使用其中最严格的 all
+// +all、?all 或缺少 all 时改为 ~all；只有 redirect 没有 all 时保留 redirect
+func mergeSPF(records []EmailRecord) string {
+	terms := []string{"v=spf1"}
+	seen := make(map[string]bool)
+	all := ""
+	redirect := ""
+	for _, r := range records {
+		for _, term := range strings.Fields(r.Text)[1:] {
+			lower := strings.ToLower(term)
+			switch {
+			case lower == "-all":
+				all = "-all"
+				continue
+			case lower == "~all":
+				if all != "-all" {
+					all = "~all"
+				}
+				continue
+			case lower == "all", lower == "+all", lower == "?all":
+				continue
+			case strings.HasPrefix(lower, "redirect="):
+				if redirect == "" {
+					redirect = term
+				}
+				continue
+			}
+			if !seen[lower] {
+				seen[lower] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+	switch {
+	case all != "":
+		terms = append(terms, all)
+	case redirect != "":
+		terms = append(terms, redirect)
+	default:
+		terms = append(terms, "~all")
+	}
+	return strings.Join(terms, " ")
+}
+
+// buildDMARC 生成 DMARC 记录，保留已有的其他标签（pct 除外，使策略对全部邮件生效）
+func buildDMARC(existing map[string]string, opts EmailOptions) string {
+	parts := []string{"v=DMARC1", "p=" + opts.DMARCPolicy}
+	for _, tag := range []string{"sp", "adkim", "aspf", "ruf", "fo", "rf", "ri"} {
+		if v := existing[tag]; v != "" {
+			parts = append(parts, tag+"="+v)
+		}
+	}
+	// 报告地址未修改时保留原有的完整列表
+	rua := existing["rua"]
+	if opts.DMARCReport != "" && !strings.EqualFold(firstURI(rua), "mailto:"+opts.DMARCReport) {
+		rua = "mailto:" + opts.DMARCReport
+	}
+	if rua != "" {
+		parts = append(parts, "rua="+rua)
+	}
+	return strings.Join(parts, "; ")
+}
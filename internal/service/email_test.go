@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// stubResolver 按名称返回预设的 TXT 记录并记录查询次数，未设置的名称视为查询失败
+type stubResolver struct {
+	txt     map[string][]string
+	queries int
+}
+
+func (r *stubResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	r.queries++
+	txts, ok := r.txt[name]
+	if !ok {
+		return nil, errors.New("SERVFAIL")
+	}
+	return txts, nil
+}
+
+func TestCountSPFLookups(t *testing.T) {
+	resolver := &stubResolver{txt: map[string][]string{
+		"_spf.mail.test":   {"v=spf1 include:_net1.mail.test include:_net2.mail.test ~all"},
+		"_net1.mail.test":  {"v=spf1 ip4:192.0.2.0/24 ~all"},
+		"_net2.mail.test":  {"v=spf1 a mx ~all"},
+		"loop-a.test":      {"v=spf1 include:loop-b.test -all"},
+		"loop-b.test":      {"v=spf1 include:loop-a.test -all"},
+		"not-spf.test":     {"google-site-verification=abc"},
+		"redirect.test":    {"v=spf1 mx -all"},
+		"verification.txt": {"v=spf1 -all"},
+	}}
+
+	tests := []struct {
+		spf      string
+		want     int
+		problems []string
+	}{
+		{"v=spf1 ip4:192.0.2.1 -all", 0, nil},
+		{"v=spf1 a mx include:_spf.mail.test -all", 7, nil},
+		{"v=spf1 redirect=redirect.test", 2, nil},
+		{"v=spf1 include:loop-a.test -all", 3, []string{"email_spf_loop"}},
+		{"v=spf1 include:not-spf.test include:missing.test -all", 2, []string{"email_spf_include_missing", "email_spf_lookup_failed"}},
+		{"v=spf1 exists:%{i}.rbl.test -all", 1, nil},
+	}
+	for _, tt := range tests {
+		got, problems := CountSPFLookups(context.Background(), resolver, tt.spf)
+		if got != tt.want {
+			t.Errorf("CountSPFLookups(%q) = %d, want %d", tt.spf, got, tt.want)
+		}
+		var ids []string
+		for _, p := range problems {
+			ids = append(ids, p.MessageID)
+		}
+		if strings.Join(ids, ",") != strings.Join(tt.problems, ",") {
+			t.Errorf("CountSPFLookups(%q) problems = %v, want %v", tt.spf, ids, tt.problems)
+		}
+	}
+}
+
+func TestCountSPFLookupsStopsAtLimit(t *testing.T) {
+	// 每一级 include 下一级，共 30 级
+	resolver := &stubResolver{txt: map[string][]string{}}
+	for i := 0; i < 30; i++ {
+		resolver.txt[fmt.Sprintf("l%d.test", i)] = []string{fmt.Sprintf("v=spf1 include:l%d.test -all", i+1)}
+	}
+	resolver.txt["l30.test"] = []string{"v=spf1 -all"}
+
+	got, _ := CountSPFLookups(context.Background(), resolver, "v=spf1 include:l0.test -all")
+	if got != 11 {
+		t.Errorf("CountSPFLookups = %d, want 11 (limit exceeded)", got)
+	}
+	if resolver.queries > 10 {
+		t.Errorf("resolver was queried %d times, want at most 10", resolver.queries)
+	}
+}
+
+// issueIDs 未传入 localizer 时 Message 即 messageID
+func issueIDs(e *EmailSecurity, area string) []string {
+	var ids []string
+	for _, issue := range e.Issues {
+		if issue.Area == area {
+			ids = append(ids, issue.Message)
+		}
+	}
+	return ids
+}
+
+func txtRecord(id, name, content string) cloudflare.DNSRecord {
+	return cloudflare.DNSRecord{ID: id, Type: "TXT", Name: name, Content: content}
+}
+
+func TestAnalyzeEmailSecuritySPF(t *testing.T) {
+	resolver := &stubResolver{txt: map[string][]string{}}
+	for i := 0; i < 11; i++ {
+		resolver.txt[fmt.Sprintf("s%d.test", i)] = []string{"v=spf1 -all"}
+	}
+	mx := cloudflare.DNSRecord{Type: "MX", Name: "example.com", Content: "mx.example.com"}
+
+	var includes []string
+	for i := 0; i < 11; i++ {
+		includes = append(includes, fmt.Sprintf("include:s%d.test", i))
+	}
+
+	tests := []struct {
+		records []cloudflare.DNSRecord
+		want    []string
+	}{
+		{[]cloudflare.DNSRecord{mx}, []string{"email_spf_missing_mx"}},
+		{nil, []string{"email_spf_missing"}},
+		{[]cloudflare.DNSRecord{txtRecord("1", "example.com", "v=spf1 mx -all")}, nil},
+		{[]cloudflare.DNSRecord{txtRecord("1", "example.com", "v=spf1 mx +all")}, []string{"email_spf_pass_all"}},
+		{[]cloudflare.DNSRecord{txtRecord("1", "example.com", "v=spf1 mx")}, []string{"email_spf_no_all"}},
+		{[]cloudflare.DNSRecord{
+			txtRecord("1", "example.com", "v=spf1 mx -all"),
+			txtRecord("2", "example.com", "v=spf1 a -all"),
+		}, []string{"email_spf_multiple"}},
+		{[]cloudflare.DNSRecord{txtRecord("1", "example.com", "v=spf1 "+strings.Join(includes, " ")+" -all")}, []string{"email_spf_lookups"}},
+	}
+	for _, tt := range tests {
+		e := AnalyzeEmailSecurity(context.Background(), "example.com", tt.records, resolver, nil)
+		if got := issueIDs(e, "SPF"); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("SPF issues for %+v = %v, want %v", tt.records, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeEmailSecurityDMARC(t *testing.T) {
+	spf := txtRecord("1", "example.com", "v=spf1 -all")
+	tests := []struct {
+		dmarc  []string
+		want   []string
+		policy string
+	}{
+		{nil, []string{"email_dmarc_missing"}, ""},
+		{[]string{"v=DMARC1; p=reject; rua=mailto:d@example.com"}, nil, "reject"},
+		{[]string{"v=DMARC1; p=none; rua=mailto:d@example.com"}, []string{"email_dmarc_none"}, "none"},
+		{[]string{"v=DMARC1; rua=mailto:d@example.com"}, []string{"email_dmarc_no_policy"}, ""},
+		{[]string{"v=DMARC1; p=block"}, []string{"email_dmarc_invalid_policy", "email_dmarc_no_rua"}, "block"},
+		{[]string{"v=DMARC1; p=quarantine; pct=50; rua=mailto:d@example.com"}, []string{"email_dmarc_pct"}, "quarantine"},
+		{[]string{"v=DMARC1; p=reject; rua=mailto:d@example.com", "v=DMARC1; p=none"}, []string{"email_dmarc_multiple"}, "reject"},
+	}
+	for _, tt := range tests {
+		records := []cloudflare.DNSRecord{spf}
+		for i, text := range tt.dmarc {
+			records = append(records, txtRecord(fmt.Sprint("d", i), "_dmarc.example.com", text))
+		}
+		e := AnalyzeEmailSecurity(context.Background(), "example.com", records, nil, nil)
+		if got := issueIDs(e, "DMARC"); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("DMARC issues for %v = %v, want %v", tt.dmarc, got, tt.want)
+		}
+		if e.DMARCPolicy() != tt.policy {
+			t.Errorf("DMARCPolicy for %v = %q, want %q", tt.dmarc, e.DMARCPolicy(), tt.policy)
+		}
+	}
+}
+
+func TestEmailChangesSkipsDelegatedDKIM(t *testing.T) {
+	records := []cloudflare.DNSRecord{
+		txtRecord("1", "example.com", "v=spf1 -all"),
+		txtRecord("2", "_dmarc.example.com", "v=DMARC1; p=reject"),
+		{ID: "3", Type: "CNAME", Name: "s1._domainkey.example.com", Content: "s1.dkim.mail.test"},
+	}
+	e := AnalyzeEmailSecurity(context.Background(), "example.com", records, nil, nil)
+	opts := EmailOptions{DMARCPolicy: "reject", DKIMSelector: "s1", DKIMKey: "MIIBCgKCAQEA"}
+
+	if got := e.DKIMDelegated("S1"); got != "s1.dkim.mail.test" {
+		t.Fatalf("DKIMDelegated = %q", got)
+	}
+	for _, c := range e.Changes(opts, time.Now()) {
+		if c.Key == "dkim" {
+			t.Fatalf("Changes created a DKIM TXT next to the CNAME: %+v", c)
+		}
+	}
+
+	opts.DKIMSelector = "s2"
+	found := false
+	for _, c := range e.Changes(opts, time.Now()) {
+		found = found || (c.Key == "dkim" && c.Name == "s2._domainkey.example.com")
+	}
+	if !found {
+		t.Fatal("Changes did not create the DKIM record for an unused selector")
+	}
+}
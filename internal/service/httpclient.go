@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"golang.org/x/time/rate"
+)
+
+// DefaultAPIBaseURL Cloudflare API v4 地址
+const DefaultAPIBaseURL = "https://api.cloudflare.com/client/v4"
+
+// HTTPOptions 访问 Cloudflare API 的网络设置，SDK 调用和原始 HTTP 调用共用
+type HTTPOptions struct {
+	BaseURL        string        // API 地址，为空时使用 DefaultAPIBaseURL
+	Proxy          string        // 出站代理（http://、https:// 或 socks5://），为空时读取 HTTPS_PROXY 等环境变量
+	CABundle       string        // 额外信任的 CA 证书文件（PEM），追加到系统证书池
+	ConnectTimeout time.Duration // 建立连接和 TLS 握手超时
+	Timeout        time.Duration // 单次请求等待响应头的超时
+	MaxRetries     int           // 429 / 5xx 的最大重试次数，POST / PATCH 只在 429 时重试
+	MinBackoff     time.Duration // 首次重试等待时间，之后逐次翻倍
+	MaxBackoff     time.Duration // 单次重试最长等待时间（也是 Retry-After 的上限）
+}
+
+// DefaultHTTPOptions 默认网络设置（重试策略与 cloudflare-go 默认值一致）
+var DefaultHTTPOptions = HTTPOptions{
+	ConnectTimeout: 10 * time.Second,
+	Timeout:        30 * time.Second,
+	MaxRetries:     3,
+	MinBackoff:     time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// apiBaseURL 去掉末尾斜杠的 API 地址
+func (o HTTPOptions) apiBaseURL() string {
+	if o.BaseURL == "" {
+		return DefaultAPIBaseURL
+	}
+	return strings.TrimSuffix(o.BaseURL, "/")
+}
+
+// NewHTTPClient 按设置创建 HTTP 客户端
+func NewHTTPClient(o HTTPOptions) (*http.Client, error) {
+	transport, err := newTransport(o)
+	if err != nil {
+		return nil, err
+	}
+	return retryClient(o, transport), nil
+}
+
+// newTransport 按代理、CA 和超时设置创建底层连接池，可被多个客户端共用
+func newTransport(o HTTPOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if o.Proxy != "" {
+		proxyURL, err := url.Parse(o.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL: %s", o.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if o.CABundle != "" {
+		pem, err := os.ReadFile(o.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", o.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	if o.ConnectTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: o.ConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = o.ConnectTimeout
+	}
+	if o.Timeout > 0 {
+		transport.ResponseHeaderTimeout = o.Timeout
+	}
+	return transport, nil
+}
+
+// retryClient 在 next 外层加上重试策略
+func retryClient(o HTTPOptions, next http.RoundTripper) *http.Client {
+	return &http.Client{
+		Transport: &retryTransport{
+			next:       next,
+			maxRetries: o.MaxRetries,
+			minBackoff: o.MinBackoff,
+			maxBackoff: o.MaxBackoff,
+		},
+	}
+}
+
+// retryTransport 对 429、5xx 按指数退避重试，规则见 retryable
+// 请求体必须可以重放（http.NewRequest 对 bytes.Reader 等会自动设置 GetBody）
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= t.maxRetries || !retryable(req, resp, err) || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		wait := t.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		if err := sleepContext(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// backoff 第 attempt 次失败后的等待时间，优先使用 Retry-After
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	wait := t.minBackoff << attempt
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+	}
+	if t.maxBackoff > 0 && (wait > t.maxBackoff || wait < 0) {
+		wait = t.maxBackoff
+	}
+	return wait
+}
+
+// retryable 判断是否重试：429 表示请求未被处理，任何方法都可以重试；
+// 网络错误和 5xx 时请求可能已在源站生效（例如边缘返回 502/504），只重试幂等方法，避免 POST 重复创建记录
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if errors.Is(err, ErrBudgetExhausted) {
+		return false
+	}
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if req.Method == http.MethodPost || req.Method == http.MethodPatch {
+		return false
+	}
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// sdkOptions 将网络设置应用到 SDK
+// 重试由 retryTransport 统一处理，关闭 SDK 自带的重试以免叠加；
+// SDK 默认每个实例限速 4 次/秒，请求配额由 ClientPool 按凭证统一管理，这里不再限速
+func sdkOptions(o HTTPOptions, client *http.Client) []cloudflare.Option {
+	return []cloudflare.Option{
+		cloudflare.BaseURL(o.apiBaseURL()),
+		cloudflare.HTTPClient(client),
+		cloudflare.UsingRetryPolicy(0, 0, 0),
+		cloudflare.UsingRateLimit(float64(rate.Inf)),
+	}
+}
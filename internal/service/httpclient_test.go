@@ -0,0 +1,89 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusServer 按顺序返回 statuses 中的状态码，之后一律返回 200
+func statusServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		status    int
+		wantCalls int32
+		wantCode  int
+	}{
+		{"GET retried on 502", http.MethodGet, http.StatusBadGateway, 2, http.StatusOK},
+		{"DELETE retried on 503", http.MethodDelete, http.StatusServiceUnavailable, 2, http.StatusOK},
+		{"POST retried on 429", http.MethodPost, http.StatusTooManyRequests, 2, http.StatusOK},
+		{"POST not retried on 502", http.MethodPost, http.StatusBadGateway, 1, http.StatusBadGateway},
+		{"PATCH not retried on 504", http.MethodPatch, http.StatusGatewayTimeout, 1, http.StatusGatewayTimeout},
+		{"GET not retried on 404", http.MethodGet, http.StatusNotFound, 1, http.StatusNotFound},
+	}
+	client, err := NewHTTPClient(HTTPOptions{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := statusServer(t, tt.status)
+			req, _ := http.NewRequest(tt.method, srv.URL, strings.NewReader(`{"type":"A"}`))
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantCode || calls.Load() != tt.wantCalls {
+				t.Errorf("status %d after %d calls, want %d after %d", resp.StatusCode, calls.Load(), tt.wantCode, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	srv, calls := statusServer(t, 500, 500, 500, 500, 500)
+	client, _ := NewHTTPClient(HTTPOptions{MaxRetries: 2, MinBackoff: time.Millisecond})
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 500 || calls.Load() != 3 {
+		t.Errorf("status %d after %d calls, want 500 after 3", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryBackoffHonorsRetryAfter(t *testing.T) {
+	rt := &retryTransport{minBackoff: time.Second, maxBackoff: 30 * time.Second}
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"7"}}}
+	if got := rt.backoff(0, resp); got != 7*time.Second {
+		t.Errorf("backoff with Retry-After = %v, want 7s", got)
+	}
+	if got := rt.backoff(3, nil); got != 8*time.Second {
+		t.Errorf("backoff(3) = %v, want 8s", got)
+	}
+	if got := rt.backoff(10, nil); got != 30*time.Second {
+		t.Errorf("backoff(10) = %v, want capped at 30s", got)
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrBudgetExhausted 请求配额不足，且在排队上限或请求截止时间内无法恢复
+var ErrBudgetExhausted = errors.New("cloudflare API request budget exhausted, try again later")
+
+// BudgetOptions 每个凭证的请求配额（Cloudflare 默认 5 分钟 1200 次）
+type BudgetOptions struct {
+	Requests int           // 窗口内允许的请求数，也是令牌桶容量
+	Window   time.Duration // 配额窗口
+	MaxWait  time.Duration // 配额不足时最长排队时间，超过则直接拒绝；0 表示不排队
+}
+
+// DefaultBudgetOptions 与 Cloudflare 全局限额一致
+var DefaultBudgetOptions = BudgetOptions{
+	Requests: 1200,
+	Window:   5 * time.Minute,
+	MaxWait:  30 * time.Second,
+}
+
+// Budget 单个凭证的令牌桶：容量为 Requests，按 Requests/Window 的速度恢复
+type Budget struct {
+	limiter  *rate.Limiter
+	capacity int
+	maxWait  time.Duration
+}
+
+// NewBudget 创建满额的令牌桶
+func NewBudget(o BudgetOptions) *Budget {
+	if o.Requests <= 0 {
+		o.Requests = DefaultBudgetOptions.Requests
+	}
+	if o.Window <= 0 {
+		o.Window = DefaultBudgetOptions.Window
+	}
+	every := o.Window / time.Duration(o.Requests)
+	return &Budget{
+		limiter:  rate.NewLimiter(rate.Every(every), o.Requests),
+		capacity: o.Requests,
+		maxWait:  o.MaxWait,
+	}
+}
+
+// Wait 取得一个令牌；配额不足时排队，预计等待超过 MaxWait 或请求截止时间时立即拒绝
+func (b *Budget) Wait(ctx context.Context) error {
+	r := b.limiter.Reserve()
+	delay := r.Delay()
+	if delay > b.maxWait {
+		r.Cancel()
+		return ErrBudgetExhausted
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		r.Cancel()
+		return ErrBudgetExhausted
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		r.Cancel()
+		return err
+	}
+	return nil
+}
+
+// Drain 清空剩余配额（收到 429 时调用，说明实际配额已被其他客户端用完）
+func (b *Budget) Drain() {
+	if n := int(b.limiter.Tokens()); n > 0 {
+		b.limiter.AllowN(time.Now(), n)
+	}
+}
+
+// Remaining 当前剩余的请求数
+func (b *Budget) Remaining() int {
+	n := int(b.limiter.Tokens())
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Available 在 d 时间内最多可以发出的请求数（剩余配额加上期间恢复的配额）
+func (b *Budget) Available(d time.Duration) int {
+	if d < 0 {
+		d = 0
+	}
+	return b.Remaining() + int(float64(b.limiter.Limit())*d.Seconds())
+}
+
+// Capacity 配额上限
+func (b *Budget) Capacity() int {
+	return b.capacity
+}
+
+// Low 剩余配额不足 10%
+func (b *Budget) Low() bool {
+	return b.Remaining()*10 < b.capacity
+}
+
+// budgetTransport 每次发出请求（包括重试）前消耗一个令牌
+type budgetTransport struct {
+	next   http.RoundTripper
+	budget *Budget
+}
+
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.budget.Wait(req.Context()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		t.budget.Drain()
+	}
+	return resp, err
+}
+
+// poolIdleTimeout 闲置超过该时间的客户端会被移除（此时令牌桶早已恢复满额）
+const poolIdleTimeout = 30 * time.Minute
+
+// ClientPool 按凭证复用 CloudflareService，同一凭证的所有请求共享一个令牌桶
+// 所有客户端共用同一个底层连接池
+type ClientPool struct {
+	options   HTTPOptions
+	budget    BudgetOptions
+	transport http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]*poolEntry
+}
+
+type poolEntry struct {
+	service  *CloudflareService
+	budget   *Budget
+	lastUsed time.Time
+}
+
+// NewClientPool 按网络设置和配额创建客户端池
+func NewClientPool(o HTTPOptions, budget BudgetOptions) (*ClientPool, error) {
+	transport, err := newTransport(o)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientPool{
+		options:   o,
+		budget:    budget,
+		transport: transport,
+		entries:   make(map[string]*poolEntry),
+	}, nil
+}
+
+// Clients 实现 ClientFactory，相同凭证返回同一个客户端
+func (p *ClientPool) Clients(authType, email, secret string) (Client, error) {
+	key := poolKey(authType, email, secret)
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k, e := range p.entries {
+		if now.Sub(e.lastUsed) > poolIdleTimeout {
+			delete(p.entries, k)
+		}
+	}
+	if e, ok := p.entries[key]; ok {
+		e.lastUsed = now
+		return e.service, nil
+	}
+
+	budget := NewBudget(p.budget)
+	client := retryClient(p.options, &budgetTransport{next: p.transport, budget: budget})
+	s, err := NewCloudflareServiceWithOptions(authType, email, secret, p.options, client)
+	if err != nil {
+		return nil, err
+	}
+	p.entries[key] = &poolEntry{service: s, budget: budget, lastUsed: now}
+	return s, nil
+}
+
+// Budget 返回凭证的令牌桶，该凭证尚未发起过请求时返回 nil
+func (p *ClientPool) Budget(authType, email, secret string) *Budget {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.entries[poolKey(authType, email, secret)]; ok {
+		return e.budget
+	}
+	return nil
+}
+
+// CredentialID 凭证的稳定标识，写入审计日志用于按账户过滤
+// Global API Key 以账户邮箱标识（更换密钥后仍能看到之前的日志），API Token 以令牌摘要的前 16 位标识
+func CredentialID(authType, email, secret string) string {
+	if authType == AuthTypeAPIToken {
+		sum := sha256.Sum256([]byte(secret))
+		return "token:" + hex.EncodeToString(sum[:8])
+	}
+	return "key:" + strings.ToLower(email)
+}
+
+// poolKey 凭证的池键，不直接保存密钥明文
+func poolKey(authType, email, secret string) string {
+	if authType == "" {
+		authType = AuthTypeAPIKey
+	}
+	sum := sha256.Sum256([]byte(authType + "\x00" + email + "\x00" + secret))
+	return hex.EncodeToString(sum[:])
+}
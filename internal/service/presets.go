@@ -94,15 +94,14 @@ var Presets = map[string]ConfigPreset{
 }
 
 // ApplyPreset 应用预设配置
-func (s *CloudflareService) ApplyPreset(ctx context.Context, zoneID, presetName string) error {
+func ApplyPreset(ctx context.Context, s Client, zoneID, presetName string) error {
 	preset, ok := Presets[presetName]
 	if !ok {
 		return cloudflare.ErrMissingZoneID // 返回一个合适的错误
 	}
 
 	// 批量更新设置
-	_, err := s.API.UpdateZoneSettings(ctx, zoneID, preset.Settings)
-	return err
+	return s.UpdateZoneSettings(ctx, zoneID, preset.Settings)
 }
 
 // GetPresetInfo 获取预设信息（用于前端显示）
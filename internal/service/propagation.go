@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/miekg/dns"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/validation"
+)
+
+// PropagationTypes 支持传播检查的记录类型
+var PropagationTypes = map[string]uint16{
+	"A":     dns.TypeA,
+	"AAAA":  dns.TypeAAAA,
+	"CNAME": dns.TypeCNAME,
+	"MX":    dns.TypeMX,
+	"TXT":   dns.TypeTXT,
+	"NS":    dns.TypeNS,
+	"PTR":   dns.TypePTR,
+	"CAA":   dns.TypeCAA,
+	"SRV":   dns.TypeSRV,
+}
+
+// resolverNames 常见公共解析器的名称
+var resolverNames = map[string]string{
+	"1.1.1.1":         "Cloudflare",
+	"1.0.0.1":         "Cloudflare",
+	"8.8.8.8":         "Google",
+	"8.8.4.4":         "Google",
+	"9.9.9.9":         "Quad9",
+	"208.67.222.222":  "OpenDNS",
+	"223.5.5.5":       "阿里 DNS",
+	"223.6.6.6":       "阿里 DNS",
+	"119.29.29.29":    "腾讯 DNSPod",
+	"114.114.114.114": "114 DNS",
+	"180.76.76.76":    "百度 DNS",
+}
+
+// PropagationServer 参与检查的 DNS 服务器
+type PropagationServer struct {
+	Name          string
+	Addr          string // host:port
+	Authoritative bool   // Zone 分配的权威服务器（不递归查询）
+}
+
+// PropagationServers 由 Zone 的权威 NS 和配置的公共解析器组成服务器列表
+func PropagationServers(nameServers, resolvers []string) []PropagationServer {
+	servers := make([]PropagationServer, 0, len(nameServers)+len(resolvers))
+	for _, ns := range nameServers {
+		ns = strings.TrimSuffix(ns, ".")
+		servers = append(servers, PropagationServer{Name: ns, Addr: withDefaultPort(ns), Authoritative: true})
+	}
+	for _, r := range resolvers {
+		addr := withDefaultPort(r)
+		host, _, _ := net.SplitHostPort(addr)
+		name := resolverNames[host]
+		if name == "" {
+			name = host
+		}
+		servers = append(servers, PropagationServer{Name: name, Addr: addr})
+	}
+	return servers
+}
+
+// PropagationResult 单个服务器的查询结果
+type PropagationResult struct {
+	Server  PropagationServer
+	Answers []string
+	TTL     uint32 // 应答中的最小 TTL
+	RTT     time.Duration
+	Rcode   string
+	Err     string
+	Match   bool
+}
+
+// PropagationReport 一条记录在各服务器上的传播情况
+type PropagationReport struct {
+	Name      string
+	Type      string // 实际查询的类型
+	Expected  []string
+	Reference string // 期望值来源：Cloudflare 记录或权威服务器应答
+	Note      string
+	Results   []PropagationResult
+}
+
+// Matched 与期望一致的服务器数量
+func (r *PropagationReport) Matched() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Match {
+			n++
+		}
+	}
+	return n
+}
+
+// QueryServer 向指定服务器发送一次查询，recursive 为 false 时用于查询权威服务器
+func QueryServer(ctx context.Context, server, name string, qtype uint16, timeout time.Duration, recursive bool) (*dns.Msg, time.Duration, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.RecursionDesired = recursive
+
+	client := &dns.Client{Timeout: timeout}
+	resp, rtt, err := client.ExchangeContext(ctx, msg, server)
+	if err == nil && resp.Truncated {
+		// 应答被截断时改用 TCP 重试（大的 TXT 记录集常见）
+		client.Net = "tcp"
+		resp, rtt, err = client.ExchangeContext(ctx, msg, server)
+	}
+	return resp, rtt, err
+}
+
+// CheckPropagation 检查 rrset（同名同类型的全部 Cloudflare 记录）在各服务器上的应答
+//
+// 代理记录和根域名 CNAME（展平）对外返回 Cloudflare 的 IP，无法与记录内容比较，
+// 这种情况下以第一个应答的权威服务器结果作为期望值。
+func CheckPropagation(ctx context.Context, rrset []cloudflare.DNSRecord, zone string, servers []PropagationServer, timeout time.Duration) (*PropagationReport, error) {
+	if len(rrset) == 0 {
+		return nil, fmt.Errorf("no records to check")
+	}
+	first := rrset[0]
+	qtypeName := first.Type
+	if _, ok := PropagationTypes[qtypeName]; !ok {
+		return nil, fmt.Errorf("unsupported record type: %s", first.Type)
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(first.Name, "."))
+	report := &PropagationReport{Name: name, Type: qtypeName, Reference: "Cloudflare"}
+
+	proxied := first.Proxied != nil && *first.Proxied
+	flattened := first.Type == "CNAME" && name == strings.ToLower(strings.TrimSuffix(zone, "."))
+	switch {
+	case proxied && first.Type == "CNAME", flattened:
+		report.Type = "A"
+		report.Reference = "authoritative"
+		report.Note = "CNAME 被代理或展平，对外返回 A 记录，以权威服务器的应答为准"
+	case proxied:
+		report.Reference = "authoritative"
+		report.Note = "代理记录对外返回 Cloudflare Anycast IP，以权威服务器的应答为准"
+	default:
+		for _, r := range rrset {
+			report.Expected = append(report.Expected, expectedValue(r))
+		}
+		sort.Strings(report.Expected)
+	}
+	qtype := PropagationTypes[report.Type]
+
+	report.Results = make([]PropagationResult, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, server PropagationServer) {
+			defer wg.Done()
+			res := PropagationResult{Server: server}
+			resp, rtt, err := QueryServer(ctx, server.Addr, name, qtype, timeout, !server.Authoritative)
+			res.RTT = rtt
+			if err != nil {
+				res.Err = err.Error()
+			} else {
+				res.Rcode = dns.RcodeToString[resp.Rcode]
+				res.Answers, res.TTL = AnswerValues(resp, qtype)
+			}
+			report.Results[i] = res
+		}(i, server)
+	}
+	wg.Wait()
+
+	if report.Reference == "authoritative" {
+		for _, res := range report.Results {
+			if res.Server.Authoritative && res.Err == "" && len(res.Answers) > 0 {
+				report.Expected = res.Answers
+				break
+			}
+		}
+	}
+	for i := range report.Results {
+		res := &report.Results[i]
+		res.Match = res.Err == "" && len(report.Expected) > 0 && sameValues(res.Answers, report.Expected)
+	}
+	return report, nil
+}
+
+// AnswerValues 提取应答中指定类型的记录值（已排序）和最小 TTL
+func AnswerValues(resp *dns.Msg, qtype uint16) ([]string, uint32) {
+	var values []string
+	var ttl uint32
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype != qtype {
+			continue
+		}
+		if ttl == 0 || rr.Header().Ttl < ttl {
+			ttl = rr.Header().Ttl
+		}
+		values = append(values, rrValue(rr))
+	}
+	sort.Strings(values)
+	return values, ttl
+}
+
+// rrValue 将应答记录格式化为可比较的值
+func rrValue(rr dns.RR) string {
+	switch v := rr.(type) {
+	case *dns.A:
+		return v.A.String()
+	case *dns.AAAA:
+		return v.AAAA.String()
+	case *dns.CNAME:
+		return normalizeHost(v.Target)
+	case *dns.NS:
+		return normalizeHost(v.Ns)
+	case *dns.PTR:
+		return normalizeHost(v.Ptr)
+	case *dns.MX:
+		return strconv.Itoa(int(v.Preference)) + " " + normalizeHost(v.Mx)
+	case *dns.TXT:
+		return strings.Join(v.Txt, "")
+	case *dns.CAA:
+		return fmt.Sprintf("%d %s %s", v.Flag, strings.ToLower(v.Tag), v.Value)
+	case *dns.SRV:
+		return fmt.Sprintf("%d %d %d %s", v.Priority, v.Weight, v.Port, normalizeHost(v.Target))
+	}
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
+
+// expectedValue 将 Cloudflare 记录格式化为与 rrValue 相同的形式
+func expectedValue(r cloudflare.DNSRecord) string {
+	switch r.Type {
+	case "A", "AAAA":
+		if ip := net.ParseIP(r.Content); ip != nil {
+			return ip.String()
+		}
+	case "CNAME", "NS", "PTR":
+		return normalizeHost(r.Content)
+	case "MX":
+		prio := 0
+		if r.Priority != nil {
+			prio = int(*r.Priority)
+		}
+		return strconv.Itoa(prio) + " " + normalizeHost(r.Content)
+	case "TXT":
+		if chunks, err := validation.TXTStrings(r.Content); err == nil {
+			return strings.Join(chunks, "")
+		}
+	case "CAA":
+		if data, ok := r.Data.(map[string]interface{}); ok {
+			return fmt.Sprintf("%v %s %v", data["flags"], strings.ToLower(fmt.Sprint(data["tag"])), data["value"])
+		}
+	case "SRV":
+		if data, ok := r.Data.(map[string]interface{}); ok {
+			return fmt.Sprintf("%v %v %v %s", data["priority"], data["weight"], data["port"], normalizeHost(fmt.Sprint(data["target"])))
+		}
+	}
+	return r.Content
+}
+
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// closedAddr 返回一个没有服务监听的本地 UDP 地址
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := pc.LocalAddr().String()
+	pc.Close()
+	return addr
+}
+
+func TestCheckPropagation(t *testing.T) {
+	longTXT := strings.Repeat("k", 300)
+	proxied := true
+
+	// 权威服务器返回 Cloudflare 上的最新值，fresh 与之一致，stale 仍缓存旧值
+	auth := serveDNS(t,
+		"www.example.com. 300 IN A 192.0.2.10",
+		"www.example.com. 300 IN A 192.0.2.11",
+		"cdn.example.com. 300 IN A 104.16.1.1",
+		"example.com. 300 IN A 104.16.2.2",
+		"example.com. 300 IN MX 10 mx1.example.com.",
+		"example.com. 300 IN MX 20 MX2.example.com.",
+		`dkim.example.com. 300 IN TXT "`+longTXT[:255]+`" "`+longTXT[255:]+`"`,
+		"_sip._tcp.example.com. 300 IN SRV 10 5 5060 sip.example.com.",
+		`example.com. 300 IN CAA 0 issue "letsencrypt.org"`,
+	)
+	fresh := serveDNS(t,
+		"www.example.com. 120 IN A 192.0.2.11",
+		"www.example.com. 60 IN A 192.0.2.10",
+		"cdn.example.com. 300 IN A 104.16.1.1",
+		"example.com. 300 IN A 104.16.2.2",
+		"example.com. 300 IN MX 20 mx2.example.com.",
+		"example.com. 300 IN MX 10 mx1.example.com.",
+		`dkim.example.com. 300 IN TXT "`+longTXT+`"`,
+		"_sip._tcp.example.com. 300 IN SRV 10 5 5060 sip.example.com.",
+		`example.com. 300 IN CAA 0 issue "letsencrypt.org"`,
+	)
+	stale := serveDNS(t,
+		"www.example.com. 3600 IN A 198.51.100.1",
+		"cdn.example.com. 300 IN A 198.51.100.2",
+		"example.com. 300 IN A 198.51.100.3",
+		"example.com. 300 IN MX 10 mx1.example.com.",
+	)
+	servers := []PropagationServer{
+		{Name: "ns1.cf.test", Addr: auth, Authoritative: true},
+		{Name: "fresh", Addr: fresh},
+		{Name: "stale", Addr: stale},
+		{Name: "down", Addr: closedAddr(t)},
+	}
+
+	tests := []struct {
+		name      string
+		rrset     []cloudflare.DNSRecord
+		qtype     string
+		reference string
+		expected  []string
+		matched   []string // 与期望一致的服务器
+	}{
+		{
+			name: "A",
+			rrset: []cloudflare.DNSRecord{
+				{Type: "A", Name: "www.example.com", Content: "192.0.2.11"},
+				{Type: "A", Name: "www.example.com", Content: "192.0.2.10"},
+			},
+			qtype: "A", reference: "Cloudflare",
+			expected: []string{"192.0.2.10", "192.0.2.11"},
+			matched:  []string{"ns1.cf.test", "fresh"},
+		},
+		{
+			name:  "proxied A",
+			rrset: []cloudflare.DNSRecord{{Type: "A", Name: "cdn.example.com", Content: "192.0.2.20", Proxied: &proxied}},
+			qtype: "A", reference: "authoritative",
+			expected: []string{"104.16.1.1"},
+			matched:  []string{"ns1.cf.test", "fresh"},
+		},
+		{
+			name:  "flattened apex CNAME",
+			rrset: []cloudflare.DNSRecord{{Type: "CNAME", Name: "example.com", Content: "origin.example.net"}},
+			qtype: "A", reference: "authoritative",
+			expected: []string{"104.16.2.2"},
+			matched:  []string{"ns1.cf.test", "fresh"},
+		},
+		{
+			name: "MX",
+			rrset: []cloudflare.DNSRecord{
+				{Type: "MX", Name: "example.com", Content: "mx1.example.com", Priority: cloudflare.Uint16Ptr(10)},
+				{Type: "MX", Name: "example.com", Content: "mx2.example.com", Priority: cloudflare.Uint16Ptr(20)},
+			},
+			qtype: "MX", reference: "Cloudflare",
+			expected: []string{"10 mx1.example.com", "20 mx2.example.com"},
+			matched:  []string{"ns1.cf.test", "fresh"},
+		},
+		{
+			name:  "long TXT",
+			rrset: []cloudflare.DNSRecord{{Type: "TXT", Name: "dkim.example.com", Content: longTXT}},
+			qtype: "TXT", reference: "Cloudflare",
+			expected: []string{longTXT},
+			matched:  []string{"ns1.cf.test", "fresh"},
+		},
+		{
+			name: "SRV",
+			rrset: []cloudflare.DNSRecord{{Type: "SRV", Name: "_sip._tcp.example.com", Data: map[string]interface{}{
+				"priority": float64(10), "weight": float64(5), "port": float64(5060), "target": "sip.example.com",
+			}}},
+			qtype: "SRV", reference: "Cloudflare",
+			expected: []string{"10 5 5060 sip.example.com"},
+			matched:  []string{"ns1.cf.test", "fresh"},
+		},
+		{
+			name: "CAA",
+			rrset: []cloudflare.DNSRecord{{Type: "CAA", Name: "example.com", Data: map[string]interface{}{
+				"flags": float64(0), "tag": "issue", "value": "letsencrypt.org",
+			}}},
+			qtype: "CAA", reference: "Cloudflare",
+			expected: []string{"0 issue letsencrypt.org"},
+			matched:  []string{"ns1.cf.test", "fresh"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := CheckPropagation(context.Background(), tt.rrset, "example.com", servers, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Type != tt.qtype || report.Reference != tt.reference {
+				t.Errorf("Type, Reference = %s, %s, want %s, %s", report.Type, report.Reference, tt.qtype, tt.reference)
+			}
+			if !reflect.DeepEqual(report.Expected, tt.expected) {
+				t.Errorf("Expected = %q, want %q", report.Expected, tt.expected)
+			}
+
+			var matched []string
+			for _, res := range report.Results {
+				if res.Match {
+					matched = append(matched, res.Server.Name)
+				}
+			}
+			if !reflect.DeepEqual(matched, tt.matched) || report.Matched() != len(tt.matched) {
+				t.Errorf("matched servers = %v, want %v", matched, tt.matched)
+			}
+
+			down := report.Results[3]
+			if down.Err == "" || down.Match {
+				t.Errorf("unreachable server result = %+v, want an error", down)
+			}
+		})
+	}
+
+	// 解析器上的 TTL 取应答中的最小值
+	report, _ := CheckPropagation(context.Background(), tests[0].rrset, "example.com", servers, time.Second)
+	if got := report.Results[1].TTL; got != 60 {
+		t.Errorf("fresh TTL = %d, want 60", got)
+	}
+	if got := report.Results[2].Answers; !reflect.DeepEqual(got, []string{"198.51.100.1"}) {
+		t.Errorf("stale answers = %v", got)
+	}
+}
+
+func TestCheckPropagationNXDomain(t *testing.T) {
+	auth := serveDNS(t, "www.example.com. 300 IN A 192.0.2.10")
+	servers := []PropagationServer{{Name: "ns1.cf.test", Addr: auth, Authoritative: true}}
+	rrset := []cloudflare.DNSRecord{{Type: "A", Name: "new.example.com", Content: "192.0.2.30"}}
+
+	report, err := CheckPropagation(context.Background(), rrset, "example.com", servers, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := report.Results[0]; res.Rcode != "NXDOMAIN" || res.Match || len(res.Answers) != 0 {
+		t.Errorf("result for a record not yet published = %+v", res)
+	}
+}
+
+func TestCheckPropagationRejects(t *testing.T) {
+	if _, err := CheckPropagation(context.Background(), nil, "example.com", nil, time.Second); err == nil {
+		t.Error("empty rrset should be rejected")
+	}
+	rrset := []cloudflare.DNSRecord{{Type: "SSHFP", Name: "host.example.com", Content: "4 2 ABCD"}}
+	if _, err := CheckPropagation(context.Background(), rrset, "example.com", nil, time.Second); err == nil {
+		t.Error("SSHFP should be rejected as unsupported")
+	}
+}
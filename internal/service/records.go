@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// RecordsPerPage Zone 管理页每页显示的记录数
+const RecordsPerPage = 20
+
+// DefaultRecordStaleWait 缓存过期后等待刷新的时间，超过后先返回旧数据，刷新在后台继续
+const DefaultRecordStaleWait = 2 * time.Second
+
+// recordFetchTimeout 后台刷新的超时时间（不随发起请求的页面取消）
+const recordFetchTimeout = 2 * time.Minute
+
+// RecordCache 按账户和 Zone 缓存完整的 DNS 记录列表
+// 按账户区分是为了只向能访问该 Zone 的凭证返回记录；修改记录后 Invalidate 会清除所有账户的缓存
+// 在 Cloudflare 控制台等其他途径的修改需手动刷新
+type RecordCache struct {
+	TTL       time.Duration
+	StaleWait time.Duration // 缓存过期后等待刷新的时间
+
+	mu      sync.Mutex
+	entries map[recordCacheKey]*recordCacheEntry
+}
+
+type recordCacheKey struct {
+	account string
+	zoneID  string
+}
+
+type recordCacheEntry struct {
+	records []cloudflare.DNSRecord
+	fetched time.Time
+	loaded  bool
+	fetch   *recordFetch // 正在进行的刷新，同一 Zone 的并发请求共用
+}
+
+type recordFetch struct {
+	done    chan struct{}
+	records []cloudflare.DNSRecord
+	err     error
+}
+
+// CachedRecords 缓存中的记录，调用方不得修改 Records
+type CachedRecords struct {
+	Records   []cloudflare.DNSRecord
+	FetchedAt time.Time
+	Stale     bool // 刷新较慢或失败，返回的是过期数据
+}
+
+// NewRecordCache 创建缓存，ttl <= 0 时不缓存
+func NewRecordCache(ttl time.Duration) *RecordCache {
+	return &RecordCache{
+		TTL:       ttl,
+		StaleWait: DefaultRecordStaleWait,
+		entries:   make(map[recordCacheKey]*recordCacheEntry),
+	}
+}
+
+// Get 获取 Zone 的全部记录；refresh 为 true 时忽略缓存并等待刷新完成
+// 缓存过期时发起刷新，若在 StaleWait 内未完成或刷新失败则先返回过期数据
+func (c *RecordCache) Get(ctx context.Context, account string, s Client, zoneID string, refresh bool) (*CachedRecords, error) {
+	key := recordCacheKey{account: account, zoneID: zoneID}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &recordCacheEntry{}
+		c.entries[key] = entry
+	}
+	if entry.loaded && !refresh && time.Since(entry.fetched) < c.TTL {
+		result := &CachedRecords{Records: entry.records, FetchedAt: entry.fetched}
+		c.mu.Unlock()
+		return result, nil
+	}
+	fetch := entry.fetch
+	if fetch == nil {
+		fetch = &recordFetch{done: make(chan struct{})}
+		entry.fetch = fetch
+		go c.revalidate(ctx, key, entry, fetch, s)
+	}
+	stale := &CachedRecords{Records: entry.records, FetchedAt: entry.fetched, Stale: true}
+	hasStale := entry.loaded && !refresh
+	c.mu.Unlock()
+
+	if hasStale {
+		timer := time.NewTimer(c.StaleWait)
+		defer timer.Stop()
+		select {
+		case <-fetch.done:
+			if fetch.err != nil {
+				return stale, nil
+			}
+			return &CachedRecords{Records: fetch.records, FetchedAt: time.Now()}, nil
+		case <-timer.C:
+			return stale, nil
+		case <-ctx.Done():
+			return stale, nil
+		}
+	}
+
+	select {
+	case <-fetch.done:
+		if fetch.err != nil {
+			return nil, fetch.err
+		}
+		return &CachedRecords{Records: fetch.records, FetchedAt: time.Now()}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// revalidate 在后台获取记录并写入缓存；期间缓存被清除（记录已被修改）时丢弃结果
+func (c *RecordCache) revalidate(ctx context.Context, key recordCacheKey, entry *recordCacheEntry, fetch *recordFetch, s Client) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordFetchTimeout)
+	defer cancel()
+
+	records, err := ListAllDNSRecords(ctx, s, key.zoneID)
+
+	c.mu.Lock()
+	fetch.records, fetch.err = records, err
+	entry.fetch = nil
+	if err == nil && c.entries[key] == entry {
+		entry.records = records
+		entry.fetched = time.Now()
+		entry.loaded = true
+	}
+	c.prune()
+	c.mu.Unlock()
+	close(fetch.done)
+}
+
+// Invalidate 清除所有账户对该 Zone 的缓存（增删改记录后调用）
+// 正在进行的刷新可能读到修改前的数据，其结果不会写入缓存
+func (c *RecordCache) Invalidate(zoneID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.zoneID == zoneID {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// prune 清理没有进行中刷新的条目，调用方需持有锁
+// 过期的记录再保留一个 TTL，刷新较慢时用来先返回
+func (c *RecordCache) prune() {
+	now := time.Now()
+	for k, e := range c.entries {
+		if e.fetch == nil && (!e.loaded || now.Sub(e.fetched) > 2*c.TTL) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// FilterRecords 按名称或内容关键词（不区分大小写）、类型和代理状态（"true" / "false"）过滤记录
+func FilterRecords(records []cloudflare.DNSRecord, query, recordType, proxied string) []cloudflare.DNSRecord {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var filtered []cloudflare.DNSRecord
+	for _, record := range records {
+		if recordType != "" && record.Type != recordType {
+			continue
+		}
+		isProxied := record.Proxied != nil && *record.Proxied
+		if (proxied == "true" && !isProxied) || (proxied == "false" && isProxied) {
+			continue
+		}
+		if query != "" &&
+			!strings.Contains(strings.ToLower(record.Name), query) &&
+			!strings.Contains(strings.ToLower(record.Content), query) {
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+	return filtered
+}
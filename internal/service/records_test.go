@@ -0,0 +1,165 @@
+package service_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/fakecf"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+)
+
+// slowClient 统计 ListDNSRecords 调用次数，并可模拟接口变慢
+type slowClient struct {
+	service.Client
+	delay atomic.Int64
+	calls atomic.Int32
+}
+
+func (s *slowClient) ListDNSRecords(ctx context.Context, rc *cloudflare.ResourceContainer, params cloudflare.ListDNSRecordsParams) ([]cloudflare.DNSRecord, *cloudflare.ResultInfo, error) {
+	s.calls.Add(1)
+	select {
+	case <-time.After(time.Duration(s.delay.Load())):
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	return s.Client.ListDNSRecords(ctx, rc, params)
+}
+
+func newRecordFixture(t *testing.T) (*fakecf.Backend, string, *slowClient) {
+	t.Helper()
+	backend := fakecf.New()
+	zone := backend.AddZone("example.com")
+	addARecord(t, backend, zone.ID, "a.example.com")
+	client, err := backend.Clients(service.AuthTypeAPIToken, "", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backend, zone.ID, &slowClient{Client: client}
+}
+
+func addARecord(t *testing.T, backend *fakecf.Backend, zoneID, name string) {
+	t.Helper()
+	if _, err := backend.AddRecord(zoneID, cloudflare.DNSRecord{Type: "A", Name: name, Content: "192.0.2.1", TTL: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordCacheServesCachedRecords(t *testing.T) {
+	backend, zoneID, client := newRecordFixture(t)
+	cache := service.NewRecordCache(time.Hour)
+	ctx := context.Background()
+
+	if got, err := cache.Get(ctx, "alice", client, zoneID, false); err != nil || len(got.Records) != 1 {
+		t.Fatalf("first Get = %v, %v", got, err)
+	}
+	addARecord(t, backend, zoneID, "b.example.com")
+
+	got, _ := cache.Get(ctx, "alice", client, zoneID, false)
+	if len(got.Records) != 1 || client.calls.Load() != 1 {
+		t.Fatalf("cached Get returned %d records after %d calls", len(got.Records), client.calls.Load())
+	}
+	got, _ = cache.Get(ctx, "alice", client, zoneID, true)
+	if len(got.Records) != 2 {
+		t.Fatalf("refresh returned %d records, want 2", len(got.Records))
+	}
+}
+
+func TestRecordCacheInvalidateAllAccounts(t *testing.T) {
+	backend, zoneID, client := newRecordFixture(t)
+	cache := service.NewRecordCache(time.Hour)
+	ctx := context.Background()
+
+	cache.Get(ctx, "alice", client, zoneID, false)
+	cache.Get(ctx, "bob", client, zoneID, false)
+	addARecord(t, backend, zoneID, "b.example.com")
+
+	// alice 修改记录后，bob 看到的缓存也应失效
+	cache.Invalidate(zoneID)
+	for _, account := range []string{"alice", "bob"} {
+		got, err := cache.Get(ctx, account, client, zoneID, false)
+		if err != nil || len(got.Records) != 2 {
+			t.Fatalf("%s after Invalidate = %v, %v", account, got, err)
+		}
+	}
+}
+
+func TestRecordCacheStaleWhileRevalidate(t *testing.T) {
+	backend, zoneID, client := newRecordFixture(t)
+	cache := service.NewRecordCache(50 * time.Millisecond)
+	cache.StaleWait = 20 * time.Millisecond
+	ctx := context.Background()
+
+	cache.Get(ctx, "alice", client, zoneID, false)
+	addARecord(t, backend, zoneID, "b.example.com")
+	time.Sleep(60 * time.Millisecond)
+
+	client.delay.Store(int64(200 * time.Millisecond))
+	got, err := cache.Get(ctx, "alice", client, zoneID, false)
+	if err != nil || !got.Stale || len(got.Records) != 1 {
+		t.Fatalf("slow refresh = %+v, %v; want stale copy", got, err)
+	}
+	// 后台刷新进行中，并发请求不重复调用接口
+	calls := client.calls.Load()
+	cache.Get(ctx, "alice", client, zoneID, false)
+	if client.calls.Load() != calls {
+		t.Fatal("concurrent Get started a second refresh")
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	client.delay.Store(0)
+	got, _ = cache.Get(ctx, "alice", client, zoneID, false)
+	if got.Stale || len(got.Records) != 2 {
+		t.Fatalf("after background refresh = %+v", got)
+	}
+}
+
+func TestRecordCacheDropsFetchAfterInvalidate(t *testing.T) {
+	_, zoneID, client := newRecordFixture(t)
+	cache := service.NewRecordCache(time.Hour)
+	client.delay.Store(int64(100 * time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := cache.Get(ctx, "alice", client, zoneID, false); err == nil {
+		t.Fatal("Get should fail when the request deadline passes first")
+	}
+	// 刷新开始后记录被修改，刷新结果不能写入缓存
+	cache.Invalidate(zoneID)
+	time.Sleep(150 * time.Millisecond)
+
+	calls := client.calls.Load()
+	client.delay.Store(0)
+	cache.Get(context.Background(), "alice", client, zoneID, false)
+	if client.calls.Load() == calls {
+		t.Fatal("records fetched before Invalidate were cached")
+	}
+}
+
+func TestFilterRecords(t *testing.T) {
+	proxied, direct := true, false
+	records := []cloudflare.DNSRecord{
+		{Type: "A", Name: "www.example.com", Content: "192.0.2.1", Proxied: &proxied},
+		{Type: "A", Name: "mail.example.com", Content: "192.0.2.2", Proxied: &direct},
+		{Type: "TXT", Name: "example.com", Content: "v=spf1 -all"},
+	}
+	tests := []struct {
+		query, recordType, proxied string
+		want                       int
+	}{
+		{"", "", "", 3},
+		{"MAIL", "", "", 1},
+		{"192.0.2", "A", "", 2},
+		{"", "A", "true", 1},
+		{"", "", "false", 2},
+		{"spf", "A", "", 0},
+	}
+	for _, tt := range tests {
+		if got := service.FilterRecords(records, tt.query, tt.recordType, tt.proxied); len(got) != tt.want {
+			t.Errorf("FilterRecords(%q, %q, %q) = %d records, want %d", tt.query, tt.recordType, tt.proxied, len(got), tt.want)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/validation"
+)
+
+// 表单字段类型
+const (
+	FieldText     = "text"     // 字符串
+	FieldTextarea = "textarea" // 多行字符串
+	FieldInt      = "int"      // 整数
+	FieldDecimal  = "decimal"  // 小数
+	FieldSelect   = "select"   // 下拉选择，Numeric 为 true 时按整数提交
+)
+
+// FieldOption 下拉选项
+type FieldOption struct {
+	Value string
+	Label string
+}
+
+// RecordField 记录类型的单个结构化字段（对应 Cloudflare API 的 data.<Key>）
+type RecordField struct {
+	Key         string
+	Label       string
+	Kind        string
+	Options     []FieldOption
+	Numeric     bool // 下拉选项的值是否为整数
+	Placeholder string
+	Default     string
+	Min         float64
+	Max         float64
+	Optional    bool
+}
+
+// FormName 表单字段名
+func (f RecordField) FormName() string {
+	return "data_" + f.Key
+}
+
+// parse 按字段类型转换表单值，错误的 Field 为表单字段名，由调用方按请求语言翻译
+func (f RecordField) parse(raw string) (interface{}, *validation.Error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		if f.Optional {
+			return nil, nil
+		}
+		return nil, f.error("validation_field_required", nil)
+	}
+
+	switch {
+	case f.Kind == FieldInt || (f.Kind == FieldSelect && f.Numeric):
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, f.error("validation_field_integer", nil)
+		}
+		if float64(n) < f.Min || float64(n) > f.Max {
+			return nil, f.rangeError()
+		}
+		return n, nil
+
+	case f.Kind == FieldDecimal:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, f.error("validation_field_number", nil)
+		}
+		if n < f.Min || n > f.Max {
+			return nil, f.rangeError()
+		}
+		return n, nil
+
+	case f.Kind == FieldSelect:
+		for _, opt := range f.Options {
+			if opt.Value == raw {
+				return raw, nil
+			}
+		}
+		return nil, f.error("validation_field_option", nil)
+	}
+	return raw, nil
+}
+
+// error 字段校验错误，Data 中的 Field 为字段标签
+func (f RecordField) error(messageID string, data map[string]interface{}) *validation.Error {
+	if data == nil {
+		data = make(map[string]interface{}, 1)
+	}
+	data["Field"] = f.Label
+	return &validation.Error{Field: f.FormName(), MessageID: messageID, Data: data}
+}
+
+func (f RecordField) rangeError() *validation.Error {
+	return f.error("validation_field_range", map[string]interface{}{
+		"Min": formatNumber(f.Min),
+		"Max": formatNumber(f.Max),
+	})
+}
+
+// RecordType 记录类型定义，驱动表单渲染、服务端校验和 Data 构造
+type RecordType struct {
+	Type               string
+	Description        string
+	Proxiable          bool // 是否支持 Cloudflare 代理
+	HasContent         bool // 是否使用 content 字段
+	ContentLabel       string
+	ContentPlaceholder string
+	HasPriority        bool // 是否使用顶层 priority 字段
+	Fields             []RecordField
+}
+
+// HasData 是否使用结构化 data 字段
+func (t *RecordType) HasData() bool {
+	return len(t.Fields) > 0
+}
+
+// 常用取值范围
+const (
+	maxUint8  = 255
+	maxUint16 = 65535
+)
+
+var (
+	dnssecAlgorithmOptions = []FieldOption{
+		{"8", "8 - RSASHA256"},
+		{"10", "10 - RSASHA512"},
+		{"13", "13 - ECDSAP256SHA256"},
+		{"14", "14 - ECDSAP384SHA384"},
+		{"15", "15 - ED25519"},
+		{"16", "16 - ED448"},
+		{"5", "5 - RSASHA1"},
+		{"7", "7 - RSASHA1-NSEC3-SHA1"},
+	}
+	digestTypeOptions = []FieldOption{
+		{"2", "2 - SHA-256"},
+		{"4", "4 - SHA-384"},
+		{"1", "1 - SHA-1"},
+	}
+	tlsaUsageOptions = []FieldOption{
+		{"0", "0 - PKIX-TA"},
+		{"1", "1 - PKIX-EE"},
+		{"2", "2 - DANE-TA"},
+		{"3", "3 - DANE-EE"},
+	}
+	tlsaSelectorOptions = []FieldOption{
+		{"0", "0 - 完整证书"},
+		{"1", "1 - 公钥（SPKI）"},
+	}
+	tlsaMatchingOptions = []FieldOption{
+		{"0", "0 - 完整内容"},
+		{"1", "1 - SHA-256"},
+		{"2", "2 - SHA-512"},
+	}
+)
+
+// tlsaFields TLSA 与 SMIMEA 共用的字段
+func tlsaFields() []RecordField {
+	return []RecordField{
+		{Key: "usage", Label: "用途", Kind: FieldSelect, Numeric: true, Options: tlsaUsageOptions, Default: "3", Max: 3},
+		{Key: "selector", Label: "选择器", Kind: FieldSelect, Numeric: true, Options: tlsaSelectorOptions, Default: "1", Max: 1},
+		{Key: "matching_type", Label: "匹配类型", Kind: FieldSelect, Numeric: true, Options: tlsaMatchingOptions, Default: "1", Max: 2},
+		{Key: "certificate", Label: "证书数据（十六进制）", Kind: FieldTextarea},
+	}
+}
+
+// svcbFields HTTPS 与 SVCB 共用的字段
+func svcbFields() []RecordField {
+	return []RecordField{
+		{Key: "priority", Label: "优先级", Kind: FieldInt, Default: "1", Max: maxUint16},
+		{Key: "target", Label: "目标", Kind: FieldText, Placeholder: ". 表示与名称相同", Default: "."},
+		{Key: "value", Label: "参数", Kind: FieldText, Placeholder: `alpn="h3,h2" ipv4hint="192.0.2.1"`, Optional: true},
+	}
+}
+
+// RecordTypes Cloudflare 支持的全部记录类型
+var RecordTypes = []RecordType{
+	{Type: "A", Description: "IPv4 地址", Proxiable: true, HasContent: true, ContentLabel: "IPv4 地址", ContentPlaceholder: "192.0.2.1"},
+	{Type: "AAAA", Description: "IPv6 地址", Proxiable: true, HasContent: true, ContentLabel: "IPv6 地址", ContentPlaceholder: "2001:db8::1"},
+	{Type: "CNAME", Description: "别名", Proxiable: true, HasContent: true, ContentLabel: "目标域名", ContentPlaceholder: "example.com"},
+	{Type: "MX", Description: "邮件服务器", HasContent: true, ContentLabel: "邮件服务器", ContentPlaceholder: "mail.example.com", HasPriority: true},
+	{Type: "TXT", Description: "文本", HasContent: true, ContentLabel: "内容", ContentPlaceholder: "v=spf1 include:_spf.example.com ~all"},
+	{Type: "NS", Description: "子域名委派", HasContent: true, ContentLabel: "名称服务器", ContentPlaceholder: "ns1.example.com"},
+	{Type: "PTR", Description: "反向解析", HasContent: true, ContentLabel: "目标域名", ContentPlaceholder: "host.example.com"},
+	{Type: "CAA", Description: "证书颁发机构授权", Fields: []RecordField{
+		{Key: "flags", Label: "标志", Kind: FieldInt, Default: "0", Max: maxUint8},
+		{Key: "tag", Label: "标签", Kind: FieldSelect, Options: []FieldOption{
+			{"issue", "issue - 允许颁发证书"},
+			{"issuewild", "issuewild - 允许颁发通配符证书"},
+			{"iodef", "iodef - 违规报告地址"},
+		}, Default: "issue"},
+		{Key: "value", Label: "值", Kind: FieldText, Placeholder: "letsencrypt.org"},
+	}},
+	{Type: "SRV", Description: "服务定位（名称形如 _sip._tcp）", Fields: []RecordField{
+		{Key: "priority", Label: "优先级", Kind: FieldInt, Default: "10", Max: maxUint16},
+		{Key: "weight", Label: "权重", Kind: FieldInt, Default: "5", Max: maxUint16},
+		{Key: "port", Label: "端口", Kind: FieldInt, Default: "5060", Max: maxUint16},
+		{Key: "target", Label: "目标", Kind: FieldText, Placeholder: "sip.example.com"},
+	}},
+	{Type: "HTTPS", Description: "HTTPS 服务绑定", Fields: svcbFields()},
+	{Type: "SVCB", Description: "通用服务绑定", Fields: svcbFields()},
+	{Type: "TLSA", Description: "DANE 证书关联（名称形如 _443._tcp）", Fields: tlsaFields()},
+	{Type: "SMIMEA", Description: "S/MIME 证书关联", Fields: tlsaFields()},
+	{Type: "SSHFP", Description: "SSH 公钥指纹", Fields: []RecordField{
+		{Key: "algorithm", Label: "算法", Kind: FieldSelect, Numeric: true, Options: []FieldOption{
+			{"4", "4 - Ed25519"},
+			{"3", "3 - ECDSA"},
+			{"1", "1 - RSA"},
+			{"2", "2 - DSA"},
+			{"6", "6 - Ed448"},
+		}, Default: "4", Max: 6},
+		{Key: "type", Label: "指纹类型", Kind: FieldSelect, Numeric: true, Options: []FieldOption{
+			{"2", "2 - SHA-256"},
+			{"1", "1 - SHA-1"},
+		}, Default: "2", Min: 1, Max: 2},
+		{Key: "fingerprint", Label: "指纹（十六进制）", Kind: FieldText},
+	}},
+	{Type: "CERT", Description: "证书", Fields: []RecordField{
+		{Key: "type", Label: "证书类型", Kind: FieldInt, Default: "1", Max: maxUint16},
+		{Key: "key_tag", Label: "Key Tag", Kind: FieldInt, Default: "0", Max: maxUint16},
+		{Key: "algorithm", Label: "算法", Kind: FieldInt, Default: "0", Max: maxUint8},
+		{Key: "certificate", Label: "证书（Base64）", Kind: FieldTextarea},
+	}},
+	{Type: "DS", Description: "子域名 DNSSEC 委派签名", Fields: []RecordField{
+		{Key: "key_tag", Label: "Key Tag", Kind: FieldInt, Max: maxUint16},
+		{Key: "algorithm", Label: "算法", Kind: FieldSelect, Numeric: true, Options: dnssecAlgorithmOptions, Default: "13", Max: maxUint8},
+		{Key: "digest_type", Label: "摘要类型", Kind: FieldSelect, Numeric: true, Options: digestTypeOptions, Default: "2", Max: maxUint8},
+		{Key: "digest", Label: "摘要（十六进制）", Kind: FieldText},
+	}},
+	{Type: "DNSKEY", Description: "DNSSEC 公钥", Fields: []RecordField{
+		{Key: "flags", Label: "Flags", Kind: FieldInt, Default: "257", Max: maxUint16},
+		{Key: "protocol", Label: "协议", Kind: FieldInt, Default: "3", Min: 3, Max: 3},
+		{Key: "algorithm", Label: "算法", Kind: FieldSelect, Numeric: true, Options: dnssecAlgorithmOptions, Default: "13", Max: maxUint8},
+		{Key: "public_key", Label: "公钥（Base64）", Kind: FieldTextarea},
+	}},
+	{Type: "LOC", Description: "地理位置", Fields: []RecordField{
+		{Key: "lat_degrees", Label: "纬度（度）", Kind: FieldInt, Max: 90},
+		{Key: "lat_minutes", Label: "纬度（分）", Kind: FieldInt, Default: "0", Max: 59},
+		{Key: "lat_seconds", Label: "纬度（秒）", Kind: FieldDecimal, Default: "0", Max: 59.999},
+		{Key: "lat_direction", Label: "纬度方向", Kind: FieldSelect, Options: []FieldOption{{"N", "N - 北纬"}, {"S", "S - 南纬"}}, Default: "N"},
+		{Key: "long_degrees", Label: "经度（度）", Kind: FieldInt, Max: 180},
+		{Key: "long_minutes", Label: "经度（分）", Kind: FieldInt, Default: "0", Max: 59},
+		{Key: "long_seconds", Label: "经度（秒）", Kind: FieldDecimal, Default: "0", Max: 59.999},
+		{Key: "long_direction", Label: "经度方向", Kind: FieldSelect, Options: []FieldOption{{"E", "E - 东经"}, {"W", "W - 西经"}}, Default: "E"},
+		{Key: "altitude", Label: "海拔（米）", Kind: FieldDecimal, Default: "0", Min: -100000, Max: 42849672.95},
+		{Key: "size", Label: "范围（米）", Kind: FieldDecimal, Default: "1", Max: 90000000},
+		{Key: "precision_horz", Label: "水平精度（米）", Kind: FieldDecimal, Default: "10000", Max: 90000000},
+		{Key: "precision_vert", Label: "垂直精度（米）", Kind: FieldDecimal, Default: "10", Max: 90000000},
+	}},
+	{Type: "NAPTR", Description: "名称权威指针", Fields: []RecordField{
+		{Key: "order", Label: "顺序", Kind: FieldInt, Default: "100", Max: maxUint16},
+		{Key: "preference", Label: "偏好", Kind: FieldInt, Default: "10", Max: maxUint16},
+		{Key: "flags", Label: "标志", Kind: FieldText, Placeholder: "U", Optional: true},
+		{Key: "service", Label: "服务", Kind: FieldText, Placeholder: "E2U+sip", Optional: true},
+		{Key: "regex", Label: "正则", Kind: FieldText, Placeholder: "!^.*$!sip:info@example.com!", Optional: true},
+		{Key: "replacement", Label: "替换", Kind: FieldText, Default: "."},
+	}},
+	{Type: "URI", Description: "URI 映射", HasPriority: true, Fields: []RecordField{
+		{Key: "weight", Label: "权重", Kind: FieldInt, Default: "1", Max: maxUint16},
+		{Key: "target", Label: "目标 URI", Kind: FieldText, Placeholder: "https://example.com/"},
+	}},
+}
+
+// LookupRecordType 按类型名查找定义
+func LookupRecordType(recordType string) (*RecordType, bool) {
+	recordType = strings.ToUpper(recordType)
+	for i := range RecordTypes {
+		if RecordTypes[i].Type == recordType {
+			return &RecordTypes[i], true
+		}
+	}
+	return nil, false
+}
+
+// RecordInput 从表单提交的记录内容（不含名称、TTL 等公共字段）
+type RecordInput struct {
+	Content  string
+	Priority string
+	Data     map[string]string // key 为 RecordField.Key
+}
+
+// RecordValues Build 的结果
+type RecordValues struct {
+	Content  string
+	Priority *uint16
+	Data     map[string]interface{}
+}
+
+// Build 校验输入并构造 content、priority 和 data
+// 返回的错误以字段名（content、priority 或 data_<key>）为 Field
+func (t *RecordType) Build(in RecordInput) (RecordValues, validation.Errors) {
+	var (
+		values RecordValues
+		errs   validation.Errors
+	)
+
+	if t.HasContent {
+		values.Content = strings.TrimSpace(in.Content)
+		if values.Content == "" {
+			errs = append(errs, validation.Error{Field: "content", MessageID: "validation_field_required", Data: map[string]interface{}{
+				"Field": t.ContentLabel,
+			}})
+		}
+	}
+
+	if t.HasPriority {
+		p, err := strconv.Atoi(strings.TrimSpace(in.Priority))
+		if err != nil || p < 0 || p > maxUint16 {
+			errs = append(errs, validation.Error{Field: "priority", MessageID: "validation_priority_invalid", Data: map[string]interface{}{
+				"Max": maxUint16,
+			}})
+		} else {
+			prio := uint16(p)
+			values.Priority = &prio
+		}
+	}
+
+	if t.HasData() {
+		values.Data = make(map[string]interface{}, len(t.Fields))
+		for _, f := range t.Fields {
+			v, err := f.parse(in.Data[f.Key])
+			if err != nil {
+				errs = append(errs, *err)
+				continue
+			}
+			if v != nil {
+				values.Data[f.Key] = v
+			}
+		}
+	}
+
+	return values, errs
+}
+
+// FormValues 将已有记录转换为表单值，用于编辑页面回填
+// key 为表单字段名（content、priority 或 data_<key>）
+func (t *RecordType) FormValues(record cloudflare.DNSRecord) map[string]string {
+	values := make(map[string]string)
+	if t.HasContent {
+		values["content"] = record.Content
+	}
+	if t.HasPriority && record.Priority != nil {
+		values["priority"] = strconv.Itoa(int(*record.Priority))
+	}
+
+	data, _ := record.Data.(map[string]interface{})
+	for _, f := range t.Fields {
+		v, ok := data[f.Key]
+		if !ok || v == nil {
+			continue
+		}
+		switch n := v.(type) {
+		case float64:
+			values[f.FormName()] = formatNumber(n)
+		case int:
+			values[f.FormName()] = strconv.Itoa(n)
+		default:
+			values[f.FormName()] = fmt.Sprint(v)
+		}
+	}
+	return values
+}
+
+// DefaultFormValues 新建记录时的默认表单值
+func (t *RecordType) DefaultFormValues() map[string]string {
+	values := make(map[string]string)
+	if t.HasPriority {
+		values["priority"] = "10"
+	}
+	for _, f := range t.Fields {
+		if f.Default != "" {
+			values[f.FormName()] = f.Default
+		}
+	}
+	return values
+}
+
+// formatNumber 去掉多余小数位
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
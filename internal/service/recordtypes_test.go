@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/validation"
+)
+
+func TestRecordTypeBuildErrors(t *testing.T) {
+	srv, _ := LookupRecordType("SRV")
+	mx, _ := LookupRecordType("MX")
+
+	tests := []struct {
+		rt        *RecordType
+		in        RecordInput
+		field     string
+		messageID string
+	}{
+		{srv, RecordInput{Data: map[string]string{"priority": "", "weight": "5", "port": "5060", "target": "sip.example.com"}}, "data_priority", "validation_field_required"},
+		{srv, RecordInput{Data: map[string]string{"priority": "ten", "weight": "5", "port": "5060", "target": "sip.example.com"}}, "data_priority", "validation_field_integer"},
+		{srv, RecordInput{Data: map[string]string{"priority": "10", "weight": "5", "port": "70000", "target": "sip.example.com"}}, "data_port", "validation_field_range"},
+		{mx, RecordInput{Content: "", Priority: "10"}, "content", "validation_field_required"},
+		{mx, RecordInput{Content: "mx.example.com", Priority: "-1"}, "priority", "validation_priority_invalid"},
+	}
+	for _, tt := range tests {
+		_, errs := tt.rt.Build(tt.in)
+		if len(errs) != 1 || errs[0].Field != tt.field || errs[0].MessageID != tt.messageID {
+			t.Errorf("%s Build(%+v) = %+v, want %s on %s", tt.rt.Type, tt.in, errs, tt.messageID, tt.field)
+		}
+	}
+
+	loc, _ := LookupRecordType("LOC")
+	_, errs := loc.Build(RecordInput{Data: map[string]string{
+		"lat_degrees": "91", "lat_direction": "N", "long_degrees": "0", "long_direction": "X",
+		"lat_seconds": "abc", "altitude": "0", "size": "1", "precision_horz": "1", "precision_vert": "1",
+		"lat_minutes": "0", "long_minutes": "0", "long_seconds": "0",
+	}})
+	got := map[string]validation.Error{}
+	for _, err := range errs {
+		got[err.Field] = err
+	}
+	if e := got["data_lat_degrees"]; e.MessageID != "validation_field_range" || e.Data["Max"] != "90" || e.Data["Field"] != "纬度（度）" {
+		t.Errorf("lat_degrees error = %+v", e)
+	}
+	if e := got["data_lat_seconds"]; e.MessageID != "validation_field_number" {
+		t.Errorf("lat_seconds error = %+v", e)
+	}
+	if e := got["data_long_direction"]; e.MessageID != "validation_field_option" {
+		t.Errorf("long_direction error = %+v", e)
+	}
+}
+
+// recordAPI 在内存中保存记录的 DNS 记录接口，按 JSON 原样存取，
+// 读回的 data 数值与真实 API 一样为 float64
+type recordAPI struct {
+	mu      sync.Mutex
+	records map[string]map[string]interface{}
+}
+
+func (a *recordAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	const prefix = "/zones/zone1/dns_records"
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	var body map[string]interface{}
+	if r.Body != nil && r.Method != http.MethodGet {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	switch {
+	case r.Method == http.MethodPost && id == "":
+		id = fmt.Sprint("rec", len(a.records)+1)
+		body["id"] = id
+		a.records[id] = body
+	case r.Method == http.MethodPatch && a.records[id] != nil:
+		for k, v := range body {
+			a.records[id][k] = v
+		}
+	case r.Method == http.MethodGet && a.records[id] != nil:
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	writeAPIResult(w, a.records[id])
+}
+
+// recordTypeSamples 每种记录类型的表单值，以及编辑时修改的字段（空值表示清除可选字段）
+var recordTypeSamples = map[string]struct {
+	form map[string]string
+	edit map[string]string
+}{
+	"A":      {map[string]string{"content": "192.0.2.1"}, map[string]string{"content": "192.0.2.2"}},
+	"AAAA":   {map[string]string{"content": "2001:db8::1"}, map[string]string{"content": "2001:db8::2"}},
+	"CNAME":  {map[string]string{"content": "target.example.net"}, map[string]string{"content": "other.example.net"}},
+	"MX":     {map[string]string{"content": "mx1.example.com", "priority": "10"}, map[string]string{"priority": "0"}},
+	"TXT":    {map[string]string{"content": "v=spf1 -all"}, map[string]string{"content": "v=spf1 mx -all"}},
+	"NS":     {map[string]string{"content": "ns1.other.test"}, map[string]string{"content": "ns2.other.test"}},
+	"PTR":    {map[string]string{"content": "host.example.com"}, map[string]string{"content": "host2.example.com"}},
+	"CAA":    {map[string]string{"data_flags": "0", "data_tag": "issue", "data_value": "letsencrypt.org"}, map[string]string{"data_tag": "issuewild", "data_flags": "128"}},
+	"SRV":    {map[string]string{"data_priority": "10", "data_weight": "5", "data_port": "5060", "data_target": "sip.example.com"}, map[string]string{"data_port": "5061"}},
+	"HTTPS":  {map[string]string{"data_priority": "1", "data_target": ".", "data_value": `alpn="h3,h2"`}, map[string]string{"data_value": ""}},
+	"SVCB":   {map[string]string{"data_priority": "0", "data_target": "svc.example.net"}, map[string]string{"data_priority": "2", "data_value": "port=8443"}},
+	"TLSA":   {map[string]string{"data_usage": "3", "data_selector": "1", "data_matching_type": "1", "data_certificate": "0C72AC70B745AC19998811B131D662C9AC69DBDBE7CB23E5B514B56664C5D3D6"}, map[string]string{"data_usage": "2"}},
+	"SMIMEA": {map[string]string{"data_usage": "3", "data_selector": "0", "data_matching_type": "2", "data_certificate": "ABCDEF"}, map[string]string{"data_matching_type": "0"}},
+	"SSHFP":  {map[string]string{"data_algorithm": "4", "data_type": "2", "data_fingerprint": "123456789ABCDEF67890123456789ABCDEF67890123456789ABCDEF123456"}, map[string]string{"data_algorithm": "1"}},
+	"CERT":   {map[string]string{"data_type": "1", "data_key_tag": "0", "data_algorithm": "0", "data_certificate": "MIIBCgKCAQEA"}, map[string]string{"data_key_tag": "12345"}},
+	"DS":     {map[string]string{"data_key_tag": "2371", "data_algorithm": "13", "data_digest_type": "2", "data_digest": "1F987CC6583E92DF0890718C42C8B9DF5EAF5BD6F6E3D6F1B1CF09E8B0B3C6A1"}, map[string]string{"data_algorithm": "8"}},
+	"DNSKEY": {map[string]string{"data_flags": "257", "data_protocol": "3", "data_algorithm": "13", "data_public_key": "mdsswUyr3DPW132mOi8V9xESWE8jTo0dxCjjnopKl+GqJxpVXckHAeF+KkxLbxILfDLUT0rAK9iUzy1L53eKGQ=="}, map[string]string{"data_flags": "256"}},
+	"LOC": {map[string]string{
+		"data_lat_degrees": "31", "data_lat_minutes": "13", "data_lat_seconds": "49.25", "data_lat_direction": "N",
+		"data_long_degrees": "121", "data_long_minutes": "28", "data_long_seconds": "0", "data_long_direction": "E",
+		"data_altitude": "-10.5", "data_size": "1", "data_precision_horz": "10000", "data_precision_vert": "10",
+	}, map[string]string{"data_lat_direction": "S", "data_altitude": "4.2"}},
+	"NAPTR": {map[string]string{"data_order": "100", "data_preference": "10", "data_flags": "U", "data_service": "E2U+sip", "data_regex": "!^.*$!sip:info@example.com!", "data_replacement": "."}, map[string]string{"data_regex": "", "data_flags": ""}},
+	"URI":   {map[string]string{"priority": "10", "data_weight": "1", "data_target": "https://example.com/"}, map[string]string{"data_target": "ftp://example.com/pub"}},
+}
+
+// recordInput 将表单值转换为 Build 的输入
+func recordInput(form map[string]string) RecordInput {
+	in := RecordInput{Content: form["content"], Priority: form["priority"], Data: map[string]string{}}
+	for k, v := range form {
+		if key, ok := strings.CutPrefix(k, "data_"); ok {
+			in.Data[key] = v
+		}
+	}
+	return in
+}
+
+// TestRecordTypeRoundTrip 每种类型按表单创建记录，读回后回填的表单与提交的一致，编辑后再次读回
+func TestRecordTypeRoundTrip(t *testing.T) {
+	s := newTestService(t, &recordAPI{records: map[string]map[string]interface{}{}})
+	rc := cloudflare.ZoneIdentifier("zone1")
+	ctx := context.Background()
+
+	for i := range RecordTypes {
+		rt := &RecordTypes[i]
+		sample, ok := recordTypeSamples[rt.Type]
+		if !ok {
+			t.Errorf("no round-trip sample for %s", rt.Type)
+			continue
+		}
+
+		t.Run(rt.Type, func(t *testing.T) {
+			built, errs := rt.Build(recordInput(sample.form))
+			if len(errs) > 0 {
+				t.Fatalf("Build(%v) = %+v", sample.form, errs)
+			}
+			created, err := s.CreateDNSRecord(ctx, rc, cloudflare.CreateDNSRecordParams{
+				Type: rt.Type, Name: "rt.example.com", Content: built.Content, Data: built.Data, Priority: built.Priority, TTL: 1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			record, err := s.GetDNSRecord(ctx, rc, created.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			form := rt.FormValues(record)
+			if !reflect.DeepEqual(form, sample.form) {
+				t.Fatalf("FormValues after create = %v, want %v", form, sample.form)
+			}
+
+			// 在回填的表单上修改后提交
+			for k, v := range sample.edit {
+				form[k] = v
+			}
+			built, errs = rt.Build(recordInput(form))
+			if len(errs) > 0 {
+				t.Fatalf("Build(%v) = %+v", form, errs)
+			}
+			if _, err := s.UpdateDNSRecord(ctx, rc, cloudflare.UpdateDNSRecordParams{
+				ID: created.ID, Type: rt.Type, Name: "rt.example.com", Content: built.Content, Data: built.Data, Priority: built.Priority, TTL: 1,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			record, err = s.GetDNSRecord(ctx, rc, created.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range form {
+				if v == "" {
+					delete(form, k)
+				}
+			}
+			if got := rt.FormValues(record); !reflect.DeepEqual(got, form) {
+				t.Errorf("FormValues after edit = %v, want %v", got, form)
+			}
+		})
+	}
+}
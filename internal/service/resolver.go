@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// DefaultResolvers 未配置时使用的公共递归解析器
+var DefaultResolvers = []string{"1.1.1.1:53", "8.8.8.8:53"}
+
+// Resolver DNS 查询接口，测试时可替换为本地桩服务器
+type Resolver interface {
+	// LookupTXT 返回名称下的全部 TXT 记录（每条记录的多个字符串已拼接），名称不存在时返回空
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DNSResolver 基于 miekg/dns 的解析器，按顺序尝试 Servers，直到得到明确的应答
+type DNSResolver struct {
+	Servers []string // host:port，未写端口时使用 53
+	Timeout time.Duration
+}
+
+// NewDNSResolver 创建解析器，servers 为空时使用 DefaultResolvers
+func NewDNSResolver(servers ...string) *DNSResolver {
+	if len(servers) == 0 {
+		servers = DefaultResolvers
+	}
+	normalized := make([]string, 0, len(servers))
+	for _, s := range servers {
+		normalized = append(normalized, withDefaultPort(s))
+	}
+	return &DNSResolver{Servers: normalized, Timeout: 5 * time.Second}
+}
+
+// withDefaultPort 为没有端口的地址补上 53
+func withDefaultPort(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+}
+
+// Exchange 向各服务器依次发送查询，返回第一个 NOERROR 或 NXDOMAIN 应答
+func (r *DNSResolver) Exchange(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	var lastErr error
+	for _, server := range r.Servers {
+		resp, _, err := QueryServer(ctx, server, name, qtype, r.Timeout, true)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+			lastErr = fmt.Errorf("%s: %s", server, dns.RcodeToString[resp.Rcode])
+			continue
+		}
+		return resp, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no resolvers configured")
+	}
+	return nil, lastErr
+}
+
+// LookupTXT 实现 Resolver
+func (r *DNSResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	resp, err := r.Exchange(ctx, name, dns.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, rr := range resp.Answer {
+		if txt, ok := rr.(*dns.TXT); ok {
+			out = append(out, strings.Join(txt.Txt, ""))
+		}
+	}
+	return out, nil
+}
+
+// LookupHost 返回主机名的 IPv4 地址
+func (r *DNSResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	resp, err := r.Exchange(ctx, host, dns.TypeA)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, rr := range resp.Answer {
+		if a, ok := rr.(*dns.A); ok {
+			out = append(out, a.A.String())
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%s: no A record", host)
+	}
+	return out, nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+	"gopkg.in/yaml.v3"
+)
+
+// managedByPrefix 写入记录备注的托管标记前缀
+const managedByPrefix = "managed-by:"
+
+// DesiredState 声明式 DNS 配置文件（YAML/JSON）
+type DesiredState struct {
+	Zones []DesiredZone `yaml:"zones" json:"zones"`
+}
+
+// DesiredZone 单个 Zone 的目标记录集
+type DesiredZone struct {
+	Name    string          `yaml:"name" json:"name"`
+	Records []DesiredRecord `yaml:"records" json:"records"`
+}
+
+// DesiredRecord 目标记录
+// Name 可以是相对名称（"www"）、"@"（顶点）或完整域名
+// 省略 TTL 表示不指定：已有记录保持原 TTL，新建记录使用自动 TTL
+type DesiredRecord struct {
+	Type     string                 `yaml:"type" json:"type"`
+	Name     string                 `yaml:"name" json:"name"`
+	Content  string                 `yaml:"content" json:"content"`
+	TTL      int                    `yaml:"ttl" json:"ttl"`
+	Priority *uint16                `yaml:"priority" json:"priority"`
+	Proxied  *bool                  `yaml:"proxied" json:"proxied"`
+	Comment  string                 `yaml:"comment" json:"comment"`
+	Data     map[string]interface{} `yaml:"data" json:"data"`
+}
+
+// LoadDesiredState 读取配置文件（YAML 是 JSON 的超集，两种格式均可解析）
+func LoadDesiredState(path string) (*DesiredState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state DesiredState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	for i, zone := range state.Zones {
+		if zone.Name == "" {
+			return nil, fmt.Errorf("zones[%d]: missing name", i)
+		}
+		for j, record := range zone.Records {
+			if record.Type == "" || record.Name == "" {
+				return nil, fmt.Errorf("zone %s records[%d]: type and name are required", zone.Name, j)
+			}
+			if record.Content == "" && record.Data == nil {
+				return nil, fmt.Errorf("zone %s records[%d]: content or data is required", zone.Name, j)
+			}
+		}
+	}
+
+	return &state, nil
+}
+
+// DNSRecords 将目标记录转换为完整域名形式的 Cloudflare 记录
+func (z DesiredZone) DNSRecords() []cloudflare.DNSRecord {
+	zoneName := normalizeName(z.Name)
+
+	records := make([]cloudflare.DNSRecord, 0, len(z.Records))
+	for _, r := range z.Records {
+		record := cloudflare.DNSRecord{
+			Type:     strings.ToUpper(r.Type),
+			Name:     qualifyName(r.Name, zoneName),
+			Content:  r.Content,
+			TTL:      r.TTL,
+			Priority: r.Priority,
+			Proxied:  r.Proxied,
+			Comment:  r.Comment,
+		}
+		if r.Data != nil {
+			record.Data = r.Data
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// DiffManagedRecords 在 DiffRecords 的基础上支持托管标记
+// managedBy 非空时：新记录的备注会写入托管标记，只有带该标记的现有记录会被更新或删除；
+// 与未托管记录内容完全相同的目标记录视为已存在，不做任何修改
+func DiffManagedRecords(current, desired []cloudflare.DNSRecord, prune bool, managedBy string) *ChangePlan {
+	if managedBy == "" {
+		return DiffRecords(current, desired, prune)
+	}
+
+	marker := managedByPrefix + managedBy
+
+	var managed, unmanaged []cloudflare.DNSRecord
+	for _, record := range current {
+		if hasMarker(record.Comment, marker) {
+			managed = append(managed, record)
+		} else {
+			unmanaged = append(unmanaged, record)
+		}
+	}
+
+	adopted := 0
+	var remaining []cloudflare.DNSRecord
+	for _, record := range desired {
+		if hasSameRecord(unmanaged, record) {
+			adopted++
+			continue
+		}
+		// 未写备注的目标记录保持现有备注（其中已有标记），只给新建的记录加标记
+		if record.Comment != "" {
+			record.Comment = withMarker(record.Comment, marker)
+		}
+		remaining = append(remaining, record)
+	}
+
+	plan := DiffRecords(managed, remaining, prune)
+	for _, change := range plan.Creates {
+		change.Desired.Comment = withMarker(change.Desired.Comment, marker)
+	}
+	plan.Unchanged += adopted
+	return plan
+}
+
+// ZoneIDByName 根据域名查找 Zone ID
+// SDK 的 ZoneIDByName 不接受 context，这里直接按名称筛选域名列表
+func (s *CloudflareService) ZoneIDByName(ctx context.Context, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	res, err := s.API.ListZonesContext(ctx, cloudflare.WithZoneFilters(name, "", ""))
+	if err != nil {
+		return "", err
+	}
+	for _, zone := range res.Result {
+		if zone.Name == name {
+			return zone.ID, nil
+		}
+	}
+	return "", fmt.Errorf("zone %s not found", name)
+}
+
+// hasSameRecord 判断记录集中是否存在类型、名称、内容均相同的记录
+func hasSameRecord(records []cloudflare.DNSRecord, record cloudflare.DNSRecord) bool {
+	name := normalizeName(record.Name)
+	key := RecordContentKey(record)
+	for _, r := range records {
+		if r.Type == record.Type && normalizeName(r.Name) == name && RecordContentKey(r) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMarker 备注中是否包含托管标记
+// 标记按空白分隔的完整单词比较，managed-by:ci 不会匹配 managed-by:ci-staging
+func hasMarker(comment, marker string) bool {
+	for _, word := range strings.Fields(comment) {
+		if word == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// withMarker 在备注末尾追加托管标记
+func withMarker(comment, marker string) string {
+	if hasMarker(comment, marker) {
+		return comment
+	}
+	if comment == "" {
+		return marker
+	}
+	return comment + " " + marker
+}
+
+// qualifyName 将相对名称补全为完整域名
+func qualifyName(name, zoneName string) string {
+	if name == "@" || name == "" {
+		return zoneName
+	}
+	if strings.HasSuffix(name, ".") {
+		return normalizeName(name)
+	}
+	name = strings.ToLower(name)
+	if name == zoneName || strings.HasSuffix(name, "."+zoneName) {
+		return name
+	}
+	return name + "." + zoneName
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func TestDesiredStateOmittedTTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zone.yaml")
+	state := `zones:
+  - name: example.com
+    records:
+      - type: A
+        name: www
+        content: 192.0.2.1
+      - type: A
+        name: api
+        content: 192.0.2.2
+      - type: MX
+        name: "@"
+        content: mx1.example.com
+        priority: 10
+        ttl: 3600
+`
+	if err := os.WriteFile(path, []byte(state), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	desired, err := LoadDesiredState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := desired.Zones[0].DNSRecords()
+	if records[0].TTL != 0 || records[2].TTL != 3600 {
+		t.Fatalf("TTLs = %d, %d; want 0 (unspecified) and 3600", records[0].TTL, records[2].TTL)
+	}
+
+	priority := uint16(10)
+	current := []cloudflare.DNSRecord{
+		{ID: "1", Type: "A", Name: "www.example.com", Content: "192.0.2.1", TTL: 600},
+		{ID: "2", Type: "MX", Name: "example.com", Content: "mx1.example.com", Priority: &priority, TTL: 300},
+	}
+	plan := DiffRecords(current, records, false)
+
+	// www 的 TTL 未指定，不应被改成自动
+	if len(plan.Updates) != 1 || plan.Updates[0].Current.ID != "2" {
+		t.Fatalf("updates = %+v, want only the MX TTL change", plan.Updates)
+	}
+	if len(plan.Creates) != 1 || plan.Creates[0].Desired.Name != "api.example.com" {
+		t.Fatalf("creates = %+v, want api.example.com", plan.Creates)
+	}
+	if params := CreateParamsFromRecord(*plan.Creates[0].Desired); params.TTL != 0 {
+		t.Errorf("create TTL = %d, want 0 so the API applies automatic TTL", params.TTL)
+	}
+}
+
+func TestDiffManagedRecords(t *testing.T) {
+	current := []cloudflare.DNSRecord{
+		{ID: "www", Type: "A", Name: "www.example.com", Content: "192.0.2.1", TTL: 1},
+		{ID: "api", Type: "A", Name: "api.example.com", Content: "192.0.2.2", TTL: 1, Comment: "managed-by:ci"},
+		{ID: "old", Type: "A", Name: "old.example.com", Content: "192.0.2.3", TTL: 1, Comment: "deploy managed-by:ci"},
+		{ID: "staging", Type: "A", Name: "stage.example.com", Content: "192.0.2.4", TTL: 1, Comment: "managed-by:ci-staging"},
+		{ID: "manual", Type: "TXT", Name: "example.com", Content: "hand-written", TTL: 1, Comment: "managed-by:cix note"},
+	}
+	a := func(name, content string) cloudflare.DNSRecord {
+		return cloudflare.DNSRecord{Type: "A", Name: name + ".example.com", Content: content}
+	}
+
+	tests := []struct {
+		name      string
+		desired   []cloudflare.DNSRecord
+		prune     bool
+		managedBy string
+		creates   []string // 新建记录的名称
+		updates   []string // 被更新的现有记录 ID
+		deletes   []string // 被删除的现有记录 ID
+		unchanged int
+	}{
+		{
+			name:      "adopt identical unmanaged record",
+			desired:   []cloudflare.DNSRecord{a("www", "192.0.2.1")},
+			managedBy: "ci",
+			unchanged: 1,
+		},
+		{
+			name:      "adopt record of another tool with the same content",
+			desired:   []cloudflare.DNSRecord{a("stage", "192.0.2.4")},
+			prune:     true,
+			managedBy: "ci",
+			deletes:   []string{"api", "old"},
+			unchanged: 1,
+		},
+		{
+			name:      "unmanaged record with different content is not touched",
+			desired:   []cloudflare.DNSRecord{a("www", "192.0.2.99")},
+			prune:     true,
+			managedBy: "ci",
+			creates:   []string{"www.example.com"},
+			deletes:   []string{"api", "old"},
+		},
+		{
+			name:      "prune only deletes managed records",
+			desired:   []cloudflare.DNSRecord{a("api", "192.0.2.2")},
+			prune:     true,
+			managedBy: "ci",
+			deletes:   []string{"old"},
+			unchanged: 1,
+		},
+		{
+			name:      "marker prefix does not claim other owners",
+			prune:     true,
+			managedBy: "ci",
+			deletes:   []string{"api", "old"},
+		},
+		{
+			name:      "the longer marker owns only its own records",
+			prune:     true,
+			managedBy: "ci-staging",
+			deletes:   []string{"staging"},
+		},
+		{
+			name:      "without prune nothing is deleted",
+			desired:   []cloudflare.DNSRecord{a("new", "192.0.2.5")},
+			managedBy: "ci",
+			creates:   []string{"new.example.com"},
+		},
+		{
+			name:      "prune pairs a changed managed record into an update and keeps its comment",
+			desired:   []cloudflare.DNSRecord{a("api", "192.0.2.9"), a("old", "192.0.2.3")},
+			prune:     true,
+			managedBy: "ci",
+			updates:   []string{"api"},
+			unchanged: 1,
+		},
+		{
+			name:      "a comment in the state keeps the marker",
+			desired:   []cloudflare.DNSRecord{{Type: "A", Name: "old.example.com", Content: "192.0.2.3", Comment: "deploy"}},
+			managedBy: "ci",
+			unchanged: 1,
+		},
+		{
+			name:      "a changed comment is updated and keeps the marker",
+			desired:   []cloudflare.DNSRecord{{Type: "A", Name: "old.example.com", Content: "192.0.2.3", Comment: "retired"}},
+			managedBy: "ci",
+			updates:   []string{"old"},
+		},
+		{
+			name:    "no marker prunes every record missing from the state",
+			desired: []cloudflare.DNSRecord{a("www", "192.0.2.1")},
+			prune:   true,
+			deletes: []string{"api", "manual", "old", "staging"},
+			// www 已存在且内容相同
+			unchanged: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := DiffManagedRecords(current, tt.desired, tt.prune, tt.managedBy)
+
+			var creates, updates, deletes []string
+			for _, c := range plan.Creates {
+				creates = append(creates, c.Desired.Name)
+				if tt.managedBy != "" && !hasMarker(c.Desired.Comment, managedByPrefix+tt.managedBy) {
+					t.Errorf("created record %s has comment %q without the marker", c.Desired.Name, c.Desired.Comment)
+				}
+			}
+			for _, c := range plan.Updates {
+				updates = append(updates, c.Current.ID)
+				if tt.managedBy != "" && !hasMarker(c.Desired.Comment, managedByPrefix+tt.managedBy) {
+					t.Errorf("update of %s drops the marker: %q", c.Current.ID, c.Desired.Comment)
+				}
+			}
+			for _, c := range plan.Deletes {
+				deletes = append(deletes, c.Current.ID)
+			}
+			sort.Strings(deletes)
+
+			if !reflect.DeepEqual(creates, tt.creates) {
+				t.Errorf("creates = %v, want %v", creates, tt.creates)
+			}
+			if !reflect.DeepEqual(updates, tt.updates) {
+				t.Errorf("updates = %v, want %v", updates, tt.updates)
+			}
+			if !reflect.DeepEqual(deletes, tt.deletes) {
+				t.Errorf("deletes = %v, want %v", deletes, tt.deletes)
+			}
+			if plan.Unchanged != tt.unchanged {
+				t.Errorf("unchanged = %d, want %d", plan.Unchanged, tt.unchanged)
+			}
+		})
+	}
+}
+
+func TestManagedMarker(t *testing.T) {
+	const marker = "managed-by:ci"
+	tests := []struct {
+		comment string
+		has     bool
+		with    string
+	}{
+		{"", false, "managed-by:ci"},
+		{"managed-by:ci", true, "managed-by:ci"},
+		{"web server managed-by:ci", true, "web server managed-by:ci"},
+		{"managed-by:ci\tsecond line", true, "managed-by:ci\tsecond line"},
+		{"managed-by:ci-staging", false, "managed-by:ci-staging managed-by:ci"},
+		{"xmanaged-by:ci", false, "xmanaged-by:ci managed-by:ci"},
+		{"managed-by:cix", false, "managed-by:cix managed-by:ci"},
+	}
+	for _, tt := range tests {
+		if got := hasMarker(tt.comment, marker); got != tt.has {
+			t.Errorf("hasMarker(%q) = %v, want %v", tt.comment, got, tt.has)
+		}
+		if got := withMarker(tt.comment, marker); got != tt.with {
+			t.Errorf("withMarker(%q) = %q, want %q", tt.comment, got, tt.with)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/miekg/dns"
+)
+
+// autoTTL Cloudflare 中 TTL=1 表示"自动"，实际下发的 TTL 为 300 秒
+const autoTTL = 300
+
+// ListAllDNSRecords 分页获取 Zone 的全部 DNS 记录
+func ListAllDNSRecords(ctx context.Context, s Client, zoneID string) ([]cloudflare.DNSRecord, error) {
+	rc := cloudflare.ZoneIdentifier(zoneID)
+
+	var all []cloudflare.DNSRecord
+	page := 1
+	for {
+		records, resultInfo, err := s.ListDNSRecords(ctx, rc, cloudflare.ListDNSRecordsParams{
+			ResultInfo: cloudflare.ResultInfo{
+				Page:    page,
+				PerPage: 100,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, records...)
+
+		if resultInfo == nil || page >= resultInfo.TotalPages || len(records) == 0 {
+			break
+		}
+		page++
+	}
+
+	return all, nil
+}
+
+// BuildZoneFile 将 DNS 记录导出为 RFC 1035 格式的 BIND zone 文件
+func BuildZoneFile(origin string, records []cloudflare.DNSRecord) (string, error) {
+	origin = dns.Fqdn(origin)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, ";; Zone: %s\n", strings.TrimSuffix(origin, "."))
+	fmt.Fprintf(&b, ";; Exported by Cloudflare DNS Manager at %s\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "$ORIGIN %s\n", origin)
+	fmt.Fprintf(&b, "$TTL %d\n\n", autoTTL)
+
+	for _, record := range records {
+		rr, err := RecordToRR(record)
+		if err != nil {
+			return "", fmt.Errorf("record %s %s: %w", record.Type, record.Name, err)
+		}
+
+		line := rr.String()
+		// 与 Cloudflare 官方导出保持一致，用注释标记代理状态
+		if record.Proxied != nil && *record.Proxied {
+			line += " ; cf_tags=cf-proxied:true"
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
+
+// RecordToRR 将 Cloudflare DNS 记录转换为 miekg/dns 的 RR
+func RecordToRR(record cloudflare.DNSRecord) (dns.RR, error) {
+	ttl := record.TTL
+	if ttl <= 1 {
+		ttl = autoTTL
+	}
+
+	hdr := dns.RR_Header{
+		Name:   dns.Fqdn(record.Name),
+		Class:  dns.ClassINET,
+		Ttl:    uint32(ttl),
+		Rrtype: dns.StringToType[record.Type],
+	}
+
+	switch record.Type {
+	case "A":
+		ip := net.ParseIP(record.Content)
+		if ip == nil || ip.To4() == nil {
+			return nil, fmt.Errorf("invalid IPv4 address %q", record.Content)
+		}
+		return &dns.A{Hdr: hdr, A: ip.To4()}, nil
+
+	case "AAAA":
+		ip := net.ParseIP(record.Content)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IPv6 address %q", record.Content)
+		}
+		return &dns.AAAA{Hdr: hdr, AAAA: ip}, nil
+
+	case "CNAME":
+		return &dns.CNAME{Hdr: hdr, Target: dns.Fqdn(record.Content)}, nil
+
+	case "NS":
+		return &dns.NS{Hdr: hdr, Ns: dns.Fqdn(record.Content)}, nil
+
+	case "PTR":
+		return &dns.PTR{Hdr: hdr, Ptr: dns.Fqdn(record.Content)}, nil
+
+	case "MX":
+		var pref uint16
+		if record.Priority != nil {
+			pref = *record.Priority
+		}
+		return &dns.MX{Hdr: hdr, Preference: pref, Mx: dns.Fqdn(record.Content)}, nil
+
+	case "TXT", "SPF":
+		return &dns.TXT{Hdr: hdr, Txt: SplitTXT(record.Content)}, nil
+
+	case "SRV":
+		return srvToRR(hdr, record)
+
+	case "CAA":
+		return caaToRR(hdr, record)
+	}
+
+	// 其他类型直接交给 miekg/dns 按标准文本格式解析
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", hdr.Name, hdr.Ttl, record.Type, record.Content))
+	if err != nil {
+		return nil, err
+	}
+	if rr == nil {
+		return nil, fmt.Errorf("empty record content")
+	}
+	return rr, nil
+}
+
+// SplitTXT 将 TXT 内容拆分为不超过 255 字节的字符串片段
+// Cloudflare 返回的内容可能已经是带引号的多段形式，此时按原分段解析
+func SplitTXT(content string) []string {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, `"`) && strings.HasSuffix(trimmed, `"`) && len(trimmed) >= 2 {
+		if rr, err := dns.NewRR(". 1 IN TXT " + trimmed); err == nil && rr != nil {
+			return rr.(*dns.TXT).Txt
+		}
+	}
+
+	if len(content) <= 255 {
+		return []string{content}
+	}
+
+	var chunks []string
+	for len(content) > 255 {
+		chunks = append(chunks, content[:255])
+		content = content[255:]
+	}
+	if content != "" {
+		chunks = append(chunks, content)
+	}
+	return chunks
+}
+
+// srvToRR 从 Data 字段（优先）或 Content 构造 SRV 记录
+func srvToRR(hdr dns.RR_Header, record cloudflare.DNSRecord) (dns.RR, error) {
+	srv := &dns.SRV{Hdr: hdr}
+	if record.Priority != nil {
+		srv.Priority = *record.Priority
+	}
+
+	if data, ok := record.Data.(map[string]interface{}); ok && data["target"] != nil {
+		srv.Priority = uint16(dataInt(data, "priority"))
+		srv.Weight = uint16(dataInt(data, "weight"))
+		srv.Port = uint16(dataInt(data, "port"))
+		srv.Target = dns.Fqdn(fmt.Sprint(data["target"]))
+		return srv, nil
+	}
+
+	// Content 格式: "weight port target"
+	fields := strings.Fields(record.Content)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("invalid SRV content %q", record.Content)
+	}
+	weight, err1 := strconv.ParseUint(fields[0], 10, 16)
+	port, err2 := strconv.ParseUint(fields[1], 10, 16)
+	if err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("invalid SRV content %q", record.Content)
+	}
+	srv.Weight = uint16(weight)
+	srv.Port = uint16(port)
+	srv.Target = dns.Fqdn(fields[2])
+	return srv, nil
+}
+
+// caaToRR 从 Data 字段（优先）或 Content 构造 CAA 记录
+func caaToRR(hdr dns.RR_Header, record cloudflare.DNSRecord) (dns.RR, error) {
+	if data, ok := record.Data.(map[string]interface{}); ok && data["tag"] != nil {
+		return &dns.CAA{
+			Hdr:   hdr,
+			Flag:  uint8(dataInt(data, "flags")),
+			Tag:   fmt.Sprint(data["tag"]),
+			Value: fmt.Sprint(data["value"]),
+		}, nil
+	}
+
+	// Content 格式: `0 issue "letsencrypt.org"`
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN CAA %s", hdr.Name, hdr.Ttl, record.Content))
+	if err != nil {
+		return nil, err
+	}
+	if rr == nil {
+		return nil, fmt.Errorf("empty CAA content")
+	}
+	return rr, nil
+}
+
+// dataInt 从 Data map 中读取数值字段（JSON 数字解码为 float64）
+func dataInt(data map[string]interface{}, key string) int {
+	switch v := data[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// SkippedRecord 导入时被跳过的记录
+type SkippedRecord struct {
+	Line   string
+	Reason string
+}
+
+// ParseZoneFile 解析 BIND zone 文件，返回可导入 Cloudflare 的记录
+// SOA、顶点 NS 以及不属于该 Zone 的记录会被跳过（由 Cloudflare 自行管理）
+func ParseZoneFile(origin, content string) ([]cloudflare.DNSRecord, []SkippedRecord, error) {
+	origin = dns.Fqdn(origin)
+	zoneName := normalizeName(origin)
+
+	zp := dns.NewZoneParser(strings.NewReader(content), origin, "")
+	zp.SetDefaultTTL(autoTTL)
+
+	var records []cloudflare.DNSRecord
+	var skipped []SkippedRecord
+
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		hdr := rr.Header()
+		name := normalizeName(hdr.Name)
+
+		switch {
+		case hdr.Rrtype == dns.TypeSOA:
+			skipped = append(skipped, SkippedRecord{Line: rr.String(), Reason: "SOA 由 Cloudflare 管理"})
+			continue
+		case hdr.Rrtype == dns.TypeNS && name == zoneName:
+			skipped = append(skipped, SkippedRecord{Line: rr.String(), Reason: "顶点 NS 由 Cloudflare 管理"})
+			continue
+		case name != zoneName && !strings.HasSuffix(name, "."+zoneName):
+			skipped = append(skipped, SkippedRecord{Line: rr.String(), Reason: "记录不属于该域名"})
+			continue
+		}
+
+		record, err := RRToRecord(rr)
+		if err != nil {
+			skipped = append(skipped, SkippedRecord{Line: rr.String(), Reason: err.Error()})
+			continue
+		}
+
+		// 识别 Cloudflare 导出文件中的代理标记
+		if strings.Contains(zp.Comment(), "cf-proxied:true") {
+			proxied := true
+			record.Proxied = &proxied
+		} else if strings.Contains(zp.Comment(), "cf-proxied:false") {
+			proxied := false
+			record.Proxied = &proxied
+		}
+
+		records = append(records, record)
+	}
+
+	if err := zp.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return records, skipped, nil
+}
+
+// RRToRecord 将 miekg/dns 的 RR 转换为 Cloudflare DNS 记录
+func RRToRecord(rr dns.RR) (cloudflare.DNSRecord, error) {
+	hdr := rr.Header()
+	record := cloudflare.DNSRecord{
+		Type: dns.TypeToString[hdr.Rrtype],
+		Name: normalizeName(hdr.Name),
+		TTL:  int(hdr.Ttl),
+	}
+
+	switch v := rr.(type) {
+	case *dns.A:
+		record.Content = v.A.String()
+	case *dns.AAAA:
+		record.Content = v.AAAA.String()
+	case *dns.CNAME:
+		record.Content = strings.TrimSuffix(v.Target, ".")
+	case *dns.NS:
+		record.Content = strings.TrimSuffix(v.Ns, ".")
+	case *dns.PTR:
+		record.Content = strings.TrimSuffix(v.Ptr, ".")
+	case *dns.MX:
+		record.Content = strings.TrimSuffix(v.Mx, ".")
+		pref := v.Preference
+		record.Priority = &pref
+	case *dns.TXT:
+		record.Content = strings.Join(v.Txt, "")
+	case *dns.SRV:
+		record.Content = fmt.Sprintf("%d %d %s", v.Weight, v.Port, strings.TrimSuffix(v.Target, "."))
+		prio := v.Priority
+		record.Priority = &prio
+		record.Data = map[string]interface{}{
+			"priority": int(v.Priority),
+			"weight":   int(v.Weight),
+			"port":     int(v.Port),
+			"target":   strings.TrimSuffix(v.Target, "."),
+		}
+	case *dns.CAA:
+		record.Content = fmt.Sprintf("%d %s %q", v.Flag, v.Tag, v.Value)
+		record.Data = map[string]interface{}{
+			"flags": int(v.Flag),
+			"tag":   v.Tag,
+			"value": v.Value,
+		}
+	default:
+		if record.Type == "" {
+			return record, fmt.Errorf("unsupported record type %d", hdr.Rrtype)
+		}
+		record.Content = strings.TrimSpace(strings.TrimPrefix(rr.String(), hdr.String()))
+	}
+
+	return record, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func TestZoneFileRoundTrip(t *testing.T) {
+	proxied, direct := true, false
+	prio := func(p uint16) *uint16 { return &p }
+	longTXT := "v=DKIM1; k=rsa; p=" + strings.Repeat("A", 400)
+
+	exported := []cloudflare.DNSRecord{
+		{Type: "A", Name: "example.com", Content: "192.0.2.1", TTL: 1, Proxied: &proxied},
+		{Type: "A", Name: "direct.example.com", Content: "192.0.2.2", TTL: 3600, Proxied: &direct},
+		{Type: "AAAA", Name: "www.example.com", Content: "2001:db8::1", TTL: 600},
+		{Type: "CNAME", Name: "blog.example.com", Content: "example.com", TTL: 1, Proxied: &proxied},
+		{Type: "MX", Name: "example.com", Content: "mx1.example.com", TTL: 3600, Priority: prio(10)},
+		{Type: "TXT", Name: "example.com", Content: "v=spf1 include:_spf.example.com ~all", TTL: 1},
+		{Type: "TXT", Name: "s1._domainkey.example.com", Content: longTXT, TTL: 1},
+		{Type: "NS", Name: "sub.example.com", Content: "ns1.other.test", TTL: 86400},
+		// Data 来自 JSON 解码，数值为 float64
+		{Type: "SRV", Name: "_sip._tcp.example.com", TTL: 1, Data: map[string]interface{}{
+			"priority": float64(10), "weight": float64(5), "port": float64(5060), "target": "sip.example.com",
+		}},
+		{Type: "CAA", Name: "example.com", TTL: 1, Data: map[string]interface{}{
+			"flags": float64(0), "tag": "issue", "value": "letsencrypt.org",
+		}},
+		{Type: "SSHFP", Name: "host.example.com", Content: "4 2 123456789ABCDEF67890123456789ABCDEF67890123456789ABCDEF123456", TTL: 1},
+	}
+
+	text, err := BuildZoneFile("example.com", exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 导出文件中加入 SOA 和顶点 NS，导入时应跳过
+	text += "example.com. 3600 IN SOA ns.cloudflare.com. dns.cloudflare.com. 1 10000 2400 604800 3600\n"
+	text += "example.com. 86400 IN NS ada.ns.cloudflare.com.\n"
+	text += "other.test. 300 IN A 192.0.2.9\n"
+
+	parsed, skipped, err := ParseZoneFile("example.com", text)
+	if err != nil {
+		t.Fatalf("ParseZoneFile: %v\n%s", err, text)
+	}
+	if len(skipped) != 3 {
+		t.Errorf("skipped = %+v, want SOA, apex NS and out-of-zone record", skipped)
+	}
+	if len(parsed) != len(exported) {
+		t.Fatalf("parsed %d records, want %d:\n%s", len(parsed), len(exported), text)
+	}
+
+	for i, want := range exported {
+		got := parsed[i]
+		if got.Type != want.Type || got.Name != want.Name {
+			t.Errorf("record %d = %s %s, want %s %s", i, got.Type, got.Name, want.Type, want.Name)
+			continue
+		}
+		// 自动 TTL 导出为 300 秒，重新导入后不再是自动
+		wantTTL := want.TTL
+		if wantTTL == 1 {
+			wantTTL = autoTTL
+		}
+		if got.TTL != wantTTL {
+			t.Errorf("%s %s TTL = %d, want %d", want.Type, want.Name, got.TTL, wantTTL)
+		}
+		if want.Proxied != nil && *want.Proxied != (got.Proxied != nil && *got.Proxied) {
+			t.Errorf("%s %s proxied = %v, want %v", want.Type, want.Name, got.Proxied, *want.Proxied)
+		}
+		if want.Priority != nil && (got.Priority == nil || *got.Priority != *want.Priority) {
+			t.Errorf("%s %s priority = %v, want %d", want.Type, want.Name, got.Priority, *want.Priority)
+		}
+
+		switch want.Type {
+		case "SRV", "CAA":
+			// 导入得到的 Data 为 int，与导出时的 float64 逐项比较数值
+			gotData, _ := got.Data.(map[string]interface{})
+			for key, v := range want.Data.(map[string]interface{}) {
+				wantValue := v
+				if f, ok := v.(float64); ok {
+					wantValue = int(f)
+				}
+				if !reflect.DeepEqual(gotData[key], wantValue) {
+					t.Errorf("%s %s data[%s] = %#v, want %#v", want.Type, want.Name, key, gotData[key], wantValue)
+				}
+			}
+		case "SSHFP":
+			if !strings.EqualFold(got.Content, want.Content) {
+				t.Errorf("SSHFP content = %q, want %q", got.Content, want.Content)
+			}
+		default:
+			if got.Content != want.Content {
+				t.Errorf("%s %s content = %q, want %q", want.Type, want.Name, got.Content, want.Content)
+			}
+		}
+	}
+
+	// 再次导出的文件与第一次一致（忽略头部的导出时间）
+	again, err := BuildZoneFile("example.com", parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zoneBody(again) != zoneBody(strings.SplitN(text, "example.com. 3600 IN SOA", 2)[0]) {
+		t.Errorf("second export differs:\n%s\nwant:\n%s", again, text)
+	}
+}
+
+// zoneBody 去掉 zone 文件中的注释行
+func zoneBody(zone string) string {
+	var lines []string
+	for _, line := range strings.Split(zone, "\n") {
+		if !strings.HasPrefix(line, ";;") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestSplitTXT(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"hello", []string{"hello"}},
+		{`"part1" "part2"`, []string{"part1", "part2"}},
+		{strings.Repeat("x", 300), []string{strings.Repeat("x", 255), strings.Repeat("x", 45)}},
+	}
+	for _, tt := range tests {
+		if got := SplitTXT(tt.content); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitTXT(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
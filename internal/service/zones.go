@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// ZonesPerPage 域名列表每页数量
+const ZonesPerPage = 20
+
+// ZoneStatuses 可筛选的域名状态
+var ZoneStatuses = []string{"active", "pending", "initializing", "moved", "deactivated"}
+
+// ZonePlans 可筛选的套餐（对应 plan.legacy_id）
+var ZonePlans = []string{"free", "pro", "business", "enterprise"}
+
+// ZoneQuery 域名列表的搜索、筛选、排序和分页条件
+type ZoneQuery struct {
+	Page      int
+	Name      string // 名称包含
+	Status    string
+	Plan      string // 接口不支持按套餐筛选，需要在完整列表上过滤
+	Order     string // name / status
+	Direction string // asc / desc
+}
+
+// Normalize 修正非法取值
+func (q ZoneQuery) Normalize() ZoneQuery {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	q.Name = strings.ToLower(strings.TrimSpace(q.Name))
+	if !containsString(ZoneStatuses, q.Status) {
+		q.Status = ""
+	}
+	if !containsString(ZonePlans, q.Plan) {
+		q.Plan = ""
+	}
+	if q.Order != "status" {
+		q.Order = "name"
+	}
+	if q.Direction != "desc" {
+		q.Direction = "asc"
+	}
+	return q
+}
+
+// Values 转换为页面链接参数（不含页码），默认值省略
+func (q ZoneQuery) Values() url.Values {
+	v := url.Values{}
+	if q.Name != "" {
+		v.Set("name", q.Name)
+	}
+	if q.Status != "" {
+		v.Set("status", q.Status)
+	}
+	if q.Plan != "" {
+		v.Set("plan", q.Plan)
+	}
+	if q.Order != "name" {
+		v.Set("order", q.Order)
+	}
+	if q.Direction != "asc" {
+		v.Set("direction", q.Direction)
+	}
+	return v
+}
+
+// ListZones 按条件分页获取域名列表，由 Cloudflare 接口完成筛选、排序和分页
+// SDK 的 ListZonesContext 会自动翻完所有页，这里直接请求单页
+func (s *CloudflareService) ListZones(ctx context.Context, q ZoneQuery) ([]cloudflare.Zone, *cloudflare.ResultInfo, error) {
+	q = q.Normalize()
+	params := url.Values{}
+	params.Set("page", strconv.Itoa(q.Page))
+	params.Set("per_page", strconv.Itoa(ZonesPerPage))
+	params.Set("order", q.Order)
+	params.Set("direction", q.Direction)
+	if q.Name != "" {
+		params.Set("name", "contains:"+q.Name)
+	}
+	if q.Status != "" {
+		params.Set("status", q.Status)
+	}
+
+	raw, err := s.API.Raw(ctx, "GET", "/zones?"+params.Encode(), nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var zones []cloudflare.Zone
+	if err := json.Unmarshal(raw.Result, &zones); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal zones: %w", err)
+	}
+	resultInfo := raw.ResultInfo
+	if resultInfo == nil {
+		_, _, resultInfo = Paginate(len(zones), q.Page, ZonesPerPage)
+	}
+	return zones, resultInfo, nil
+}
+
+// Match 域名是否满足筛选条件（q 需已 Normalize）
+func (q ZoneQuery) Match(z cloudflare.Zone) bool {
+	if q.Name != "" && !strings.Contains(strings.ToLower(z.Name), q.Name) {
+		return false
+	}
+	if q.Status != "" && z.Status != q.Status {
+		return false
+	}
+	return q.Plan == "" || z.Plan.LegacyID == q.Plan
+}
+
+// Less 按排序条件比较两个域名（q 需已 Normalize），同状态时按名称排序
+func (q ZoneQuery) Less(a, b cloudflare.Zone) bool {
+	if q.Direction == "desc" {
+		a, b = b, a
+	}
+	if q.Order == "status" && a.Status != b.Status {
+		return a.Status < b.Status
+	}
+	return a.Name < b.Name
+}
+
+// FilterZones 在完整列表上按条件筛选和排序（按套餐筛选时使用）
+func FilterZones(zones []cloudflare.Zone, q ZoneQuery) []cloudflare.Zone {
+	q = q.Normalize()
+	var out []cloudflare.Zone
+	for _, z := range zones {
+		if q.Match(z) {
+			out = append(out, z)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return q.Less(out[i], out[j]) })
+	return out
+}
+
+// ZoneCache 按账户缓存完整域名列表和分页结果，避免每次打开列表都请求 Cloudflare
+type ZoneCache struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]zoneCacheEntry
+}
+
+type zoneCacheEntry struct {
+	zones      []cloudflare.Zone
+	resultInfo *cloudflare.ResultInfo
+	expires    time.Time
+}
+
+// NewZoneCache 创建缓存，ttl <= 0 时不缓存
+func NewZoneCache(ttl time.Duration) *ZoneCache {
+	return &ZoneCache{
+		TTL:     ttl,
+		entries: make(map[string]zoneCacheEntry),
+	}
+}
+
+// List 获取一页域名；Cloudflare 接口不支持按套餐筛选，此时需要获取全部域名，在缓存的完整列表上过滤
+func (c *ZoneCache) List(ctx context.Context, account string, s Client, q ZoneQuery) ([]cloudflare.Zone, *cloudflare.ResultInfo, error) {
+	q = q.Normalize()
+	if q.Plan != "" {
+		all, err := c.All(ctx, account, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		zones := FilterZones(all, q)
+		start, end, resultInfo := Paginate(len(zones), q.Page, ZonesPerPage)
+		return zones[start:end], resultInfo, nil
+	}
+
+	key := account + "?" + q.Values().Encode() + "&page=" + strconv.Itoa(q.Page)
+	if entry, ok := c.get(key); ok {
+		return entry.zones, entry.resultInfo, nil
+	}
+	zones, resultInfo, err := s.ListZones(ctx, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.set(key, zoneCacheEntry{zones: zones, resultInfo: resultInfo})
+	return zones, resultInfo, nil
+}
+
+// All 获取账户下的全部域名
+func (c *ZoneCache) All(ctx context.Context, account string, s Client) ([]cloudflare.Zone, error) {
+	key := account + "#all"
+	if entry, ok := c.get(key); ok {
+		return entry.zones, nil
+	}
+	zones, err := s.ListAllZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.set(key, zoneCacheEntry{zones: zones})
+	return zones, nil
+}
+
+// Invalidate 清除账户的全部缓存（添加、删除域名后调用）
+func (c *ZoneCache) Invalidate(account string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, account+"?") || key == account+"#all" {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *ZoneCache) get(key string) (zoneCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return zoneCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *ZoneCache) set(key string, entry zoneCacheEntry) {
+	if c.TTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	// 顺便清理过期条目，避免搜索词过多时无限增长
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	entry.expires = now.Add(c.TTL)
+	c.entries[key] = entry
+}
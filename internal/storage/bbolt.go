@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"encoding/binary"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var bboltBucket = []byte("fiber_storage")
+
+// Bbolt 基于 bbolt 的本地文件存储
+// 值的格式: 8 字节过期时间戳（大端） + 原始数据
+type Bbolt struct {
+	db   *bolt.DB
+	done chan struct{}
+}
+
+// NewBbolt 打开（或创建）bbolt 数据库文件
+func NewBbolt(path string, gcInterval time.Duration) (*Bbolt, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bboltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &Bbolt{
+		db:   db,
+		done: make(chan struct{}),
+	}
+	go s.gcTicker(gcInterval)
+	return s, nil
+}
+
+// Get 读取值，过期或不存在时返回 nil
+func (s *Bbolt) Get(key string) ([]byte, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(bboltBucket).Get([]byte(key))
+		if len(raw) < 8 {
+			return nil
+		}
+		if expired(int64(binary.BigEndian.Uint64(raw[:8])), time.Now().Unix()) {
+			return nil
+		}
+		value = append([]byte(nil), raw[8:]...)
+		return nil
+	})
+	return value, err
+}
+
+// Set 写入值，exp 为 0 表示永不过期
+func (s *Bbolt) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+
+	raw := make([]byte, 8+len(val))
+	binary.BigEndian.PutUint64(raw[:8], uint64(expiresAt(exp)))
+	copy(raw[8:], val)
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bboltBucket).Put([]byte(key), raw)
+	})
+}
+
+// Delete 删除值
+func (s *Bbolt) Delete(key string) error {
+	if key == "" {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bboltBucket).Delete([]byte(key))
+	})
+}
+
+// Reset 清空全部数据
+func (s *Bbolt) Reset() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bboltBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(bboltBucket)
+		return err
+	})
+}
+
+// Close 停止清理任务并关闭数据库
+func (s *Bbolt) Close() error {
+	close(s.done)
+	return s.db.Close()
+}
+
+// gcTicker 定期清理过期数据
+func (s *Bbolt) gcTicker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.gc()
+		}
+	}
+}
+
+// gc 删除所有已过期的键
+func (s *Bbolt) gc() {
+	now := time.Now().Unix()
+	s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bboltBucket)
+		var keys [][]byte
+		b.ForEach(func(k, v []byte) error {
+			if len(v) < 8 || expired(int64(binary.BigEndian.Uint64(v[:8])), now) {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range keys {
+			b.Delete(k)
+		}
+		return nil
+	})
+}
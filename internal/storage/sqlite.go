@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLite 基于 SQLite 的本地文件存储
+type SQLite struct {
+	db   *sql.DB
+	done chan struct{}
+}
+
+// NewSQLite 打开（或创建）SQLite 数据库文件
+func NewSQLite(path string, gcInterval time.Duration) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS fiber_storage (
+		k TEXT PRIMARY KEY,
+		v BLOB NOT NULL,
+		e INTEGER NOT NULL DEFAULT 0
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &SQLite{
+		db:   db,
+		done: make(chan struct{}),
+	}
+	go s.gcTicker(gcInterval)
+	return s, nil
+}
+
+// Get 读取值，过期或不存在时返回 nil
+func (s *SQLite) Get(key string) ([]byte, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	var value []byte
+	var exp int64
+	err := s.db.QueryRow(`SELECT v, e FROM fiber_storage WHERE k = ?`, key).Scan(&value, &exp)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expired(exp, time.Now().Unix()) {
+		return nil, nil
+	}
+	return value, nil
+}
+
+// Set 写入值，exp 为 0 表示永不过期
+func (s *SQLite) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO fiber_storage (k, v, e) VALUES (?, ?, ?)`, key, val, expiresAt(exp))
+	return err
+}
+
+// Delete 删除值
+func (s *SQLite) Delete(key string) error {
+	if key == "" {
+		return nil
+	}
+	_, err := s.db.Exec(`DELETE FROM fiber_storage WHERE k = ?`, key)
+	return err
+}
+
+// Reset 清空全部数据
+func (s *SQLite) Reset() error {
+	_, err := s.db.Exec(`DELETE FROM fiber_storage`)
+	return err
+}
+
+// Close 停止清理任务并关闭数据库
+func (s *SQLite) Close() error {
+	close(s.done)
+	return s.db.Close()
+}
+
+// gcTicker 定期清理过期数据
+func (s *SQLite) gcTicker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.gc()
+		}
+	}
+}
+
+// gc 删除所有已过期的行
+func (s *SQLite) gc() {
+	s.db.Exec(`DELETE FROM fiber_storage WHERE e != 0 AND e <= ?`, time.Now().Unix())
+}
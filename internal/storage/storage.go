@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/storage/memory/v2"
+)
+
+// 存储类型
+const (
+	TypeMemory = "memory"
+	TypeBbolt  = "bbolt"
+	TypeSQLite = "sqlite"
+)
+
+// New 根据类型创建 fiber.Storage
+// memory 重启后数据丢失；bbolt / sqlite 持久化到本地文件，重启后会话仍然有效
+func New(kind, path string, gcInterval time.Duration) (fiber.Storage, error) {
+	if gcInterval <= 0 {
+		gcInterval = 10 * time.Minute
+	}
+
+	switch kind {
+	case TypeMemory, "":
+		return memory.New(memory.Config{GCInterval: gcInterval}), nil
+	case TypeBbolt, TypeSQLite:
+		if path == "" {
+			return nil, fmt.Errorf("storage path is required for %s", kind)
+		}
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o700); err != nil {
+				return nil, fmt.Errorf("failed to create storage directory: %w", err)
+			}
+		}
+		if kind == TypeBbolt {
+			return NewBbolt(path, gcInterval)
+		}
+		return NewSQLite(path, gcInterval)
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", kind)
+	}
+}
+
+// expiresAt 计算过期时间戳（0 表示永不过期）
+func expiresAt(exp time.Duration) int64 {
+	if exp <= 0 {
+		return 0
+	}
+	return time.Now().Add(exp).Unix()
+}
+
+// expired 判断时间戳是否已过期
+func expired(ts int64, now int64) bool {
+	return ts != 0 && ts <= now
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	bolt "go.etcd.io/bbolt"
+)
+
+// testStorage 对存储后端执行写入、读取和删除
+func testStorage(t *testing.T, s fiber.Storage) {
+	t.Helper()
+
+	if err := s.Set("session", []byte("value"), 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	got, err := s.Get("session")
+	if err != nil || string(got) != "value" {
+		t.Fatalf("Get = %q, %v; want value", got, err)
+	}
+
+	if err := s.Delete("session"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got, _ := s.Get("session"); got != nil {
+		t.Fatalf("Get after Delete = %q, want nil", got)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now().Unix()
+	if expired(expiresAt(0), now+3600) {
+		t.Error("exp 0 should never expire")
+	}
+	if !expired(expiresAt(time.Second), now+2) {
+		t.Error("entry should expire after its duration")
+	}
+	if expired(expiresAt(time.Minute), now) {
+		t.Error("entry expired too early")
+	}
+}
+
+func TestSQLite(t *testing.T) {
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "sessions.db"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	testStorage(t, s)
+}
+
+func TestBbolt(t *testing.T) {
+	s, err := NewBbolt(filepath.Join(t.TempDir(), "sessions.db"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	testStorage(t, s)
+}
+
+// testGC 写入短期和长期条目，过期后执行 gc，检查底层数据中只剩未过期的键
+func testGC(t *testing.T, s fiber.Storage, gc func(), stored func(key string) bool) {
+	t.Helper()
+
+	entries := map[string]time.Duration{
+		"short":   time.Second,
+		"long":    time.Hour,
+		"forever": 0,
+	}
+	for key, exp := range entries {
+		if err := s.Set(key, []byte("value"), exp); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+	gc()
+	if !stored("short") {
+		t.Fatal("gc removed an entry before it expired")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	gc()
+	if stored("short") {
+		t.Error("expired entry is still stored after gc")
+	}
+	if !stored("long") || !stored("forever") {
+		t.Error("gc removed entries that have not expired")
+	}
+}
+
+func TestSQLiteGC(t *testing.T) {
+	t.Parallel()
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "sessions.db"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	testGC(t, s, s.gc, func(key string) bool {
+		var n int
+		if err := s.db.QueryRow(`SELECT COUNT(*) FROM fiber_storage WHERE k = ?`, key).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n > 0
+	})
+}
+
+func TestBboltGC(t *testing.T) {
+	t.Parallel()
+	s, err := NewBbolt(filepath.Join(t.TempDir(), "sessions.db"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	testGC(t, s, s.gc, func(key string) bool {
+		var found bool
+		s.db.View(func(tx *bolt.Tx) error {
+			found = tx.Bucket(bboltBucket).Get([]byte(key)) != nil
+			return nil
+		})
+		return found
+	})
+}
@@ -0,0 +1,68 @@
+package validation
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// Conflicts 检查新记录与 Zone 中同名记录的冲突
+//
+// 子域名的 CNAME 不能与任何其他记录共存；根域名的 CNAME 由 Cloudflare 展平（CNAME Flattening），
+// 可以与 MX、TXT 等共存，但不能与 A、AAAA 或另一条 CNAME 共存。同一名称只能有一条 SPF 记录。
+func Conflicts(r Record, zone string, existing []cloudflare.DNSRecord) Errors {
+	var errs Errors
+
+	apex := r.Name == FQDN("@", zone)
+	spf := r.Type == "TXT" && isSPFContent(r.Content)
+
+	conflicting := make(map[string]bool)
+	hasSPF := false
+	for _, rec := range existing {
+		if rec.ID == r.ID || !strings.EqualFold(strings.TrimSuffix(rec.Name, "."), r.Name) {
+			continue
+		}
+		if spf && rec.Type == "TXT" && isSPFContent(rec.Content) {
+			hasSPF = true
+		}
+		if r.Type != "CNAME" && rec.Type != "CNAME" {
+			continue
+		}
+		if apex && !cnameFlattenConflict(r.Type, rec.Type) {
+			continue
+		}
+		conflicting[rec.Type] = true
+	}
+
+	if len(conflicting) > 0 {
+		types := make([]string, 0, len(conflicting))
+		for t := range conflicting {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		messageID := "validation_cname_coexist"
+		if apex {
+			messageID = "validation_cname_apex"
+		}
+		errs.add("name", messageID, map[string]interface{}{"Types": strings.Join(types, ", ")})
+	}
+	if hasSPF {
+		errs.add("content", "validation_spf_multiple", nil)
+	}
+	return errs
+}
+
+// cnameFlattenConflict 根域名上 CNAME 展平后会与 A、AAAA 和其他 CNAME 冲突
+func cnameFlattenConflict(a, b string) bool {
+	flattened := func(t string) bool { return t == "A" || t == "AAAA" || t == "CNAME" }
+	return flattened(a) && flattened(b)
+}
+
+func isSPFContent(content string) bool {
+	chunks, err := TXTStrings(content)
+	if err != nil {
+		return false
+	}
+	return IsSPF(strings.Join(chunks, ""))
+}
@@ -0,0 +1,83 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func TestConflicts(t *testing.T) {
+	existing := []cloudflare.DNSRecord{
+		{ID: "apex-a", Type: "A", Name: "example.com", Content: "192.0.2.1"},
+		{ID: "apex-mx", Type: "MX", Name: "example.com", Content: "mx1.example.com"},
+		{ID: "apex-spf", Type: "TXT", Name: "example.com", Content: "v=spf1 mx -all"},
+		{ID: "www-a", Type: "A", Name: "WWW.example.com.", Content: "192.0.2.2"},
+		{ID: "www-aaaa", Type: "AAAA", Name: "www.example.com", Content: "2001:db8::2"},
+		{ID: "blog-cname", Type: "CNAME", Name: "blog.example.com", Content: "host.example.net"},
+		{ID: "mail-txt", Type: "TXT", Name: "mail.example.com", Content: "hello"},
+		{ID: "quoted-spf", Type: "TXT", Name: "quoted.example.com", Content: `"v=spf1 " "-all"`},
+	}
+
+	tests := []struct {
+		name   string
+		record Record
+		want   string
+		types  string // 冲突的记录类型
+	}{
+		// 子域名 CNAME 不能与任何记录共存
+		{"CNAME next to A and AAAA", Record{Type: "CNAME", Name: "www.example.com", Content: "target.example.net"}, "name:validation_cname_coexist", "A, AAAA"},
+		{"CNAME next to TXT", Record{Type: "CNAME", Name: "mail.example.com", Content: "target.example.net"}, "name:validation_cname_coexist", "TXT"},
+		{"A next to CNAME", Record{Type: "A", Name: "blog.example.com", Content: "192.0.2.3"}, "name:validation_cname_coexist", "CNAME"},
+		{"TXT next to CNAME", Record{Type: "TXT", Name: "blog.example.com", Content: "hello"}, "name:validation_cname_coexist", "CNAME"},
+		{"second CNAME", Record{Type: "CNAME", Name: "blog.example.com", Content: "other.example.net"}, "name:validation_cname_coexist", "CNAME"},
+		{"CNAME on a free name", Record{Type: "CNAME", Name: "new.example.com", Content: "target.example.net"}, "", ""},
+		{"A next to A", Record{Type: "A", Name: "www.example.com", Content: "192.0.2.9"}, "", ""},
+
+		// 编辑时排除自身
+		{"editing the CNAME itself", Record{ID: "blog-cname", Type: "CNAME", Name: "blog.example.com", Content: "other.example.net"}, "", ""},
+		{"turning the only record into a CNAME", Record{ID: "mail-txt", Type: "CNAME", Name: "mail.example.com", Content: "target.example.net"}, "", ""},
+
+		// 根域名 CNAME 展平后只与 A、AAAA、CNAME 冲突
+		{"apex CNAME next to A", Record{Type: "CNAME", Name: "example.com", Content: "target.example.net"}, "name:validation_cname_apex", "A"},
+		{"apex CNAME replacing A", Record{ID: "apex-a", Type: "CNAME", Name: "example.com", Content: "target.example.net"}, "", ""},
+		{"apex MX", Record{Type: "MX", Name: "example.com", Content: "mx2.example.com"}, "", ""},
+
+		// 同名只能有一条 SPF
+		{"second SPF", Record{Type: "TXT", Name: "example.com", Content: "v=spf1 a -all"}, "content:validation_spf_multiple", ""},
+		{"second quoted SPF", Record{Type: "TXT", Name: "quoted.example.com", Content: "v=spf1 a -all"}, "content:validation_spf_multiple", ""},
+		{"editing the SPF itself", Record{ID: "apex-spf", Type: "TXT", Name: "example.com", Content: "v=spf1 a -all"}, "", ""},
+		{"other TXT next to SPF", Record{Type: "TXT", Name: "example.com", Content: "google-site-verification=abc"}, "", ""},
+		{"SPF on another name", Record{Type: "TXT", Name: "mail.example.com", Content: "v=spf1 -all"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Conflicts(tt.record, "example.com", existing)
+			if got := errorIDs(errs); got != tt.want {
+				t.Fatalf("Conflicts(%+v) = %q, want %q", tt.record, got, tt.want)
+			}
+			if tt.types != "" && errs[0].Data["Types"] != tt.types {
+				t.Errorf("conflicting types = %v, want %q", errs[0].Data["Types"], tt.types)
+			}
+		})
+	}
+
+	// 根域名 A 与根域名 CNAME 冲突
+	withApexCNAME := []cloudflare.DNSRecord{
+		{ID: "apex-cname", Type: "CNAME", Name: "example.com", Content: "target.example.net"},
+		{ID: "apex-txt", Type: "TXT", Name: "example.com", Content: "hello"},
+	}
+	apexTests := []struct {
+		record Record
+		want   string
+	}{
+		{Record{Type: "A", Name: "example.com", Content: "192.0.2.1"}, "name:validation_cname_apex"},
+		{Record{Type: "AAAA", Name: "example.com", Content: "2001:db8::1"}, "name:validation_cname_apex"},
+		{Record{Type: "TXT", Name: "example.com", Content: "more text"}, ""},
+		{Record{Type: "MX", Name: "example.com", Content: "mx1.example.com"}, ""},
+	}
+	for _, tt := range apexTests {
+		if got := errorIDs(Conflicts(tt.record, "example.com.", withApexCNAME)); got != tt.want {
+			t.Errorf("Conflicts(%+v) with apex CNAME = %q, want %q", tt.record, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package validation
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// MaxSPFLookups RFC 7208 规定的 DNS 查询次数上限
+const MaxSPFLookups = 10
+
+// IsSPF 是否为 SPF 记录（以 v=spf1 开头）
+func IsSPF(text string) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	return text == "v=spf1" || strings.HasPrefix(text, "v=spf1 ")
+}
+
+// CheckSPF 按 RFC 7208 检查 SPF 语法和 DNS 查询次数
+func CheckSPF(text string) Errors {
+	var errs Errors
+	bad := func(messageID, term string) Errors {
+		errs.add("content", messageID, map[string]interface{}{"Term": term})
+		return errs
+	}
+
+	terms := strings.Fields(text)
+	lookups := 0
+	seenAll := false
+	modifiers := make(map[string]bool)
+
+	for _, term := range terms[1:] {
+		lower := strings.ToLower(term)
+
+		// 修饰符：name=value
+		if eq := strings.IndexByte(lower, '='); eq > 0 && !strings.ContainsAny(lower[:eq], ":/") {
+			name := lower[:eq]
+			if !validModifierName(name) {
+				return bad("validation_spf_term", term)
+			}
+			if name == "redirect" || name == "exp" {
+				if modifiers[name] {
+					return bad("validation_spf_duplicate", term)
+				}
+				if lower[eq+1:] == "" {
+					return bad("validation_spf_term", term)
+				}
+				if name == "redirect" {
+					lookups++
+				}
+			}
+			modifiers[name] = true
+			continue
+		}
+
+		if seenAll {
+			return bad("validation_spf_after_all", term)
+		}
+
+		mech := lower
+		if strings.ContainsAny(mech[:1], "+-~?") {
+			mech = mech[1:]
+		}
+		name, arg := mech, ""
+		if i := strings.IndexAny(mech, ":/"); i >= 0 {
+			name, arg = mech[:i], mech[i:]
+		}
+
+		switch name {
+		case "all":
+			if arg != "" {
+				return bad("validation_spf_term", term)
+			}
+			seenAll = true
+		case "include", "exists":
+			if !strings.HasPrefix(arg, ":") || len(arg) < 2 {
+				return bad("validation_spf_term", term)
+			}
+			lookups++
+		case "a", "mx":
+			if !validSPFDomainCIDR(arg) {
+				return bad("validation_spf_term", term)
+			}
+			lookups++
+		case "ptr":
+			if arg != "" && (!strings.HasPrefix(arg, ":") || len(arg) < 2) {
+				return bad("validation_spf_term", term)
+			}
+			lookups++
+		case "ip4", "ip6":
+			if !strings.HasPrefix(arg, ":") || !validSPFIP(arg[1:], name == "ip6") {
+				return bad("validation_spf_ip", term)
+			}
+		default:
+			return bad("validation_spf_term", term)
+		}
+	}
+
+	// redirect 在存在 all 时不会生效
+	if seenAll && modifiers["redirect"] {
+		errs.add("content", "validation_spf_redirect_all", nil)
+	}
+	if lookups > MaxSPFLookups {
+		errs.add("content", "validation_spf_lookups", map[string]interface{}{
+			"Count": lookups,
+			"Max":   MaxSPFLookups,
+		})
+	}
+	return errs
+}
+
+// validModifierName 修饰符名称：字母开头，后跟字母、数字、- _ .
+func validModifierName(name string) bool {
+	for i, ch := range name {
+		switch {
+		case ch >= 'a' && ch <= 'z':
+		case i > 0 && (ch >= '0' && ch <= '9' || ch == '-' || ch == '_' || ch == '.'):
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
+
+// validSPFDomainCIDR a / mx 机制的可选参数：[:domain][/ip4-cidr][//ip6-cidr]
+func validSPFDomainCIDR(arg string) bool {
+	if strings.HasPrefix(arg, ":") {
+		end := strings.IndexByte(arg, '/')
+		if end < 0 {
+			end = len(arg)
+		}
+		if end < 2 {
+			return false
+		}
+		arg = arg[end:]
+	}
+	if arg == "" {
+		return true
+	}
+	ip6 := ""
+	if i := strings.Index(arg, "//"); i >= 0 {
+		arg, ip6 = arg[:i], arg[i+2:]
+		if !validPrefix(ip6, 128) {
+			return false
+		}
+	}
+	if arg == "" {
+		return true
+	}
+	return strings.HasPrefix(arg, "/") && validPrefix(arg[1:], 32)
+}
+
+// validSPFIP ip4 / ip6 机制的地址和可选前缀长度
+func validSPFIP(value string, v6 bool) bool {
+	addr, prefix := value, ""
+	if i := strings.IndexByte(value, '/'); i >= 0 {
+		addr, prefix = value[:i], value[i+1:]
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil || strings.Contains(addr, ":") != v6 {
+		return false
+	}
+	if prefix == "" {
+		return true
+	}
+	if v6 {
+		return validPrefix(prefix, 128)
+	}
+	return validPrefix(prefix, 32)
+}
+
+func validPrefix(s string, max int) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= max
+}
@@ -0,0 +1,124 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsSPF(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"v=spf1 -all", true},
+		{"V=SPF1 mx -all", true},
+		{"v=spf1", true},
+		{"  v=spf1 a ", true},
+		{"v=spf10 -all", false},
+		{"v=spf1-all", false},
+		{"spf1 -all", false},
+		{"google-site-verification=abc", false},
+	}
+	for _, tt := range tests {
+		if got := IsSPF(tt.text); got != tt.want {
+			t.Errorf("IsSPF(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSPF(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // 第一条错误的 messageID，空表示有效
+	}{
+		// 有效记录
+		{"v=spf1", ""},
+		{"v=spf1 -all", ""},
+		{"v=spf1 a mx ~all", ""},
+		{"v=spf1 +a -mx ?ptr ~all", ""},
+		{"v=spf1 a:mail.example.com mx:example.com/24 -all", ""},
+		{"v=spf1 a/24 a//64 mx:example.com/24//64 -all", ""},
+		{"v=spf1 ip4:192.0.2.1 ip4:192.0.2.0/24 ip6:2001:db8::/32 ip6:2001:db8::1 -all", ""},
+		{"v=spf1 include:_spf.example.com exists:%{i}._spf.example.com -all", ""},
+		{"v=spf1 ptr:example.com -all", ""},
+		{"v=spf1 mx redirect=_spf.example.com", ""},
+		{"v=spf1 -all exp=explain.example.com", ""},
+		{"v=spf1 mx -all custom-mod=value", ""},
+		{"V=SPF1 IP4:192.0.2.1 INCLUDE:example.com -ALL", ""},
+
+		// 未知或格式错误的机制
+		{"v=spf1 foo -all", "validation_spf_term"},
+		{"v=spf1 include -all", "validation_spf_term"},
+		{"v=spf1 include: -all", "validation_spf_term"},
+		{"v=spf1 exists -all", "validation_spf_term"},
+		{"v=spf1 a: -all", "validation_spf_term"},
+		{"v=spf1 a/33 -all", "validation_spf_term"},
+		{"v=spf1 mx//129 -all", "validation_spf_term"},
+		{"v=spf1 ptr: -all", "validation_spf_term"},
+		{"v=spf1 all:example.com", "validation_spf_term"},
+		{"v=spf1 ~ -all", "validation_spf_term"},
+		{"v=spf1 redirect=", "validation_spf_term"},
+		{"v=spf1 1bad=value -all", "validation_spf_term"},
+
+		// ip4 / ip6
+		{"v=spf1 ip4:192.0.2.300 -all", "validation_spf_ip"},
+		{"v=spf1 ip4:192.0.2.0/33 -all", "validation_spf_ip"},
+		{"v=spf1 ip4:2001:db8::1 -all", "validation_spf_ip"},
+		{"v=spf1 ip6:192.0.2.1 -all", "validation_spf_ip"},
+		{"v=spf1 ip6:2001:db8::/129 -all", "validation_spf_ip"},
+		{"v=spf1 ip4 -all", "validation_spf_ip"},
+
+		// 顺序和修饰符
+		{"v=spf1 -all mx", "validation_spf_after_all"},
+		{"v=spf1 redirect=a.example.com redirect=b.example.com", "validation_spf_duplicate"},
+		{"v=spf1 exp=a.example.com exp=b.example.com -all", "validation_spf_duplicate"},
+		{"v=spf1 mx -all redirect=_spf.example.com", "validation_spf_redirect_all"},
+	}
+	for _, tt := range tests {
+		errs := CheckSPF(tt.text)
+		got := ""
+		if len(errs) > 0 {
+			got = errs[0].MessageID
+			if errs[0].Field != "content" {
+				t.Errorf("CheckSPF(%q) field = %q, want content", tt.text, errs[0].Field)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("CheckSPF(%q) = %v, want %q", tt.text, errs, tt.want)
+		}
+	}
+
+	errs := CheckSPF("v=spf1 mx ip4:192.0.2.999 -all")
+	if len(errs) != 1 || errs[0].Data["Term"] != "ip4:192.0.2.999" {
+		t.Errorf("error term = %+v, want ip4:192.0.2.999", errs)
+	}
+}
+
+func TestCheckSPFLookups(t *testing.T) {
+	terms := func(n int) string {
+		var parts []string
+		for i := 0; i < n; i++ {
+			parts = append(parts, "include:_spf"+strings.Repeat("x", i)+".example.com")
+		}
+		return strings.Join(parts, " ")
+	}
+	tests := []struct {
+		text  string
+		count int // 0 表示没有超限错误
+	}{
+		{"v=spf1 " + terms(10) + " -all", 0},
+		{"v=spf1 " + terms(11) + " -all", 11},
+		// a、mx、ptr、exists、redirect 都计入查询次数，ip4 / ip6 / all 不计入
+		{"v=spf1 a mx ptr exists:x.example.com " + terms(6) + " ip4:192.0.2.1 ip6:2001:db8::1 -all", 0},
+		{"v=spf1 a mx ptr exists:x.example.com " + terms(6) + " redirect=r.example.com", 11},
+	}
+	for _, tt := range tests {
+		errs := CheckSPF(tt.text)
+		switch {
+		case tt.count == 0 && len(errs) != 0:
+			t.Errorf("CheckSPF(%q) = %+v, want no errors", tt.text, errs)
+		case tt.count > 0 && (len(errs) != 1 || errs[0].MessageID != "validation_spf_lookups" || errs[0].Data["Count"] != tt.count):
+			t.Errorf("CheckSPF(%q) = %+v, want %d lookups", tt.text, errs, tt.count)
+		}
+	}
+}
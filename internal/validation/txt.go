@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+)
+
+// TXT 记录长度限制
+const (
+	MaxTXTLength = 2048 // Cloudflare 单条 TXT 记录内容上限
+	MaxTXTChunk  = 255  // 单个字符串（character-string）上限
+)
+
+var (
+	errTXTUnterminated = errors.New("unterminated quoted string")
+	errTXTUnquoted     = errors.New("text outside quotes")
+)
+
+// TXTStrings 将 TXT 内容拆分为字符串
+// 以双引号开头的内容按 "..." "..." 解析；否则视为一个整体，由 Cloudflare 自动按 255 字节分段
+func TXTStrings(content string) ([]string, error) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, `"`) {
+		return []string{content}, nil
+	}
+
+	var chunks []string
+	for i := 0; i < len(content); {
+		switch content[i] {
+		case ' ', '\t':
+			i++
+			continue
+		case '"':
+		default:
+			return nil, errTXTUnquoted
+		}
+
+		var b strings.Builder
+		closed := false
+		for i++; i < len(content); i++ {
+			ch := content[i]
+			if ch == '\\' && i+1 < len(content) {
+				i++
+				b.WriteByte(content[i])
+				continue
+			}
+			if ch == '"' {
+				closed = true
+				i++
+				break
+			}
+			b.WriteByte(ch)
+		}
+		if !closed {
+			return nil, errTXTUnterminated
+		}
+		chunks = append(chunks, b.String())
+	}
+	return chunks, nil
+}
+
+// checkTXT 检查 TXT 总长度、分段和 SPF 语法
+func checkTXT(content string) Errors {
+	var errs Errors
+
+	if len(content) > MaxTXTLength {
+		errs.add("content", "validation_txt_too_long", map[string]interface{}{"Max": MaxTXTLength})
+		return errs
+	}
+
+	chunks, err := TXTStrings(content)
+	if err != nil {
+		errs.add("content", "validation_txt_quotes", nil)
+		return errs
+	}
+	// 未加引号的内容由 Cloudflare 自动分段，只有手动分段时才检查每段长度
+	if strings.HasPrefix(content, `"`) {
+		for i, chunk := range chunks {
+			if len(chunk) > MaxTXTChunk {
+				errs.add("content", "validation_txt_chunk_too_long", map[string]interface{}{
+					"Index":  i + 1,
+					"Length": len(chunk),
+					"Max":    MaxTXTChunk,
+				})
+				return errs
+			}
+		}
+	}
+
+	// 多个字符串按 RFC 7208 直接拼接后再解析 SPF
+	text := strings.Join(chunks, "")
+	if IsSPF(text) {
+		errs = append(errs, CheckSPF(text)...)
+	}
+	return errs
+}
@@ -0,0 +1,33 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTXTStrings(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+		err     error
+	}{
+		{"hello world", []string{"hello world"}, nil},
+		{`  v=spf1 -all `, []string{"v=spf1 -all"}, nil},
+		{`"hello world"`, []string{"hello world"}, nil},
+		{`"part1" "part2"`, []string{"part1", "part2"}, nil},
+		{"\"part1\"\t\"part2\"", []string{"part1", "part2"}, nil},
+		{`"part1""part2"`, []string{"part1", "part2"}, nil},
+		{`"say \"hi\"" "back\\slash"`, []string{`say "hi"`, `back\slash`}, nil},
+		{`""`, []string{""}, nil},
+		{`"part1" "part2`, nil, errTXTUnterminated},
+		{`"trailing\"`, nil, errTXTUnterminated},
+		{`"part1" part2`, nil, errTXTUnquoted},
+		{`"part1",  "part2"`, nil, errTXTUnquoted},
+	}
+	for _, tt := range tests {
+		got, err := TXTStrings(tt.content)
+		if err != tt.err || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TXTStrings(%q) = %q, %v; want %q, %v", tt.content, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package validation
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	goi18n "github.com/nicksnyder/go-i18n/v2/i18n"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/i18n"
+)
+
+// TTL 范围（1 表示自动）
+const (
+	TTLAuto = 1
+	MinTTL  = 60
+	MaxTTL  = 86400
+)
+
+// Error 字段级校验错误，MessageID 为翻译 ID，Data 为翻译模板参数
+type Error struct {
+	Field     string
+	MessageID string
+	Data      map[string]interface{}
+}
+
+func (e Error) Error() string {
+	return e.Field + ": " + e.MessageID
+}
+
+// Errors 一组校验错误
+type Errors []Error
+
+func (e *Errors) add(field, messageID string, data map[string]interface{}) {
+	*e = append(*e, Error{Field: field, MessageID: messageID, Data: data})
+}
+
+// Localize 翻译为 字段名 -> 错误信息，每个字段只保留第一条错误
+func (e Errors) Localize(localizer *goi18n.Localizer) map[string]string {
+	if len(e) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(e))
+	for _, err := range e {
+		if _, ok := out[err.Field]; ok {
+			continue
+		}
+		out[err.Field] = i18n.TData(localizer, err.MessageID, err.Data)
+	}
+	return out
+}
+
+// Record 待校验的记录，Name 为 FQDN 规范化后的完整名称
+type Record struct {
+	ID      string // 编辑时的记录 ID，冲突检查时排除自身
+	Type    string
+	Name    string
+	Content string
+	Proxied bool
+}
+
+// proxiable 可以启用 Cloudflare 代理的记录类型
+var proxiable = map[string]bool{"A": true, "AAAA": true, "CNAME": true}
+
+// FQDN 将表单中的名称（@、子域名或完整域名）规范化为小写的完整名称
+func FQDN(name, zone string) string {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	if name == "" || name == "@" || name == zone {
+		return zone
+	}
+	if strings.HasSuffix(name, "."+zone) {
+		return name
+	}
+	return name + "." + zone
+}
+
+// ParseTTL 解析 TTL，只接受 1（自动）或 MinTTL ~ MaxTTL 之间的整数
+func ParseTTL(raw string) (int, *Error) {
+	ttl, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, &Error{Field: "ttl", MessageID: "validation_ttl_invalid"}
+	}
+	if ttl != TTLAuto && (ttl < MinTTL || ttl > MaxTTL) {
+		return 0, &Error{Field: "ttl", MessageID: "validation_ttl_range", Data: map[string]interface{}{
+			"Min": MinTTL,
+			"Max": MaxTTL,
+		}}
+	}
+	return ttl, nil
+}
+
+// Validate 检查名称、代理和记录内容，不依赖 Zone 中的其他记录
+func Validate(r Record) Errors {
+	var errs Errors
+
+	if !validName(r.Name) {
+		errs.add("name", "validation_name_invalid", nil)
+	}
+	if r.Proxied && !proxiable[r.Type] {
+		errs.add("proxied", "validation_proxy_unsupported", map[string]interface{}{"Type": r.Type})
+	}
+
+	content := strings.TrimSpace(r.Content)
+	switch r.Type {
+	case "A":
+		if ip := net.ParseIP(content); ip == nil || ip.To4() == nil || strings.Contains(content, ":") {
+			errs.add("content", "validation_ipv4_invalid", nil)
+		}
+	case "AAAA":
+		if ip := net.ParseIP(content); ip == nil || !strings.Contains(content, ":") {
+			errs.add("content", "validation_ipv6_invalid", nil)
+		}
+	case "CNAME":
+		target := strings.ToLower(strings.TrimSuffix(content, "."))
+		switch {
+		case net.ParseIP(content) != nil:
+			errs.add("content", "validation_cname_ip", nil)
+		case !validHostname(target):
+			errs.add("content", "validation_hostname_invalid", nil)
+		case target == r.Name:
+			errs.add("content", "validation_cname_self", nil)
+		}
+	case "MX":
+		// "." 为 RFC 7505 定义的空 MX，表示不接收邮件
+		switch {
+		case content == ".":
+		case net.ParseIP(content) != nil:
+			errs.add("content", "validation_mx_ip", nil)
+		case !validHostname(strings.TrimSuffix(content, ".")):
+			errs.add("content", "validation_hostname_invalid", nil)
+		}
+	case "TXT":
+		errs = append(errs, checkTXT(content)...)
+	}
+	return errs
+}
+
+// validName 记录名称：仅首个标签可以是通配符 *，标签可以包含下划线（_dmarc、_sip._tcp）
+func validName(name string) bool {
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if label == "*" && i == 0 {
+			continue
+		}
+		if !validLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// validHostname 目标主机名（CNAME、MX 等），不允许通配符
+func validHostname(host string) bool {
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if !validLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// validLabel 单个标签：字母、数字、连字符和下划线，允许国际化域名的非 ASCII 字符
+func validLabel(label string) bool {
+	if label == "" || len(label) > 63 {
+		return false
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, ch := range label {
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9',
+			ch == '-', ch == '_', ch >= 0x80:
+		default:
+			return false
+		}
+	}
+	return true
+}
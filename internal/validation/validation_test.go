@@ -0,0 +1,190 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+// errorIDs 以 "字段:messageID" 的形式列出错误，便于比较
+func errorIDs(errs Errors) string {
+	ids := make([]string, 0, len(errs))
+	for _, err := range errs {
+		ids = append(ids, err.Field+":"+err.MessageID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		raw       string
+		want      int
+		messageID string
+	}{
+		{"1", 1, ""},
+		{"60", 60, ""},
+		{" 3600 ", 3600, ""},
+		{"86400", 86400, ""},
+		{"0", 0, "validation_ttl_range"},
+		{"59", 0, "validation_ttl_range"},
+		{"86401", 0, "validation_ttl_range"},
+		{"-300", 0, "validation_ttl_range"},
+		{"", 0, "validation_ttl_invalid"},
+		{"abc", 0, "validation_ttl_invalid"},
+		{"300s", 0, "validation_ttl_invalid"},
+	}
+	for _, tt := range tests {
+		got, err := ParseTTL(tt.raw)
+		messageID := ""
+		if err != nil {
+			messageID = err.MessageID
+			if err.Field != "ttl" {
+				t.Errorf("ParseTTL(%q) field = %q, want ttl", tt.raw, err.Field)
+			}
+		}
+		if got != tt.want || messageID != tt.messageID {
+			t.Errorf("ParseTTL(%q) = %d, %q; want %d, %q", tt.raw, got, messageID, tt.want, tt.messageID)
+		}
+	}
+
+	_, err := ParseTTL("30")
+	if err.Data["Min"] != MinTTL || err.Data["Max"] != MaxTTL {
+		t.Errorf("range error data = %v", err.Data)
+	}
+}
+
+func TestFQDN(t *testing.T) {
+	tests := []struct {
+		name, zone, want string
+	}{
+		{"@", "example.com", "example.com"},
+		{"", "example.com", "example.com"},
+		{"WWW", "example.com", "www.example.com"},
+		{"www.example.com.", "example.com.", "www.example.com"},
+		{"Example.COM", "example.com", "example.com"},
+		{"_dmarc", "example.com", "_dmarc.example.com"},
+		{"www.example.org", "example.com", "www.example.org.example.com"},
+	}
+	for _, tt := range tests {
+		if got := FQDN(tt.name, tt.zone); got != tt.want {
+			t.Errorf("FQDN(%q, %q) = %q, want %q", tt.name, tt.zone, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		record Record
+		want   string
+	}{
+		// A / AAAA 只接受对应族的地址字面量
+		{"A", Record{Type: "A", Name: "www.example.com", Content: "192.0.2.1"}, ""},
+		{"A with spaces", Record{Type: "A", Name: "www.example.com", Content: " 192.0.2.1 "}, ""},
+		{"A out of range", Record{Type: "A", Name: "www.example.com", Content: "256.0.2.1"}, "content:validation_ipv4_invalid"},
+		{"A leading zero", Record{Type: "A", Name: "www.example.com", Content: "192.0.2.01"}, "content:validation_ipv4_invalid"},
+		{"A hostname", Record{Type: "A", Name: "www.example.com", Content: "example.com"}, "content:validation_ipv4_invalid"},
+		{"A IPv6", Record{Type: "A", Name: "www.example.com", Content: "2001:db8::1"}, "content:validation_ipv4_invalid"},
+		{"A IPv4-mapped IPv6", Record{Type: "A", Name: "www.example.com", Content: "::ffff:192.0.2.1"}, "content:validation_ipv4_invalid"},
+		{"AAAA", Record{Type: "AAAA", Name: "www.example.com", Content: "2001:db8::1"}, ""},
+		{"AAAA IPv4-mapped", Record{Type: "AAAA", Name: "www.example.com", Content: "::ffff:192.0.2.1"}, ""},
+		{"AAAA IPv4", Record{Type: "AAAA", Name: "www.example.com", Content: "192.0.2.1"}, "content:validation_ipv6_invalid"},
+		{"AAAA garbage", Record{Type: "AAAA", Name: "www.example.com", Content: "2001:db8::g"}, "content:validation_ipv6_invalid"},
+
+		// CNAME 目标
+		{"CNAME", Record{Type: "CNAME", Name: "www.example.com", Content: "target.example.net."}, ""},
+		{"CNAME to IP", Record{Type: "CNAME", Name: "www.example.com", Content: "192.0.2.1"}, "content:validation_cname_ip"},
+		{"CNAME bad host", Record{Type: "CNAME", Name: "www.example.com", Content: "bad_host-.example.net"}, "content:validation_hostname_invalid"},
+		{"CNAME wildcard target", Record{Type: "CNAME", Name: "www.example.com", Content: "*.example.net"}, "content:validation_hostname_invalid"},
+		{"CNAME to itself", Record{Type: "CNAME", Name: "www.example.com", Content: "WWW.example.com."}, "content:validation_cname_self"},
+
+		// MX 必须指向主机名
+		{"MX", Record{Type: "MX", Name: "example.com", Content: "mx1.example.com"}, ""},
+		{"null MX", Record{Type: "MX", Name: "example.com", Content: "."}, ""},
+		{"MX to IPv4", Record{Type: "MX", Name: "example.com", Content: "192.0.2.1"}, "content:validation_mx_ip"},
+		{"MX to IPv6", Record{Type: "MX", Name: "example.com", Content: "2001:db8::1"}, "content:validation_mx_ip"},
+		{"MX bad host", Record{Type: "MX", Name: "example.com", Content: "mail..example.com"}, "content:validation_hostname_invalid"},
+
+		// 名称
+		{"wildcard", Record{Type: "A", Name: "*.example.com", Content: "192.0.2.1"}, ""},
+		{"underscore", Record{Type: "TXT", Name: "_dmarc.example.com", Content: "v=DMARC1; p=none"}, ""},
+		{"IDN", Record{Type: "A", Name: "中文.example.com", Content: "192.0.2.1"}, ""},
+		{"inner wildcard", Record{Type: "A", Name: "a.*.example.com", Content: "192.0.2.1"}, "name:validation_name_invalid"},
+		{"leading hyphen", Record{Type: "A", Name: "-www.example.com", Content: "192.0.2.1"}, "name:validation_name_invalid"},
+		{"empty label", Record{Type: "A", Name: "www..example.com", Content: "192.0.2.1"}, "name:validation_name_invalid"},
+		{"long label", Record{Type: "A", Name: strings.Repeat("a", 64) + ".example.com", Content: "192.0.2.1"}, "name:validation_name_invalid"},
+		{"space", Record{Type: "A", Name: "my host.example.com", Content: "192.0.2.1"}, "name:validation_name_invalid"},
+
+		// 只有 A、AAAA、CNAME 可以代理
+		{"proxied A", Record{Type: "A", Name: "www.example.com", Content: "192.0.2.1", Proxied: true}, ""},
+		{"proxied AAAA", Record{Type: "AAAA", Name: "www.example.com", Content: "2001:db8::1", Proxied: true}, ""},
+		{"proxied CNAME", Record{Type: "CNAME", Name: "www.example.com", Content: "target.example.net", Proxied: true}, ""},
+		{"proxied MX", Record{Type: "MX", Name: "example.com", Content: "mx1.example.com", Proxied: true}, "proxied:validation_proxy_unsupported"},
+		{"proxied TXT", Record{Type: "TXT", Name: "example.com", Content: "hello", Proxied: true}, "proxied:validation_proxy_unsupported"},
+
+		// 多个错误按 名称、代理、内容 的顺序返回
+		{"several errors", Record{Type: "MX", Name: "bad name.example.com", Content: "192.0.2.1", Proxied: true},
+			"name:validation_name_invalid,proxied:validation_proxy_unsupported,content:validation_mx_ip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorIDs(Validate(tt.record)); got != tt.want {
+				t.Errorf("Validate(%+v) = %q, want %q", tt.record, got, tt.want)
+			}
+		})
+	}
+
+	errs := Validate(Record{Type: "SRV", Name: "_sip._tcp.example.com", Proxied: true})
+	if len(errs) != 1 || errs[0].Data["Type"] != "SRV" {
+		t.Errorf("proxy error = %+v, want Type=SRV", errs)
+	}
+}
+
+func TestValidateTXT(t *testing.T) {
+	chunk := strings.Repeat("a", MaxTXTChunk)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain", "hello world", ""},
+		{"long unquoted is split by Cloudflare", strings.Repeat("a", 1000), ""},
+		{"quoted chunks", `"` + chunk + `" "` + chunk + `"`, ""},
+		{"escaped quote", `"say \"hi\""`, ""},
+		{"chunk too long", `"` + chunk + `" "` + chunk + `b"`, "content:validation_txt_chunk_too_long"},
+		{"too long", strings.Repeat("a", MaxTXTLength+1), "content:validation_txt_too_long"},
+		{"unterminated", `"part1" "part2`, "content:validation_txt_quotes"},
+		{"text outside quotes", `"part1" part2`, "content:validation_txt_quotes"},
+		{"valid SPF", "v=spf1 mx -all", ""},
+		{"SPF split across chunks", `"v=spf1 ip4:192.0.2.0/24 " "include:_spf.example.com -all"`, ""},
+		{"invalid SPF", "v=spf1 ip4:192.0.2.300 -all", "content:validation_spf_ip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Validate(Record{Type: "TXT", Name: "example.com", Content: tt.content})
+			if errorIDs(got) != tt.want {
+				t.Errorf("Validate TXT %q = %q, want %q", tt.content, errorIDs(got), tt.want)
+			}
+		})
+	}
+
+	errs := Validate(Record{Type: "TXT", Name: "example.com", Content: `"ok" "` + chunk + `cd"`})
+	if len(errs) != 1 || errs[0].Data["Index"] != 2 || errs[0].Data["Length"] != MaxTXTChunk+2 {
+		t.Errorf("chunk error = %+v, want the second chunk with length %d", errs, MaxTXTChunk+2)
+	}
+}
+
+func TestErrorsLocalize(t *testing.T) {
+	errs := Errors{
+		{Field: "content", MessageID: "validation_ipv4_invalid"},
+		{Field: "content", MessageID: "validation_txt_quotes"},
+		{Field: "name", MessageID: "validation_name_invalid"},
+	}
+	// 未传入 localizer 时返回 messageID，每个字段只保留第一条
+	got := errs.Localize(nil)
+	if len(got) != 2 || got["content"] != "validation_ipv4_invalid" || got["name"] != "validation_name_invalid" {
+		t.Errorf("Localize = %v", got)
+	}
+	if Errors(nil).Localize(nil) != nil {
+		t.Error("Localize of no errors should be nil")
+	}
+}
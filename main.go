@@ -7,6 +7,7 @@ import (
 	"io/fs"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,16 +16,26 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/template/html/v2"
 
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/config"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/credentials"
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/handler"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/history"
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/i18n"
 	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/middleware"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/storage"
 )
 
 //go:embed web
 var webFS embed.FS
 
 func main() {
+	// 子命令：sync（声明式同步 DNS 记录）
+	if len(os.Args) > 1 && os.Args[1] == "sync" {
+		os.Exit(runSync(os.Args[2:]))
+	}
+
 	// 命令行参数
 	configFile := flag.String("config", "config.yaml", "配置文件路径")
 	flag.Parse()
@@ -33,25 +44,105 @@ func main() {
 	cfg, err := config.Load(*configFile)
 	if err != nil {
 		log.Printf("Warning: Failed to load %s, using defaults: %v", *configFile, err)
-		cfg = &config.Config{}
-		cfg.Server.Host = "0.0.0.0"
-		cfg.Server.Port = 8080
-		cfg.Server.PageTitle = "Cloudflare DNS Manager"
-		cfg.Session.Expire = 3600
-		cfg.Session.RememberExpire = 31536000
-		cfg.RateLimit.MaxAttempts = 5
-		cfg.RateLimit.Window = 60
-		cfg.Cache.DNSTTL = 172800
+		cfg = defaultConfig()
+	}
+
+	// Cloudflare API 客户端池（代理、超时、重试等网络设置，按凭证限制请求配额）
+	pool, err := service.NewClientPool(apiHTTPOptions(cfg), apiBudgetOptions(cfg))
+	if err != nil {
+		log.Fatalf("Failed to configure Cloudflare API client: %v", err)
+	}
+
+	// 初始化会话存储（会话与加密凭证共用同一存储）
+	sessionStorage, err := storage.New(
+		cfg.Session.Storage.Type,
+		cfg.Session.Storage.Path,
+		time.Duration(cfg.Session.Storage.GCInterval)*time.Second,
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize session storage: %v", err)
 	}
+	defer sessionStorage.Close()
 
-	// 初始化会话存储
-	middleware.InitSession(time.Duration(cfg.Session.Expire) * time.Second)
+	// 初始化凭证保险库
+	vault, err := newCredentialVault(cfg, sessionStorage)
+	if err != nil {
+		log.Fatalf("Failed to initialize credential vault: %v", err)
+	}
+
+	middleware.InitSession(time.Duration(cfg.Session.Expire)*time.Second, sessionStorage, vault)
+
+	// 初始化审计日志
+	auditLog, err := audit.New(cfg.Audit.Type, cfg.Audit.Path)
+	if err != nil {
+		log.Fatalf("Failed to initialize audit log: %v", err)
+	}
+	defer auditLog.Close()
+
+	// 初始化 DNS 快照存储
+	historyStore, err := history.NewStore(cfg.History.Path, cfg.History.MaxSnapshots)
+	if err != nil {
+		log.Fatalf("Failed to initialize history store: %v", err)
+	}
 
 	// 初始化 i18n
 	if err := i18n.Init(webFS); err != nil {
 		log.Fatalf("Failed to initialize i18n: %v", err)
 	}
 
+	app := newApp(cfg)
+
+	// 路由
+	setupRoutes(app, cfg, pool.Clients, pool, auditLog, historyStore)
+
+	// 启动服务器
+	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+	log.Printf("Starting server on %s", addr)
+	if err := app.Listen(addr); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+// defaultConfig 配置文件加载失败时使用的默认配置
+func defaultConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Server.Host = "0.0.0.0"
+	cfg.Server.Port = 8080
+	cfg.Server.PageTitle = "Cloudflare DNS Manager"
+	cfg.Session.Expire = 3600
+	cfg.Session.RememberExpire = 31536000
+	cfg.Session.Storage.Type = "memory"
+	cfg.Session.Storage.Path = "data/sessions.db"
+	cfg.Session.Storage.GCInterval = 600
+	cfg.RateLimit.MaxAttempts = 5
+	cfg.RateLimit.Window = 60
+	cfg.Cache.DNSTTL = 172800
+	cfg.Cache.ZoneTTL = 60
+	cfg.Audit.Type = "jsonl"
+	cfg.Audit.Path = "data/audit.jsonl"
+	cfg.History.Path = "data/history"
+	cfg.History.MaxSnapshots = 50
+	cfg.DNS.Resolvers = config.DefaultResolvers
+	cfg.DNS.Timeout = 3
+	cfg.API.BaseURL = "https://api.cloudflare.com/client/v4"
+	cfg.API.ConnectTimeout = 10
+	cfg.API.Timeout = 30
+	cfg.API.Retry.MaxRetries = 3
+	cfg.API.Retry.MinBackoff = 1
+	cfg.API.Retry.MaxBackoff = 30
+	cfg.API.Budget.Requests = 1200
+	cfg.API.Budget.Window = 300
+	cfg.API.Budget.MaxWait = 30
+	cfg.API.Deadlines.Read = 20
+	cfg.API.Deadlines.Write = 30
+	cfg.API.Deadlines.Bulk = 300
+	cfg.API.Deadlines.Analytics = 60
+	return cfg
+}
+
+// newApp 创建 Fiber 应用：模板引擎、通用中间件和静态文件，路由由 setupRoutes 注册
+// 调用前需要先完成 i18n.Init 和 middleware.InitSession
+func newApp(cfg *config.Config) *fiber.App {
 	// 创建模板引擎
 	templateFS, _ := fs.Sub(webFS, "web/templates")
 	engine := html.NewFileSystem(http.FS(templateFS), ".html")
@@ -106,18 +197,64 @@ func main() {
 		Browse: false,
 	}))
 
-	// 路由
-	setupRoutes(app, cfg)
+	return app
+}
 
-	// 启动服务器
-	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	log.Printf("Starting server on %s", addr)
-	if err := app.Listen(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+// apiHTTPOptions 将配置文件中的 api 段转换为网络设置
+func apiHTTPOptions(cfg *config.Config) service.HTTPOptions {
+	retries := cfg.API.Retry.MaxRetries
+	if retries < 0 {
+		retries = 0
+	}
+	return service.HTTPOptions{
+		BaseURL:        cfg.API.BaseURL,
+		Proxy:          cfg.API.Proxy,
+		CABundle:       cfg.API.CABundle,
+		ConnectTimeout: time.Duration(cfg.API.ConnectTimeout) * time.Second,
+		Timeout:        time.Duration(cfg.API.Timeout) * time.Second,
+		MaxRetries:     retries,
+		MinBackoff:     time.Duration(cfg.API.Retry.MinBackoff) * time.Second,
+		MaxBackoff:     time.Duration(cfg.API.Retry.MaxBackoff) * time.Second,
 	}
 }
 
-func setupRoutes(app *fiber.App, cfg *config.Config) {
+// apiBudgetOptions 将配置文件中的 api.budget 转换为请求配额
+func apiBudgetOptions(cfg *config.Config) service.BudgetOptions {
+	maxWait := time.Duration(cfg.API.Budget.MaxWait) * time.Second
+	if maxWait < 0 {
+		maxWait = 0
+	}
+	return service.BudgetOptions{
+		Requests: cfg.API.Budget.Requests,
+		Window:   time.Duration(cfg.API.Budget.Window) * time.Second,
+		MaxWait:  maxWait,
+	}
+}
+
+// newCredentialVault 创建凭证保险库
+// 未配置主密钥时生成临时密钥，重启后已保存的凭证将无法解密（需要重新登录）
+func newCredentialVault(cfg *config.Config, store fiber.Storage) (*credentials.Vault, error) {
+	var key []byte
+	var err error
+	if cfg.Credentials.SecretKey != "" {
+		key, err = credentials.ParseKey(cfg.Credentials.SecretKey)
+	} else {
+		log.Printf("Warning: credentials.secret_key is not set, using an ephemeral key")
+		if cfg.Session.Storage.Type != storage.TypeMemory {
+			log.Printf("Warning: persisted sessions will not survive a restart without credentials.secret_key")
+		}
+		key, err = credentials.GenerateKey()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewVault(key, store)
+}
+
+// setupRoutes 创建 handler 并注册路由
+// clients 为 Cloudflare 客户端工厂，测试时可传入 fakecf.Backend.Clients 在本地内存中运行
+// pool 用于在页面上显示请求配额，可以为 nil
+func setupRoutes(app *fiber.App, cfg *config.Config, clients service.ClientFactory, pool *service.ClientPool, auditLog audit.Logger, historyStore *history.Store) {
 	// 创建限流器
 	rateLimiter := middleware.NewRateLimiter(
 		cfg.RateLimit.MaxAttempts,
@@ -126,64 +263,107 @@ func setupRoutes(app *fiber.App, cfg *config.Config) {
 
 	// 创建 handler
 	homeHandler := handler.NewHomeHandler()
-	authHandler := handler.NewAuthHandler(rateLimiter)
-	zoneHandler := handler.NewZoneHandler()
-	dnsHandler := handler.NewDNSHandler()
-	securityHandler := handler.NewSecurityHandler()
-	settingsHandler := handler.NewSettingsHandler()
-	certificateHandler := handler.NewCertificateHandler()
-	// analyticsHandler := handler.NewAnalyticsHandler() // Analytics 功能已移除
+	authHandler := handler.NewAuthHandler(clients, rateLimiter)
+	accountHandler := handler.NewAccountHandler(clients, rateLimiter)
+	dnsTimeout := time.Duration(cfg.DNS.Timeout) * time.Second
+	resolver := service.NewDNSResolver(cfg.DNS.Resolvers...)
+	resolver.Timeout = dnsTimeout
+	zoneCache := service.NewZoneCache(time.Duration(cfg.Cache.ZoneTTL) * time.Second)
+	recordCache := service.NewRecordCache(time.Duration(cfg.Cache.DNSTTL) * time.Second)
+	zoneHandler := handler.NewZoneHandler(clients, auditLog, resolver, zoneCache, recordCache)
+	dnsHandler := handler.NewDNSHandler(clients, auditLog, historyStore, recordCache)
+	historyHandler := handler.NewHistoryHandler(clients, historyStore, auditLog, recordCache)
+	securityHandler := handler.NewSecurityHandler(clients, auditLog, historyStore, recordCache)
+	settingsHandler := handler.NewSettingsHandler(clients, auditLog)
+	certificateHandler := handler.NewCertificateHandler(clients, auditLog)
+	auditHandler := handler.NewAuditHandler(clients, auditLog, zoneCache)
+	analyticsHandler := handler.NewAnalyticsHandler(clients)
+	emailHandler := handler.NewEmailHandler(clients, auditLog, historyStore, resolver, recordCache)
+	propagationHandler := handler.NewPropagationHandler(clients, cfg.DNS.Resolvers, dnsTimeout)
+
+	// 按操作类型设置 Cloudflare 调用的截止时间（受保护路由默认使用 read）
+	read := middleware.Deadline(time.Duration(cfg.API.Deadlines.Read) * time.Second)
+	write := middleware.Deadline(time.Duration(cfg.API.Deadlines.Write) * time.Second)
+	bulk := middleware.Deadline(time.Duration(cfg.API.Deadlines.Bulk) * time.Second)
+	analytics := middleware.Deadline(time.Duration(cfg.API.Deadlines.Analytics) * time.Second)
 
 	// 首页 - 显示 landing page 或跳转到域名列表
 	app.Get("/", homeHandler.ShowHome)
 
 	// 公开路由 - 登录/登出
 	app.Get("/login", authHandler.ShowLogin)
-	app.Post("/login", authHandler.PostLogin)
+	app.Post("/login", write, authHandler.PostLogin)
 	app.Get("/logout", authHandler.Logout)
+	app.Post("/forget", authHandler.ForgetKey)
 
 	// 受保护的路由
-	protected := app.Group("/", middleware.AuthRequired)
+	protected := app.Group("/", middleware.AuthRequired, middleware.APIBudget(pool), read)
+
+	// 多账户路由
+	protected.Get("/accounts/add", accountHandler.ShowAddAccount)
+	protected.Post("/accounts/add", write, accountHandler.AddAccount)
+	protected.Get("/accounts/switch", accountHandler.SwitchAccount)
+	protected.Post("/accounts/remove", accountHandler.RemoveAccount)
 
 	// 域名管理路由
 	protected.Get("/zones", zoneHandler.ListZones)
 	protected.Get("/zone/add", zoneHandler.ShowAddZone)
-	protected.Post("/zone/add", zoneHandler.AddZone)
+	protected.Post("/zone/add", write, zoneHandler.AddZone)
 	protected.Get("/zone", zoneHandler.ShowZone)
-	protected.Post("/api/zone/delete", zoneHandler.DeleteZone)
+	protected.Post("/api/zone/delete", write, zoneHandler.DeleteZone)
+	protected.Get("/api/zone/delegation", zoneHandler.GetDelegation)
+
+	// DNS 快照路由
+	protected.Get("/zone/history", historyHandler.ShowHistory)
+	protected.Get("/zone/history/restore", historyHandler.ShowRestore)
+	protected.Post("/zone/history/restore", bulk, historyHandler.Restore)
 
 	// DNS 记录管理路由
 	protected.Get("/dns/add", dnsHandler.ShowAddRecord)
-	protected.Post("/dns/add", dnsHandler.AddRecord)
+	protected.Post("/dns/add", write, dnsHandler.AddRecord)
 	protected.Get("/dns/edit", dnsHandler.ShowEditRecord)
-	protected.Post("/dns/edit", dnsHandler.EditRecord)
-	protected.Get("/dns/delete", dnsHandler.DeleteRecord)
+	protected.Post("/dns/edit", write, dnsHandler.EditRecord)
+	protected.Get("/dns/delete", write, dnsHandler.DeleteRecord)
+	protected.Get("/dns/export", dnsHandler.ExportZoneFile)
+	protected.Get("/dns/import", dnsHandler.ShowImportZoneFile)
+	protected.Post("/dns/import", bulk, dnsHandler.ImportZoneFile)
+	protected.Get("/dns/propagation", propagationHandler.ShowPropagation)
 
 	// HTMX API 端点
-	protected.Post("/api/dns/:id/toggle-proxy", dnsHandler.ToggleProxy)
+	protected.Post("/api/dns/:id/toggle-proxy", write, dnsHandler.ToggleProxy)
+	protected.Post("/api/dns/bulk", bulk, dnsHandler.BulkRecords)
 	protected.Get("/api/dns/search", zoneHandler.SearchDNSRecords)
 	protected.Get("/api/dns/stats", zoneHandler.GetDNSStats)
+	protected.Get("/api/dns/analytics", analytics, zoneHandler.GetDNSAnalytics)
 
 	// 安全功能路由
 	protected.Get("/security", securityHandler.ShowSecurity)
-	protected.Post("/security/dnssec", securityHandler.ToggleDNSSEC)
+	protected.Post("/security/dnssec", write, securityHandler.ToggleDNSSEC)
+	protected.Post("/security/dcv", write, securityHandler.CreateDCVRecords)
+
+	// 邮件安全向导（SPF / DKIM / DMARC / MTA-STS / BIMI）
+	protected.Get("/email", emailHandler.ShowEmail)
+	protected.Post("/email/apply", write, emailHandler.ApplyEmail)
 
 	// Zone 设置路由
 	protected.Get("/settings", settingsHandler.ShowSettings)
-	protected.Post("/api/settings/development_mode/toggle", settingsHandler.ToggleDevelopmentMode)
-	protected.Post("/api/settings/:setting/update", settingsHandler.UpdateSetting)
-	protected.Post("/api/cache/purge", settingsHandler.PurgeCache)
-	protected.Post("/api/settings/preset/apply", settingsHandler.ApplyPreset)
+	protected.Post("/api/settings/development_mode/toggle", write, settingsHandler.ToggleDevelopmentMode)
+	protected.Post("/api/settings/:setting/update", write, settingsHandler.UpdateSetting)
+	protected.Post("/api/cache/purge", write, settingsHandler.PurgeCache)
+	protected.Post("/api/settings/preset/apply", write, settingsHandler.ApplyPreset)
 
 	// SSL 证书管理路由
 	protected.Get("/certificates", certificateHandler.ShowCertificates)
 	protected.Get("/api/certificates/edge/:id/details", certificateHandler.GetEdgeCertificateDetails)
 	protected.Get("/api/certificates/origin/:id/download", certificateHandler.DownloadOriginCertificate)
-	protected.Post("/api/certificates/origin/create", certificateHandler.CreateOriginCertificate)
-	protected.Post("/api/certificates/origin/:id/revoke", certificateHandler.RevokeOriginCertificate)
+	protected.Post("/api/certificates/origin/create", write, certificateHandler.CreateOriginCertificate)
+	protected.Post("/api/certificates/origin/:id/revoke", write, certificateHandler.RevokeOriginCertificate)
+
+	// 审计日志路由
+	protected.Get("/audit", auditHandler.ShowAudit)
 
-	// 统计分析路由 - 已移除（Cloudflare Analytics API 实现复杂，需要 GraphQL）
-	// protected.Get("/analytics", analyticsHandler.ShowAnalytics)
+	// 统计分析路由（GraphQL Analytics API）
+	protected.Get("/analytics", analytics, analyticsHandler.ShowAnalytics)
 
 	// 健康检查
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/audit"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/credentials"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/fakecf"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/history"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/i18n"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/middleware"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/storage"
+)
+
+// testToken fakecf 后端接受的 API Token
+const testToken = "test-token"
+
+var initI18n sync.Once
+
+// newTestApp 创建使用 fakecf 后端的完整应用，数据只保存在内存和临时目录中
+func newTestApp(t *testing.T) (*fiber.App, *fakecf.Backend) {
+	t.Helper()
+	initI18n.Do(func() {
+		if err := i18n.Init(webFS); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	cfg := defaultConfig()
+	dir := t.TempDir()
+
+	store, err := storage.New(storage.TypeMemory, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	key, err := credentials.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault, err := credentials.NewVault(key, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	middleware.InitSession(time.Duration(cfg.Session.Expire)*time.Second, store, vault)
+
+	auditLog, err := audit.New("jsonl", dir+"/audit.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { auditLog.Close() })
+	historyStore, err := history.NewStore(dir+"/history", cfg.History.MaxSnapshots)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := fakecf.New()
+	backend.Secret = testToken
+	app := newApp(cfg)
+	setupRoutes(app, cfg, backend.Clients, nil, auditLog, historyStore)
+	return app, backend
+}
+
+// browser 保存会话 Cookie，依次向应用发送请求
+type browser struct {
+	t       *testing.T
+	app     *fiber.App
+	lang    string // Accept-Language
+	cookies map[string]*http.Cookie
+}
+
+func newBrowser(t *testing.T, app *fiber.App) *browser {
+	return &browser{t: t, app: app, lang: "zh-CN", cookies: make(map[string]*http.Cookie)}
+}
+
+// do 发送请求，form 非空时以表单提交；返回响应和正文
+func (b *browser) do(method, target string, form url.Values) (*http.Response, string) {
+	b.t.Helper()
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req := httptest.NewRequest(method, target, body)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.Header.Set("Accept-Language", b.lang)
+	for _, c := range b.cookies {
+		req.AddCookie(c)
+	}
+
+	resp, err := b.app.Test(req, -1)
+	if err != nil {
+		b.t.Fatalf("%s %s: %v", method, target, err)
+	}
+	defer resp.Body.Close()
+	for _, c := range resp.Cookies() {
+		b.cookies[c.Name] = c
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	return resp, string(data)
+}
+
+// expectRedirect 检查响应是否跳转到 location
+func expectRedirect(t *testing.T, resp *http.Response, body, location string) {
+	t.Helper()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != location {
+		t.Fatalf("got %d Location=%q, want redirect to %s; body:\n%s", resp.StatusCode, resp.Header.Get("Location"), location, body)
+	}
+}
+
+// login 使用 API Token 登录
+func (b *browser) login(token string) (*http.Response, string) {
+	return b.do("POST", "/login", url.Values{
+		"auth_type":      {"api_token"},
+		"cloudflare_api": {token},
+	})
+}
+
+func TestLogin(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	b := newBrowser(t, app)
+	resp, body := b.do("GET", "/zones", nil)
+	expectRedirect(t, resp, body, "/login")
+
+	resp, body = b.login("wrong-token")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "无效的凭证") {
+		t.Fatalf("login with a wrong token: %d\n%s", resp.StatusCode, body)
+	}
+	resp, body = b.do("GET", "/zones", nil)
+	expectRedirect(t, resp, body, "/login")
+
+	resp, body = b.login(testToken)
+	expectRedirect(t, resp, body, "/zones")
+	if resp, body = b.do("GET", "/zones", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /zones after login: %d\n%s", resp.StatusCode, body)
+	}
+
+	b.do("GET", "/logout", nil)
+	resp, body = b.do("GET", "/zones", nil)
+	expectRedirect(t, resp, body, "/login")
+}
+
+func TestZoneList(t *testing.T) {
+	app, backend := newTestApp(t)
+	backend.AddZone("alpha-site.com")
+	backend.AddZone("beta-site.org")
+
+	b := newBrowser(t, app)
+	b.login(testToken)
+
+	resp, body := b.do("GET", "/zones", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /zones: %d\n%s", resp.StatusCode, body)
+	}
+	for _, name := range []string{"alpha-site.com", "beta-site.org"} {
+		if !strings.Contains(body, name) {
+			t.Errorf("zone list does not contain %s", name)
+		}
+	}
+
+	_, body = b.do("GET", "/zones?name=beta", nil)
+	if strings.Contains(body, "alpha-site.com") || !strings.Contains(body, "beta-site.org") {
+		t.Errorf("zone list filtered by name=beta:\n%s", body)
+	}
+}
+
+func TestDNSRecordLifecycle(t *testing.T) {
+	app, backend := newTestApp(t)
+	zone := backend.AddZone("example.com")
+	zonePage := "/zone?zoneid=" + zone.ID + "&domain=example.com"
+
+	b := newBrowser(t, app)
+	b.login(testToken)
+
+	// 创建
+	resp, body := b.do("POST", "/dns/add", url.Values{
+		"zoneid":  {zone.ID},
+		"domain":  {"example.com"},
+		"type":    {"A"},
+		"name":    {"www"},
+		"content": {"192.0.2.1"},
+		"ttl":     {"1"},
+	})
+	expectRedirect(t, resp, body, zonePage)
+	records := backend.Records(zone.ID)
+	if len(records) != 1 || records[0].Name != "www.example.com" || records[0].Content != "192.0.2.1" {
+		t.Fatalf("records after create = %+v", records)
+	}
+	id := records[0].ID
+
+	_, body = b.do("GET", zonePage, nil)
+	if !strings.Contains(body, "www.example.com") || !strings.Contains(body, "192.0.2.1") {
+		t.Fatalf("zone page does not list the new record:\n%s", body)
+	}
+
+	// 校验失败时不调用接口，并在表单中显示字段错误
+	resp, body = b.do("POST", "/dns/add", url.Values{
+		"zoneid":  {zone.ID},
+		"domain":  {"example.com"},
+		"type":    {"A"},
+		"name":    {"bad"},
+		"content": {"not-an-ip"},
+		"ttl":     {"1"},
+	})
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "is-invalid") {
+		t.Fatalf("invalid record: %d\n%s", resp.StatusCode, body)
+	}
+	if n := len(backend.Records(zone.ID)); n != 1 {
+		t.Fatalf("invalid record was created, %d records", n)
+	}
+
+	// 编辑
+	editPage := "/dns/edit?zoneid=" + zone.ID + "&domain=example.com&recordid=" + id
+	if resp, body = b.do("GET", editPage, nil); resp.StatusCode != http.StatusOK || !strings.Contains(body, "192.0.2.1") {
+		t.Fatalf("GET edit page: %d\n%s", resp.StatusCode, body)
+	}
+	resp, body = b.do("POST", "/dns/edit", url.Values{
+		"zoneid":   {zone.ID},
+		"domain":   {"example.com"},
+		"recordid": {id},
+		"name":     {"www"},
+		"content":  {"192.0.2.2"},
+		"ttl":      {"3600"},
+	})
+	expectRedirect(t, resp, body, zonePage)
+	records = backend.Records(zone.ID)
+	if len(records) != 1 || records[0].Content != "192.0.2.2" || records[0].TTL != 3600 {
+		t.Fatalf("records after edit = %+v", records)
+	}
+
+	// 删除
+	resp, body = b.do("GET", "/dns/delete?zoneid="+zone.ID+"&domain=example.com&delete="+id, nil)
+	expectRedirect(t, resp, body, zonePage)
+	if records = backend.Records(zone.ID); len(records) != 0 {
+		t.Fatalf("records after delete = %+v", records)
+	}
+	_, body = b.do("GET", zonePage, nil)
+	if strings.Contains(body, "192.0.2.2") {
+		t.Fatalf("zone page still lists the deleted record:\n%s", body)
+	}
+}
+
+func TestDNSRecordWithData(t *testing.T) {
+	app, backend := newTestApp(t)
+	zone := backend.AddZone("example.com")
+
+	b := newBrowser(t, app)
+	b.login(testToken)
+
+	resp, body := b.do("POST", "/dns/add", url.Values{
+		"zoneid":        {zone.ID},
+		"domain":        {"example.com"},
+		"type":          {"SRV"},
+		"name":          {"_sip._tcp"},
+		"ttl":           {"1"},
+		"data_priority": {"10"},
+		"data_weight":   {"5"},
+		"data_port":     {"5060"},
+		"data_target":   {"sip.example.com"},
+	})
+	expectRedirect(t, resp, body, "/zone?zoneid="+zone.ID+"&domain=example.com")
+
+	records := backend.Records(zone.ID)
+	if len(records) != 1 {
+		t.Fatalf("records = %+v", records)
+	}
+	data, _ := records[0].Data.(map[string]interface{})
+	if records[0].Type != "SRV" || data["port"] != 5060 || data["target"] != "sip.example.com" {
+		t.Fatalf("SRV record = %+v", records[0])
+	}
+}
+
+func TestDNSRecordFieldErrorsAreLocalized(t *testing.T) {
+	app, backend := newTestApp(t)
+	zone := backend.AddZone("example.com")
+	record, err := backend.AddRecord(zone.ID, cloudflare.DNSRecord{Type: "A", Name: "www", Content: "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBrowser(t, app)
+	b.login(testToken)
+
+	form := url.Values{
+		"zoneid":        {zone.ID},
+		"domain":        {"example.com"},
+		"type":          {"SRV"},
+		"name":          {"_sip._tcp"},
+		"ttl":           {"1"},
+		"data_priority": {"10"},
+		"data_weight":   {"5"},
+		"data_port":     {"70000"},
+		"data_target":   {"sip.example.com"},
+	}
+	_, body := b.do("POST", "/dns/add", form)
+	if !strings.Contains(body, "端口必须在 0 ~ 65535 之间") {
+		t.Fatalf("SRV port error missing:\n%s", body)
+	}
+
+	// 批量修改 TTL 时无法解析的 TTL 不能按 0 处理
+	bulk := url.Values{"ids": {record.ID}, "bulk_action": {"ttl"}, "ttl": {"abc"}}
+	_, body = b.do("POST", "/api/dns/bulk?zoneid="+zone.ID+"&domain=example.com", bulk)
+	if !strings.Contains(body, "TTL 必须是整数") {
+		t.Fatalf("bulk TTL error missing:\n%s", body)
+	}
+	if records := backend.Records(zone.ID); records[0].TTL != 1 {
+		t.Fatalf("bulk TTL changed the record: %+v", records[0])
+	}
+}
+
+func TestEmailIssuesUseRequestLanguage(t *testing.T) {
+	app, backend := newTestApp(t)
+	zone := backend.AddZone("example.com")
+	if _, err := backend.AddRecord(zone.ID, cloudflare.DNSRecord{Type: "MX", Name: "@", Content: "mx.example.com", Priority: cloudflare.Uint16Ptr(10)}); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBrowser(t, app)
+	b.login(testToken)
+	page := "/email?zoneid=" + zone.ID + "&domain=example.com"
+
+	if _, body := b.do("GET", page, nil); !strings.Contains(body, "未配置 SPF 记录，收件方无法验证") {
+		t.Fatalf("Chinese SPF issue missing:\n%s", body)
+	}
+	b.lang = "en-US"
+	if _, body := b.do("GET", page, nil); !strings.Contains(body, "No SPF record; receivers cannot verify") {
+		t.Fatalf("English SPF issue missing:\n%s", body)
+	}
+}
+
+// TestAuditKeepsDeletedZone 删除 Zone 后，该操作仍显示在审计日志中
+func TestAuditKeepsDeletedZone(t *testing.T) {
+	app, backend := newTestApp(t)
+	zone := backend.AddZone("gone-site.com")
+
+	b := newBrowser(t, app)
+	b.login(testToken)
+
+	resp, body := b.do("POST", "/api/zone/delete?zoneid="+zone.ID, url.Values{"domain": {"gone-site.com"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete zone: %d %s", resp.StatusCode, body)
+	}
+
+	_, body = b.do("GET", "/audit", nil)
+	if !strings.Contains(body, "DeleteZone") || !strings.Contains(body, "gone-site.com") {
+		t.Fatalf("audit page does not show the deleted zone:\n%s", body)
+	}
+
+	// 另一个凭证看不到这条日志
+	other := newBrowser(t, app)
+	backend.Secret = "other-token"
+	resp, body = other.login("other-token")
+	expectRedirect(t, resp, body, "/zones")
+	resp, body = other.do("GET", "/audit", nil)
+	if resp.StatusCode != http.StatusOK || strings.Contains(body, "gone-site.com") {
+		t.Fatal("audit entry of another credential is visible")
+	}
+}
+
+// TestDNSAnalyticsUsesRecordCache 清理候选使用缓存中的记录列表，不再单独列出全部记录
+func TestDNSAnalyticsUsesRecordCache(t *testing.T) {
+	app, backend := newTestApp(t)
+	zone := backend.AddZone("example.com")
+	if _, err := backend.AddRecord(zone.ID, cloudflare.DNSRecord{Type: "A", Name: "cached", Content: "192.0.2.1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBrowser(t, app)
+	b.login(testToken)
+	if resp, body := b.do("GET", "/zone?zoneid="+zone.ID+"&domain=example.com", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /zone: %d\n%s", resp.StatusCode, body)
+	}
+
+	// 绕过应用直接添加的记录不在缓存中
+	if _, err := backend.AddRecord(zone.ID, cloudflare.DNSRecord{Type: "A", Name: "uncached", Content: "192.0.2.2"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, body := b.do("GET", "/api/dns/analytics?zoneid="+zone.ID+"&domain=example.com&days=30", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /api/dns/analytics: %d\n%s", resp.StatusCode, body)
+	}
+	if !strings.Contains(body, "cached.example.com") || strings.Contains(body, "uncached.example.com") {
+		t.Fatalf("cleanup candidates were not read from the record cache:\n%s", body)
+	}
+}
+
+// TestSSLVerificationStatus 证书验证状态的显示，以及在 Zone 中创建缺失的 DCV 记录
+func TestSSLVerificationStatus(t *testing.T) {
+	app, backend := newTestApp(t)
+	zone := backend.AddZone("example.com")
+	err := backend.SetSSLVerifications(zone.ID, []service.SSLVerification{
+		{
+			CertPackID:        "pack-pending",
+			CertificateStatus: "pending_validation",
+			ValidationMethod:  "txt",
+			Hosts:             []string{"example.com", "*.example.com"},
+			Records: []cloudflare.SSLValidationRecord{
+				{TxtName: "_acme-challenge.example.com", TxtValue: "token-existing"},
+				{TxtName: "_acme-challenge.example.com", TxtValue: "token-missing"},
+			},
+		},
+		{
+			CertPackID:         "pack-active",
+			CertificateStatus:  "active",
+			ValidationMethod:   "txt",
+			VerificationStatus: true,
+			Records: []cloudflare.SSLValidationRecord{
+				{TxtName: "_acme-challenge.example.com", TxtValue: "token-done"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第一条验证记录已在 Zone 中（内容带引号）
+	if _, err := backend.AddRecord(zone.ID, cloudflare.DNSRecord{Type: "TXT", Name: "_acme-challenge", Content: `"token-existing"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newBrowser(t, app)
+	b.login(testToken)
+	page := "/security?zoneid=" + zone.ID + "&domain=example.com"
+
+	resp, body := b.do("GET", page, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %d\n%s", page, resp.StatusCode, body)
+	}
+	for _, want := range []string{"pack-pending", "待验证", "token-missing", "pack-active", "已验证", `name="cert_pack" value="pack-pending"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("security page does not contain %q", want)
+		}
+	}
+	// 已验证的证书包不显示验证记录，也不能创建
+	if strings.Contains(body, "token-done") || strings.Contains(body, `value="pack-active"`) {
+		t.Error("records of the active certificate pack are shown")
+	}
+	if got := strings.Count(body, "已存在"); got != 1 {
+		t.Errorf("%d records marked as existing, want 1", got)
+	}
+	if got := strings.Count(body, "未添加"); got != 1 {
+		t.Errorf("%d records marked as missing, want 1", got)
+	}
+
+	// 只创建缺失的记录
+	resp, body = b.do("POST", "/security/dcv", url.Values{
+		"zoneid":    {zone.ID},
+		"domain":    {"example.com"},
+		"cert_pack": {"pack-pending"},
+	})
+	expectRedirect(t, resp, body, page+"&success=dcv_created&count=1")
+
+	var txt []string
+	for _, r := range backend.Records(zone.ID) {
+		if r.Type == "TXT" && r.Name == "_acme-challenge.example.com" {
+			txt = append(txt, strings.Trim(r.Content, `"`))
+		}
+	}
+	if len(txt) != 2 || !slices.Contains(txt, "token-missing") {
+		t.Fatalf("TXT records after creating DCV records = %v", txt)
+	}
+
+	_, body = b.do("GET", page+"&success=dcv_created&count=1", nil)
+	if !strings.Contains(body, "已创建 1 条验证记录") {
+		t.Error("success message missing")
+	}
+	if strings.Count(body, "已存在") != 2 || strings.Contains(body, "未添加") {
+		t.Errorf("records are not all marked as existing after creation:\n%s", body)
+	}
+
+	// 再次提交时没有需要添加的记录
+	resp, body = b.do("POST", "/security/dcv", url.Values{"zoneid": {zone.ID}, "domain": {"example.com"}})
+	expectRedirect(t, resp, body, page+"&error=dcv_nothing_to_add")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cloudflare/cloudflare-go"
+
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/config"
+	"github.com/zhufengme/Cloudflare-DNS-Manager/internal/service"
+)
+
+// runSync 执行 sync 子命令：按声明式配置文件同步 DNS 记录
+// 默认只输出变更计划，加 --apply 才会真正修改
+func runSync(args []string) int {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	stateFile := fs.String("f", "", "DNS 配置文件路径（YAML/JSON）")
+	apply := fs.Bool("apply", false, "执行变更（默认仅输出计划）")
+	prune := fs.Bool("prune", false, "删除配置文件中不存在的记录")
+	managedBy := fs.String("managed-by", "", "托管标记，写入记录备注；只修改带该标记的记录")
+	email := fs.String("email", os.Getenv("CLOUDFLARE_EMAIL"), "Cloudflare 邮箱（默认读取 CLOUDFLARE_EMAIL）")
+	apiKey := fs.String("api-key", os.Getenv("CLOUDFLARE_API_KEY"), "Global API Key（默认读取 CLOUDFLARE_API_KEY）")
+	apiToken := fs.String("api-token", os.Getenv("CLOUDFLARE_API_TOKEN"), "API Token（默认读取 CLOUDFLARE_API_TOKEN，优先于 Global API Key）")
+	configFile := fs.String("config", "", "配置文件路径，读取其中的 api 网络设置（代理、超时、重试等）")
+	fs.Parse(args)
+
+	if *stateFile == "" {
+		fmt.Fprintln(os.Stderr, "Usage: cf-dns-manager sync -f zone.yaml [--apply] [--prune] [--managed-by TAG]")
+		fs.PrintDefaults()
+		return 2
+	}
+	if *apiToken == "" && (*email == "" || *apiKey == "") {
+		fmt.Fprintln(os.Stderr, "Error: missing credentials, set CLOUDFLARE_API_TOKEN or CLOUDFLARE_EMAIL and CLOUDFLARE_API_KEY")
+		return 2
+	}
+
+	state, err := service.LoadDesiredState(*stateFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	clients := service.NewClient
+	if *configFile != "" {
+		cfg, err := config.Load(*configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		pool, err := service.NewClientPool(apiHTTPOptions(cfg), apiBudgetOptions(cfg))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		clients = pool.Clients
+	}
+
+	var cfService service.Client
+	if *apiToken != "" {
+		cfService, err = clients(service.AuthTypeAPIToken, "", *apiToken)
+	} else {
+		cfService, err = clients(service.AuthTypeAPIKey, *email, *apiKey)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	failed := false
+
+	for _, zone := range state.Zones {
+		fmt.Printf("Zone %s\n", zone.Name)
+
+		zoneID, err := cfService.ZoneIDByName(ctx, zone.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  Error: %v\n", err)
+			failed = true
+			continue
+		}
+
+		current, err := service.ListAllDNSRecords(ctx, cfService, zoneID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  Error: failed to fetch DNS records: %v\n", err)
+			failed = true
+			continue
+		}
+
+		plan := service.DiffManagedRecords(current, zone.DNSRecords(), *prune, *managedBy)
+		printPlan(plan)
+
+		if !*apply || plan.Empty() {
+			continue
+		}
+
+		for _, result := range service.ApplyPlan(ctx, cfService, zoneID, plan) {
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "  FAILED %s %s: %v\n", result.Change.Action, formatRecord(result.Change.Record()), result.Err)
+				failed = true
+				continue
+			}
+			fmt.Printf("  done   %s %s\n", result.Change.Action, formatRecord(result.Change.Record()))
+		}
+	}
+
+	if !*apply {
+		fmt.Println("\nDry run only, re-run with --apply to make these changes.")
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// printPlan 输出变更计划
+func printPlan(plan *service.ChangePlan) {
+	for _, change := range plan.Deletes {
+		fmt.Printf("  - %s\n", formatRecord(change.Current))
+	}
+	for _, change := range plan.Updates {
+		fmt.Printf("  ~ %s\n", formatRecord(change.Current))
+		fmt.Printf("    → %s\n", formatRecord(change.Desired))
+	}
+	for _, change := range plan.Creates {
+		fmt.Printf("  + %s\n", formatRecord(change.Desired))
+	}
+	fmt.Printf("  %d to create, %d to update, %d to delete, %d unchanged\n",
+		len(plan.Creates), len(plan.Updates), len(plan.Deletes), plan.Unchanged)
+}
+
+// formatRecord 单行展示记录
+func formatRecord(record *cloudflare.DNSRecord) string {
+	s := fmt.Sprintf("%s %s %s", record.Type, record.Name, record.Content)
+	if record.Priority != nil && record.Type == "MX" {
+		s = fmt.Sprintf("%s %s %d %s", record.Type, record.Name, *record.Priority, record.Content)
+	}
+	if record.TTL > 1 {
+		s += fmt.Sprintf(" ttl=%d", record.TTL)
+	} else {
+		s += " ttl=auto"
+	}
+	if record.Proxied != nil && *record.Proxied {
+		s += " proxied"
+	}
+	return s
+}